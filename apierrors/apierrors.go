@@ -0,0 +1,239 @@
+// Package apierrors defines the errors of the API, each with a stable code
+// from the catalog, and writes them as RFC 7807 problem details
+package apierrors
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/TranQuocToan1996/ginProject/validation"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ContentType is the media type of problem details
+const ContentType = "application/problem+json"
+
+// Codes of the catalog
+const (
+	CodeBadRequest           = "bad_request"
+	CodeInvalidID            = "invalid_id"
+	CodeInvalidBody          = "invalid_body"
+	CodeValidation           = "validation_failed"
+	CodeUnprocessable        = "unprocessable"
+	CodeUnauthorized         = "unauthorized"
+	CodeForbidden            = "forbidden"
+	CodeNotFound             = "not_found"
+	CodeNotAcceptable        = "not_acceptable"
+	CodeConflict             = "conflict"
+	CodePreconditionFailed   = "precondition_failed"
+	CodePayloadTooLarge      = "payload_too_large"
+	CodeUnsupportedMedia     = "unsupported_media_type"
+	CodePreconditionRequired = "precondition_required"
+	CodeInternal             = "internal"
+)
+
+// Entry describes a code of the catalog
+type Entry struct {
+	Code        string `json:"code"`
+	Status      int    `json:"status"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+}
+
+// Catalog lists every code an error of the API can have
+var Catalog = []Entry{
+	{CodeBadRequest, http.StatusBadRequest, "Bad request", "A parameter of the request is missing or malformed."},
+	{CodeInvalidID, http.StatusBadRequest, "Invalid id", "An id of the request isn't a 24 character hex object id."},
+	{CodeInvalidBody, http.StatusBadRequest, "Invalid request body", "The body isn't JSON, or a field has the wrong type."},
+	{CodeValidation, http.StatusUnprocessableEntity, "Validation failed", "Fields of the body break a rule, they are listed in fields."},
+	{CodeUnprocessable, http.StatusUnprocessableEntity, "Unprocessable request", "The request is well formed but can't be applied to the resource."},
+	{CodeUnauthorized, http.StatusUnauthorized, "Unauthorized", "The request needs a valid session, API key or token."},
+	{CodeForbidden, http.StatusForbidden, "Forbidden", "The signed in user isn't allowed to do this."},
+	{CodeNotFound, http.StatusNotFound, "Not found", "The resource doesn't exist or isn't visible to the signed in user."},
+	{CodeNotAcceptable, http.StatusNotAcceptable, "Not acceptable", "No media type of the Accept header can represent the response."},
+	{CodeConflict, http.StatusConflict, "Conflict", "The request conflicts with the current state of the resource."},
+	{CodePreconditionFailed, http.StatusPreconditionFailed, "Precondition failed", "The resource has changed since the entity tag of If-Match."},
+	{CodePayloadTooLarge, http.StatusRequestEntityTooLarge, "Payload too large", "The body, or an image it holds, is larger than allowed."},
+	{CodeUnsupportedMedia, http.StatusUnsupportedMediaType, "Unsupported media type", "The Content-Type of the body isn't accepted by the endpoint."},
+	{CodePreconditionRequired, http.StatusPreconditionRequired, "Precondition required", "The request has to carry an If-Match header."},
+	{CodeInternal, http.StatusInternalServerError, "Internal error", "The server failed to handle the request."},
+}
+
+// Lookup returns the catalog entry of code
+func Lookup(code string) (Entry, bool) {
+	for _, entry := range Catalog {
+		if entry.Code == code {
+			return entry, true
+		}
+	}
+	return Entry{}, false
+}
+
+// Error is an error of the API
+type Error struct {
+	Code   string
+	Detail string
+	Fields validation.Errors
+	Err    error
+}
+
+func (e *Error) Error() string {
+	if e.Detail != "" {
+		return e.Detail
+	}
+	return e.Code
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Status returns the http status of the error
+func (e *Error) Status() int {
+	entry, ok := Lookup(e.Code)
+	if !ok {
+		return http.StatusInternalServerError
+	}
+	return entry.Status
+}
+
+// New returns an error of code
+func New(code, detail string) *Error {
+	return &Error{Code: code, Detail: detail}
+}
+
+func BadRequest(detail string) *Error           { return New(CodeBadRequest, detail) }
+func Unprocessable(detail string) *Error        { return New(CodeUnprocessable, detail) }
+func Unauthorized(detail string) *Error         { return New(CodeUnauthorized, detail) }
+func Forbidden(detail string) *Error            { return New(CodeForbidden, detail) }
+func NotFound(detail string) *Error             { return New(CodeNotFound, detail) }
+func NotAcceptable(detail string) *Error        { return New(CodeNotAcceptable, detail) }
+func Conflict(detail string) *Error             { return New(CodeConflict, detail) }
+func PreconditionFailed(detail string) *Error   { return New(CodePreconditionFailed, detail) }
+func PreconditionRequired(detail string) *Error { return New(CodePreconditionRequired, detail) }
+func PayloadTooLarge(detail string) *Error      { return New(CodePayloadTooLarge, detail) }
+func UnsupportedMedia(detail string) *Error     { return New(CodeUnsupportedMedia, detail) }
+
+// Internal wraps an unexpected error
+func Internal(err error) *Error {
+	return &Error{Code: CodeInternal, Detail: err.Error(), Err: err}
+}
+
+// Validation returns the error of a body whose fields break rules
+func Validation(fields validation.Errors) *Error {
+	return &Error{Code: CodeValidation, Detail: "Fields of the request body are invalid", Fields: fields}
+}
+
+// InvalidInput returns the error of a malformed id or body: invalid_id for
+// an id, invalid_body for a body that isn't the expected JSON,
+// validation_failed for one breaking rules and bad_request otherwise. Errors
+// of the API, such as an unsupported media type, are returned unchanged.
+func InvalidInput(err error) *Error {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr
+	}
+	if err == primitive.ErrInvalidHex {
+		return &Error{Code: CodeInvalidID, Detail: "id must be a 24 character hex string", Err: err}
+	}
+	fields, ok := validation.FromError(err).(validation.Errors)
+	if !ok {
+		return &Error{Code: CodeBadRequest, Detail: err.Error(), Err: err}
+	}
+	for _, field := range fields {
+		if field.Code == validation.InvalidJSON || field.Code == validation.InvalidType {
+			return &Error{Code: CodeInvalidBody, Detail: "Request body isn't the expected JSON", Fields: fields, Err: err}
+		}
+	}
+	return Validation(fields)
+}
+
+// From maps any error to an Error: invalid ids and documents not found get
+// their own codes, duplicate keys are conflicts and the others internal errors
+func From(err error) *Error {
+	var apiErr *Error
+	var fields validation.Errors
+	switch {
+	case errors.As(err, &apiErr):
+		return apiErr
+	case err == primitive.ErrInvalidHex, errors.As(err, &fields):
+		return InvalidInput(err)
+	case errors.Is(err, mongo.ErrNoDocuments):
+		return &Error{Code: CodeNotFound, Detail: "Resource not found", Err: err}
+	case mongo.IsDuplicateKeyError(err):
+		return &Error{Code: CodeConflict, Detail: "Resource already exists", Err: err}
+	}
+	return Internal(err)
+}
+
+// Problem is an RFC 7807 problem details object, extended with the code of
+// the error and the invalid fields of validation errors
+type Problem struct {
+	Type     string            `json:"type"`
+	Title    string            `json:"title"`
+	Status   int               `json:"status"`
+	Detail   string            `json:"detail,omitempty"`
+	Instance string            `json:"instance,omitempty"`
+	Code     string            `json:"code"`
+	Fields   validation.Errors `json:"fields,omitempty"`
+}
+
+// TypeURI returns the type of the problems of code, a link to its entry of
+// the catalog served at GET /errors
+func TypeURI(code string) string {
+	return "/errors#" + code
+}
+
+// ProblemOf returns the problem details of err for the request of instance
+func ProblemOf(err error, instance string) Problem {
+	apiErr := From(err)
+	entry, ok := Lookup(apiErr.Code)
+	if !ok {
+		entry, _ = Lookup(CodeInternal)
+	}
+	return Problem{
+		Type:     TypeURI(entry.Code),
+		Title:    entry.Title,
+		Status:   entry.Status,
+		Detail:   apiErr.Detail,
+		Instance: instance,
+		Code:     entry.Code,
+		Fields:   apiErr.Fields,
+	}
+}
+
+// Write writes err as problem details
+func Write(c *gin.Context, err error) {
+	problem := ProblemOf(err, c.Request.URL.Path)
+	if problem.Status >= http.StatusInternalServerError {
+		log.Println(c.Request.Method, c.Request.URL.Path, err)
+	}
+	c.Header("Content-Type", ContentType)
+	c.JSON(problem.Status, problem)
+}
+
+// Middleware writes the last error handlers added with c.Error as problem
+// details, unless they already wrote a response
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+		Write(c, c.Errors.Last().Err)
+	}
+}
+
+// Abort stops the handler chain with err, for middlewares
+func Abort(c *gin.Context, err error) {
+	c.Error(err)
+	c.Abort()
+}
+
+// ListCatalog returns the catalog of error codes
+func ListCatalog(c *gin.Context) {
+	c.JSON(http.StatusOK, Catalog)
+}
@@ -0,0 +1,99 @@
+package apierrors
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/TranQuocToan1996/ginProject/models"
+	"github.com/TranQuocToan1996/ginProject/validation"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestProblemOf(t *testing.T) {
+	var syntax error = json.Unmarshal([]byte("{"), &struct{}{})
+	invalid := validation.Struct(&models.Recipe{Ingredients: []string{"1 egg"}})
+	for i, tt := range []struct {
+		err    error
+		status int
+		code   string
+		fields int
+	}{
+		{NotFound("Recipe not found"), http.StatusNotFound, CodeNotFound, 0},
+		{Conflict("User admin already exists"), http.StatusConflict, CodeConflict, 0},
+		{fmt.Errorf("wrapped: %w", PreconditionFailed("Recipe has been modified")), http.StatusPreconditionFailed, CodePreconditionFailed, 0},
+		{mongo.ErrNoDocuments, http.StatusNotFound, CodeNotFound, 0},
+		{primitive.ErrInvalidHex, http.StatusBadRequest, CodeInvalidID, 0},
+		{InvalidInput(primitive.ErrInvalidHex), http.StatusBadRequest, CodeInvalidID, 0},
+		{InvalidInput(syntax), http.StatusBadRequest, CodeInvalidBody, 1},
+		{InvalidInput(invalid), http.StatusUnprocessableEntity, CodeValidation, 1},
+		{invalid, http.StatusUnprocessableEntity, CodeValidation, 1},
+		{InvalidInput(errors.New("units must be metric or us")), http.StatusBadRequest, CodeBadRequest, 0},
+		{InvalidInput(UnsupportedMedia("Content-Type must be application/json")), http.StatusUnsupportedMediaType, CodeUnsupportedMedia, 0},
+		{NotAcceptable("Responses are available as application/json"), http.StatusNotAcceptable, CodeNotAcceptable, 0},
+		{errors.New("connection refused"), http.StatusInternalServerError, CodeInternal, 0},
+		{New("unknown", "not in the catalog"), http.StatusInternalServerError, CodeInternal, 0},
+	} {
+		t.Run(fmt.Sprintf("%v", i), func(t *testing.T) {
+			problem := ProblemOf(tt.err, "/recipes")
+			if problem.Status != tt.status || problem.Code != tt.code {
+				t.Errorf("want %v %v; got %v %v", tt.status, tt.code, problem.Status, problem.Code)
+			}
+			if len(problem.Fields) != tt.fields {
+				t.Errorf("want %v fields; got %v", tt.fields, problem.Fields)
+			}
+			if problem.Type != TypeURI(problem.Code) || problem.Instance != "/recipes" {
+				t.Errorf("want type %v and instance /recipes; got %v %v", TypeURI(problem.Code), problem.Type, problem.Instance)
+			}
+		})
+	}
+}
+
+func TestCatalog(t *testing.T) {
+	seen := make(map[string]bool)
+	for _, entry := range Catalog {
+		if seen[entry.Code] {
+			t.Errorf("duplicate code %v", entry.Code)
+		}
+		seen[entry.Code] = true
+		if http.StatusText(entry.Status) == "" || entry.Title == "" || entry.Description == "" {
+			t.Errorf("incomplete entry %v", entry)
+		}
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	for i, tt := range []struct {
+		handler gin.HandlerFunc
+		status  int
+		problem bool
+	}{
+		{func(c *gin.Context) { c.Error(NotFound("Recipe not found")) }, http.StatusNotFound, true},
+		{func(c *gin.Context) { Abort(c, Unauthorized("Not logged")) }, http.StatusUnauthorized, true},
+		{func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{}) }, http.StatusOK, false},
+		{func(c *gin.Context) {
+			c.Error(errors.New("logged only"))
+			c.JSON(http.StatusCreated, gin.H{})
+		}, http.StatusCreated, false},
+	} {
+		t.Run(fmt.Sprintf("%v", i), func(t *testing.T) {
+			router := gin.New()
+			router.Use(Middleware())
+			router.GET("/", tt.handler)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+			if w.Code != tt.status {
+				t.Errorf("want status %v; got %v", tt.status, w.Code)
+			}
+			if problem := w.Header().Get("Content-Type") == ContentType; problem != tt.problem {
+				t.Errorf("want problem %v; got Content-Type %v", tt.problem, w.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
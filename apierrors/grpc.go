@@ -0,0 +1,90 @@
+package apierrors
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/runtime/protoiface"
+)
+
+// ErrorDomain is the domain of the ErrorInfo details of gRPC errors
+const ErrorDomain = "recipes"
+
+// grpcCodes maps the http statuses of the catalog to gRPC codes
+var grpcCodes = map[int]codes.Code{
+	http.StatusBadRequest:            codes.InvalidArgument,
+	http.StatusUnprocessableEntity:   codes.InvalidArgument,
+	http.StatusUnauthorized:          codes.Unauthenticated,
+	http.StatusForbidden:             codes.PermissionDenied,
+	http.StatusNotFound:              codes.NotFound,
+	http.StatusNotAcceptable:         codes.InvalidArgument,
+	http.StatusConflict:              codes.AlreadyExists,
+	http.StatusPreconditionFailed:    codes.FailedPrecondition,
+	http.StatusRequestEntityTooLarge: codes.ResourceExhausted,
+	http.StatusUnsupportedMediaType:  codes.InvalidArgument,
+	http.StatusPreconditionRequired:  codes.FailedPrecondition,
+}
+
+// GRPCStatus returns the gRPC status of err. Its details carry the code of
+// the catalog in an ErrorInfo and the invalid fields in a BadRequest.
+func GRPCStatus(err error) *status.Status {
+	if s, ok := status.FromError(err); ok {
+		return s
+	}
+	apiErr := From(err)
+	code, ok := grpcCodes[apiErr.Status()]
+	if !ok {
+		code = codes.Internal
+	}
+	s := status.New(code, apiErr.Error())
+	details := []protoiface.MessageV1{&errdetails.ErrorInfo{Reason: apiErr.Code, Domain: ErrorDomain}}
+	if len(apiErr.Fields) > 0 {
+		request := &errdetails.BadRequest{}
+		for _, field := range apiErr.Fields {
+			request.FieldViolations = append(request.FieldViolations, &errdetails.BadRequest_FieldViolation{
+				Field:       field.Field,
+				Description: field.Message,
+			})
+		}
+		details = append(details, request)
+	}
+	if detailed, err := s.WithDetails(details...); err == nil {
+		return detailed
+	}
+	return s
+}
+
+// UnaryInterceptor converts the errors of unary calls to gRPC statuses, as
+// Middleware writes the errors of handlers as problem details
+func UnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		resp, err := handler(ctx, req)
+		if err != nil {
+			return nil, grpcError(info.FullMethod, err)
+		}
+		return resp, nil
+	}
+}
+
+// StreamInterceptor converts the errors of streaming calls to gRPC statuses
+func StreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := handler(srv, stream); err != nil {
+			return grpcError(info.FullMethod, err)
+		}
+		return nil
+	}
+}
+
+func grpcError(method string, err error) error {
+	s := GRPCStatus(err)
+	if s.Code() == codes.Internal || s.Code() == codes.Unknown {
+		log.Println(method, err)
+	}
+	return s.Err()
+}
@@ -0,0 +1,66 @@
+package apierrors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/TranQuocToan1996/ginProject/models"
+	"github.com/TranQuocToan1996/ginProject/validation"
+	"go.mongodb.org/mongo-driver/mongo"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestGRPCStatus(t *testing.T) {
+	invalid := validation.Struct(&models.Recipe{Ingredients: []string{"1 egg"}})
+	for i, tt := range []struct {
+		err    error
+		code   codes.Code
+		reason string
+		fields int
+	}{
+		{NotFound("Recipe not found"), codes.NotFound, CodeNotFound, 0},
+		{mongo.ErrNoDocuments, codes.NotFound, CodeNotFound, 0},
+		{Unauthorized("Invalid token"), codes.Unauthenticated, CodeUnauthorized, 0},
+		{fmt.Errorf("wrapped: %w", PreconditionFailed("Recipe has been modified")), codes.FailedPrecondition, CodePreconditionFailed, 0},
+		{InvalidInput(invalid), codes.InvalidArgument, CodeValidation, 1},
+		{errors.New("connection refused"), codes.Internal, CodeInternal, 0},
+		{status.Error(codes.Canceled, "canceled"), codes.Canceled, "", 0},
+	} {
+		t.Run(fmt.Sprintf("%v", i), func(t *testing.T) {
+			s := GRPCStatus(tt.err)
+			reason, fields := "", 0
+			for _, detail := range s.Details() {
+				switch detail := detail.(type) {
+				case *errdetails.ErrorInfo:
+					reason = detail.Reason
+				case *errdetails.BadRequest:
+					fields = len(detail.FieldViolations)
+				}
+			}
+			if s.Code() != tt.code || reason != tt.reason || fields != tt.fields {
+				t.Errorf("want %v %v %v; got %v %v %v", tt.code, tt.reason, tt.fields, s.Code(), reason, fields)
+			}
+		})
+	}
+}
+
+func TestUnaryInterceptor(t *testing.T) {
+	info := &grpc.UnaryServerInfo{FullMethod: "/recipes.v1.RecipeService/Get"}
+	_, err := UnaryInterceptor()(context.Background(), nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, NotFound("Recipe not found")
+	})
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("want %v; got %v", codes.NotFound, err)
+	}
+	resp, err := UnaryInterceptor()(context.Background(), nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "recipe", nil
+	})
+	if resp != "recipe" || err != nil {
+		t.Errorf("want recipe; got %v %v", resp, err)
+	}
+}
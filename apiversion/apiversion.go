@@ -0,0 +1,97 @@
+// Package apiversion holds the middlewares telling the versions of the API
+// apart: deprecation headers for v1 and the response envelope of v2
+package apiversion
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Deprecated marks the responses of a deprecated version with the Deprecation
+// (RFC 9745) and Sunset (RFC 8594) headers, linking to its successor
+func Deprecated(since, sunset time.Time, successor string) gin.HandlerFunc {
+	deprecation := fmt.Sprintf("@%v", since.Unix())
+	sunsetDate := sunset.UTC().Format(http.TimeFormat)
+	link := fmt.Sprintf(`<%v>; rel="successor-version"`, successor)
+	return func(c *gin.Context) {
+		c.Header("Deprecation", deprecation)
+		c.Header("Sunset", sunsetDate)
+		c.Header("Link", link)
+		c.Next()
+	}
+}
+
+// Envelope is the body of the successful JSON responses of v2
+type Envelope struct {
+	Data json.RawMessage `json:"data"`
+	Meta Meta            `json:"meta"`
+}
+
+// Meta describes the data of an envelope, Count is set for lists
+type Meta struct {
+	Version string `json:"version"`
+	Count   *int   `json:"count,omitempty"`
+}
+
+// Enveloped wraps the successful JSON responses of handlers in an Envelope
+// of version. Errors, which are problem details, and other media types are
+// written unchanged.
+func Enveloped(version string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		writer := &bufferedWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+		c.Writer = writer.ResponseWriter
+		if writer.body.Len() == 0 {
+			return
+		}
+		body := writer.body.Bytes()
+		if wrapped, ok := wrap(body, c.Writer.Status(), c.Writer.Header().Get("Content-Type"), version); ok {
+			body = wrapped
+		}
+		c.Writer.Write(body)
+	}
+}
+
+// wrap returns body in an envelope when it is a successful JSON response
+func wrap(body []byte, status int, contentType, version string) ([]byte, bool) {
+	if status < 200 || status >= 300 || !strings.HasPrefix(contentType, gin.MIMEJSON) {
+		return nil, false
+	}
+	meta := Meta{Version: version}
+	var items []json.RawMessage
+	if bytes.HasPrefix(bytes.TrimSpace(body), []byte("[")) && json.Unmarshal(body, &items) == nil {
+		count := len(items)
+		meta.Count = &count
+	}
+	wrapped, err := json.Marshal(Envelope{Data: body, Meta: meta})
+	if err != nil {
+		return nil, false
+	}
+	return wrapped, true
+}
+
+// bufferedWriter holds the body written by handlers until the envelope is
+// written, headers and status go to the underlying writer
+type bufferedWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *bufferedWriter) Write(data []byte) (int, error) {
+	return w.body.Write(data)
+}
+
+func (w *bufferedWriter) WriteString(s string) (int, error) {
+	return w.body.WriteString(s)
+}
+
+func (w *bufferedWriter) Written() bool {
+	return w.body.Len() > 0 || w.ResponseWriter.Written()
+}
@@ -0,0 +1,57 @@
+package apiversion
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestDeprecated(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	since := time.Date(2026, time.November, 1, 0, 0, 0, 0, time.UTC)
+	router.Use(Deprecated(since, since.AddDate(0, 6, 0), "/v2"))
+	router.GET("/recipes", func(c *gin.Context) { c.JSON(http.StatusOK, []string{}) })
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/recipes", nil))
+	for header, want := range map[string]string{
+		"Deprecation": "@1793491200",
+		"Sunset":      "Sat, 01 May 2027 00:00:00 GMT",
+		"Link":        `</v2>; rel="successor-version"`,
+	} {
+		if got := w.Header().Get(header); got != want {
+			t.Errorf("want %v %v; got %v", header, want, got)
+		}
+	}
+}
+
+func TestEnveloped(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	for i, tt := range []struct {
+		handler gin.HandlerFunc
+		status  int
+		body    string
+	}{
+		{func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"name": "Pancakes"}) }, http.StatusOK, `{"data":{"name":"Pancakes"},"meta":{"version":"v2"}}`},
+		{func(c *gin.Context) { c.JSON(http.StatusCreated, []int{1, 2}) }, http.StatusCreated, `{"data":[1,2],"meta":{"version":"v2","count":2}}`},
+		{func(c *gin.Context) { c.JSON(http.StatusOK, []int{}) }, http.StatusOK, `{"data":[],"meta":{"version":"v2","count":0}}`},
+		{func(c *gin.Context) { c.JSON(http.StatusNotFound, gin.H{"code": "not_found"}) }, http.StatusNotFound, `{"code":"not_found"}`},
+		{func(c *gin.Context) { c.Data(http.StatusOK, "text/plain", []byte("2 eggs")) }, http.StatusOK, "2 eggs"},
+		{func(c *gin.Context) { c.Status(http.StatusNotModified) }, http.StatusNotModified, ""},
+	} {
+		t.Run(fmt.Sprintf("%v", i), func(t *testing.T) {
+			router := gin.New()
+			router.Use(Enveloped("v2"))
+			router.GET("/", tt.handler)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+			if w.Code != tt.status || w.Body.String() != tt.body {
+				t.Errorf("want %v %v; got %v %v", tt.status, tt.body, w.Code, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,20 @@
+package blobstore
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// ErrNotFound is returned when no blob is stored under a key
+var ErrNotFound = errors.New("blobstore: blob not found")
+
+// BlobStore stores binary objects such as recipe images under slash separated keys
+type BlobStore interface {
+	// Put stores data under key, replacing any previous blob
+	Put(ctx context.Context, key, contentType string, data []byte) error
+	// Open returns the blob stored under key and its content type
+	Open(ctx context.Context, key string) (io.ReadCloser, string, error)
+	// Delete removes the blob stored under key
+	Delete(ctx context.Context, key string) error
+}
@@ -0,0 +1,57 @@
+package blobstore
+
+import (
+	"bytes"
+	"context"
+	"io"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/gridfs"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// GridFSStore keeps blobs in a mongo GridFS bucket, using the key as file id
+type GridFSStore struct {
+	bucket *gridfs.Bucket
+}
+
+func NewGridFSStore(db *mongo.Database, bucketName string) (*GridFSStore, error) {
+	bucket, err := gridfs.NewBucket(db, options.GridFSBucket().SetName(bucketName))
+	if err != nil {
+		return nil, err
+	}
+	return &GridFSStore{bucket: bucket}, nil
+}
+
+func (store *GridFSStore) Put(ctx context.Context, key, contentType string, data []byte) error {
+	// GridFS files are immutable, replacing a blob means deleting it first
+	if err := store.bucket.Delete(key); err != nil && err != gridfs.ErrFileNotFound {
+		return err
+	}
+	opts := options.GridFSUpload().SetMetadata(bson.M{"contentType": contentType})
+	return store.bucket.UploadFromStreamWithID(key, key, bytes.NewReader(data), opts)
+}
+
+func (store *GridFSStore) Open(ctx context.Context, key string) (io.ReadCloser, string, error) {
+	stream, err := store.bucket.OpenDownloadStream(key)
+	if err == gridfs.ErrFileNotFound {
+		return nil, "", ErrNotFound
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	contentType := ""
+	if metadata := stream.GetFile().Metadata; metadata != nil {
+		contentType, _ = metadata.Lookup("contentType").StringValueOK()
+	}
+	return stream, contentType, nil
+}
+
+func (store *GridFSStore) Delete(ctx context.Context, key string) error {
+	err := store.bucket.Delete(key)
+	if err == gridfs.ErrFileNotFound {
+		return ErrNotFound
+	}
+	return err
+}
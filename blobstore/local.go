@@ -0,0 +1,73 @@
+package blobstore
+
+import (
+	"context"
+	"io"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// LocalStore keeps blobs as files below a directory of the local filesystem
+type LocalStore struct {
+	dir string
+}
+
+func NewLocalStore(dir string) (*LocalStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &LocalStore{dir: dir}, nil
+}
+
+// path maps a key to a file inside the store directory, rejecting keys that
+// would escape it
+func (store *LocalStore) path(key string) (string, error) {
+	clean := path.Clean("/" + key)
+	if clean == "/" || strings.Contains(key, "..") {
+		return "", ErrNotFound
+	}
+	return filepath.Join(store.dir, filepath.FromSlash(clean)), nil
+}
+
+func (store *LocalStore) Put(ctx context.Context, key, contentType string, data []byte) error {
+	file, err := store.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(file, data, 0o644)
+}
+
+// Open returns the file stored under key. The content type is derived from
+// the extension of the key.
+func (store *LocalStore) Open(ctx context.Context, key string) (io.ReadCloser, string, error) {
+	file, err := store.path(key)
+	if err != nil {
+		return nil, "", err
+	}
+	f, err := os.Open(file)
+	if os.IsNotExist(err) {
+		return nil, "", ErrNotFound
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	return f, mime.TypeByExtension(path.Ext(key)), nil
+}
+
+func (store *LocalStore) Delete(ctx context.Context, key string) error {
+	file, err := store.path(key)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(file)
+	if os.IsNotExist(err) {
+		return ErrNotFound
+	}
+	return err
+}
@@ -0,0 +1,36 @@
+package blobstore
+
+import (
+	"context"
+	"io"
+	"testing"
+)
+
+func TestLocalStore(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Put(ctx, "recipes/1/thumbnail.png", "image/png", []byte("data")); err != nil {
+		t.Fatal(err)
+	}
+	reader, contentType, err := store.Open(ctx, "recipes/1/thumbnail.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(reader)
+	reader.Close()
+	if string(data) != "data" || contentType != "image/png" {
+		t.Errorf("want data image/png; got %v %v", string(data), contentType)
+	}
+	if err := store.Delete(ctx, "recipes/1/thumbnail.png"); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := store.Open(ctx, "recipes/1/thumbnail.png"); err != ErrNotFound {
+		t.Errorf("want %v; got %v", ErrNotFound, err)
+	}
+	if _, _, err := store.Open(ctx, "../../etc/passwd"); err != ErrNotFound {
+		t.Errorf("want keys escaping the store to be refused; got %v", err)
+	}
+}
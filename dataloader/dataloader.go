@@ -0,0 +1,65 @@
+// Package dataloader batches the loads of keys made while a GraphQL query is
+// resolved, so that resolving a field of every item of a list costs a single
+// query instead of one per item
+package dataloader
+
+// Fetch loads the values of keys. Keys missing from the result have the zero
+// value.
+type Fetch[V any] func(keys []string) (map[string]V, error)
+
+// Loader queues keys until one of their values is needed, then fetches every
+// queued key at once. Values are cached, so a Loader lives for one request.
+// It isn't safe for concurrent use.
+type Loader[V any] struct {
+	fetch   Fetch[V]
+	pending []string
+	queued  map[string]bool
+	values  map[string]V
+	errs    map[string]error
+}
+
+// New returns a loader of the values fetch returns
+func New[V any](fetch Fetch[V]) *Loader[V] {
+	return &Loader[V]{
+		fetch:  fetch,
+		queued: make(map[string]bool),
+		values: make(map[string]V),
+		errs:   make(map[string]error),
+	}
+}
+
+// Load queues key and returns a thunk returning its value, fetched along with
+// every key queued before the first thunk of the batch is called
+func (l *Loader[V]) Load(key string) func() (V, error) {
+	if !l.queued[key] {
+		l.queued[key] = true
+		l.pending = append(l.pending, key)
+	}
+	return func() (V, error) {
+		if len(l.pending) > 0 {
+			l.dispatch()
+		}
+		return l.values[key], l.errs[key]
+	}
+}
+
+// dispatch fetches the pending keys
+func (l *Loader[V]) dispatch() {
+	keys := l.pending
+	l.pending = nil
+	values, err := l.fetch(keys)
+	for _, key := range keys {
+		if err != nil {
+			l.errs[key] = err
+			continue
+		}
+		l.values[key] = values[key]
+	}
+}
+
+// Thunk adapts a thunk of Load to the thunks a GraphQL resolver may return
+func Thunk[V any](thunk func() (V, error)) func() (interface{}, error) {
+	return func() (interface{}, error) {
+		return thunk()
+	}
+}
@@ -0,0 +1,71 @@
+package dataloader
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoader(t *testing.T) {
+	for i, tt := range []struct {
+		loads   [][]string
+		batches [][]string
+		values  []string
+	}{
+		{[][]string{{"a", "b", "c"}}, [][]string{{"a", "b", "c"}}, []string{"A", "B", "C"}},
+		{[][]string{{"a", "b", "a"}}, [][]string{{"a", "b"}}, []string{"A", "B", "A"}},
+		{[][]string{{"a", "b"}, {"b", "c"}}, [][]string{{"a", "b"}, {"c"}}, []string{"A", "B", "B", "C"}},
+		{[][]string{{"a", "missing"}}, [][]string{{"a", "missing"}}, []string{"A", ""}},
+	} {
+		t.Run(fmt.Sprintf("%v", i), func(t *testing.T) {
+			batches := make([][]string, 0)
+			loader := New(func(keys []string) (map[string]string, error) {
+				batches = append(batches, keys)
+				values := make(map[string]string)
+				for _, key := range keys {
+					if key != "missing" {
+						values[key] = strings.ToUpper(key)
+					}
+				}
+				return values, nil
+			})
+			values := make([]string, 0)
+			for _, keys := range tt.loads {
+				thunks := make([]func() (string, error), 0)
+				for _, key := range keys {
+					thunks = append(thunks, loader.Load(key))
+				}
+				for _, thunk := range thunks {
+					value, err := thunk()
+					if err != nil {
+						t.Fatal(err)
+					}
+					values = append(values, value)
+				}
+			}
+			if !reflect.DeepEqual(batches, tt.batches) || !reflect.DeepEqual(values, tt.values) {
+				t.Errorf("want %v %v; got %v %v", tt.batches, tt.values, batches, values)
+			}
+		})
+	}
+}
+
+func TestLoaderError(t *testing.T) {
+	failure := errors.New("connection refused")
+	loader := New(func(keys []string) (map[string]int, error) {
+		return nil, failure
+	})
+	a, b := loader.Load("a"), loader.Load("b")
+	if _, err := a(); err != failure {
+		t.Errorf("want %v; got %v", failure, err)
+	}
+	if _, err := b(); err != failure {
+		t.Errorf("want %v; got %v", failure, err)
+	}
+	value, err := Thunk(loader.Load("a"))()
+	if value != 0 || err != failure {
+		t.Errorf("want 0 %v; got %v %v", failure, value, err)
+	}
+}
@@ -0,0 +1,156 @@
+package diet
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/TranQuocToan1996/ginProject/ingredients"
+)
+
+// rules.json is the default dictionary, replaced at startup with LoadFile
+//
+//go:embed rules.json
+var defaultRules []byte
+
+// Group is a family of ingredients such as meat or dairy. Except lists
+// phrases that contain a keyword without belonging to the group, like
+// "peanut butter" for dairy.
+type Group struct {
+	Allergen bool     `json:"allergen"`
+	Keywords []string `json:"keywords"`
+	Except   []string `json:"except"`
+}
+
+// Rules classify ingredients into groups, and recipes into labels such as
+// vegan, which are the groups a recipe must not contain
+type Rules struct {
+	Groups map[string]Group    `json:"groups"`
+	Labels map[string][]string `json:"labels"`
+}
+
+// Classification holds the labels and allergens derived from a recipe's ingredients
+type Classification struct {
+	Labels    []string
+	Allergens []string
+}
+
+var rules *Rules
+
+func init() {
+	var err error
+	if rules, err = Parse(defaultRules); err != nil {
+		panic("diet: invalid rules.json: " + err.Error())
+	}
+}
+
+// Parse reads and validates a rules dictionary
+func Parse(data []byte) (*Rules, error) {
+	var r Rules
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, err
+	}
+	for name, g := range r.Groups {
+		for i, keyword := range g.Keywords {
+			g.Keywords[i] = ingredients.NormalizeName(keyword)
+		}
+		for i, phrase := range g.Except {
+			g.Except[i] = ingredients.NormalizeName(phrase)
+		}
+		r.Groups[name] = g
+	}
+	for label, groups := range r.Labels {
+		for _, g := range groups {
+			if _, ok := r.Groups[g]; !ok {
+				return nil, fmt.Errorf("diet: label %v excludes unknown group %v", label, g)
+			}
+		}
+	}
+	return &r, nil
+}
+
+// LoadFile replaces the default rules with the dictionary stored at path
+func LoadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	r, err := Parse(data)
+	if err != nil {
+		return err
+	}
+	rules = r
+	return nil
+}
+
+// IsLabel reports whether name is a label of the current rules
+func IsLabel(name string) bool {
+	_, ok := rules.Labels[name]
+	return ok
+}
+
+// IsAllergen reports whether name is an allergen group of the current rules
+func IsAllergen(name string) bool {
+	return rules.Groups[name].Allergen
+}
+
+// Classify derives the labels and allergens of a recipe from its ingredient lines
+func Classify(lines []string) Classification {
+	found := make(map[string]bool)
+	for _, line := range lines {
+		if ingredients.IsSeparator(line) {
+			continue
+		}
+		for _, g := range GroupsOf(line) {
+			found[g] = true
+		}
+	}
+
+	result := Classification{Labels: make([]string, 0), Allergens: make([]string, 0)}
+	for label, excluded := range rules.Labels {
+		suitable := true
+		for _, g := range excluded {
+			if found[g] {
+				suitable = false
+			}
+		}
+		if suitable {
+			result.Labels = append(result.Labels, label)
+		}
+	}
+	for g := range found {
+		if rules.Groups[g].Allergen {
+			result.Allergens = append(result.Allergens, g)
+		}
+	}
+	sort.Strings(result.Labels)
+	sort.Strings(result.Allergens)
+	return result
+}
+
+// GroupsOf returns the groups a single ingredient line belongs to
+func GroupsOf(line string) []string {
+	name := line
+	if ingredient, err := ingredients.Parse(line); err == nil {
+		name = ingredient.Name
+	}
+	text := " " + ingredients.NormalizeName(name) + " "
+	groups := make([]string, 0)
+	for g, group := range rules.Groups {
+		masked := text
+		for _, phrase := range group.Except {
+			masked = strings.ReplaceAll(masked, " "+phrase+" ", "  ")
+		}
+		for _, keyword := range group.Keywords {
+			if strings.Contains(masked, " "+keyword+" ") {
+				groups = append(groups, g)
+				break
+			}
+		}
+	}
+	sort.Strings(groups)
+	return groups
+}
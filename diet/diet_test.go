@@ -0,0 +1,54 @@
+package diet
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestGroupsOf(t *testing.T) {
+	for i, tt := range []struct {
+		line string
+		out  []string
+	}{
+		{"2 tablespoon butter", []string{"dairy"}},
+		{"1/4 cup peanut butter", []string{"peanut"}},
+		{"1 can coconut milk", []string{}},
+		{"1 eggplant, diced", []string{}},
+		{"2 Eggs", []string{"egg"}},
+		{"2 cup chicken broth", []string{"meat"}},
+		{"1 tbsp soy sauce", []string{"gluten", "soy"}},
+		{"1/2 tsp cream of tartar", []string{}},
+		{"1/4 cup pine nuts", []string{"tree-nut"}},
+	} {
+		t.Run(fmt.Sprintf("%v", i), func(t *testing.T) {
+			if result := GroupsOf(tt.line); !reflect.DeepEqual(result, tt.out) {
+				t.Errorf("want %v; got %v", tt.out, result)
+			}
+		})
+	}
+}
+
+func TestClassify(t *testing.T) {
+	result := Classify([]string{
+		"3 tablespoon butter\r",
+		"30 oz frozen peas\r",
+		"2 cup vegetable broth\r",
+		"8 slices sandwich bread\r",
+		"<hr>",
+	})
+	want := Classification{
+		Labels:    []string{"egg-free", "nut-free", "pescatarian", "vegetarian"},
+		Allergens: []string{"dairy", "gluten"},
+	}
+	if !reflect.DeepEqual(result, want) {
+		t.Errorf("want %+v; got %+v", want, result)
+	}
+}
+
+func TestParseUnknownGroup(t *testing.T) {
+	_, err := Parse([]byte(`{"groups": {}, "labels": {"vegan": ["meat"]}}`))
+	if err == nil {
+		t.Errorf("want an error for a label excluding an unknown group")
+	}
+}
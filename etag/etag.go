@@ -0,0 +1,52 @@
+// Package etag formats entity tags of recipes and evaluates the conditional
+// request headers comparing them
+package etag
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strings"
+
+	"github.com/TranQuocToan1996/ginProject/models"
+)
+
+// Of returns the strong entity tag of a version of recipe
+func Of(recipe models.Recipe) string {
+	return fmt.Sprintf(`"%v-%v"`, recipe.ID.Hex(), recipe.Version)
+}
+
+// List returns a weak entity tag of a list from the tags of its items, which
+// changes whenever an item is added, removed, moved or changed
+func List(tags []string) string {
+	h := fnv.New64a()
+	for _, tag := range tags {
+		h.Write([]byte(tag))
+		h.Write([]byte{','})
+	}
+	return fmt.Sprintf(`W/"%x"`, h.Sum64())
+}
+
+// Match reports whether tag is one of the tags of an If-Match or If-None-Match
+// header. "*" matches any tag. If-Match compares tags strongly, so weak tags
+// never match, If-None-Match compares them weakly.
+func Match(header, tag string, weak bool) bool {
+	if strings.TrimSpace(header) == "*" {
+		return true
+	}
+	if !weak && strings.HasPrefix(tag, "W/") {
+		return false
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if strings.HasPrefix(candidate, "W/") {
+			if !weak {
+				continue
+			}
+			candidate = candidate[2:]
+		}
+		if candidate == strings.TrimPrefix(tag, "W/") {
+			return true
+		}
+	}
+	return false
+}
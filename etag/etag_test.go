@@ -0,0 +1,53 @@
+package etag
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/TranQuocToan1996/ginProject/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestOf(t *testing.T) {
+	id, _ := primitive.ObjectIDFromHex("62a1b2c3d4e5f60718293a4b")
+	if got := Of(models.Recipe{ID: id, Version: 3}); got != `"62a1b2c3d4e5f60718293a4b-3"` {
+		t.Errorf("got %v", got)
+	}
+}
+
+func TestList(t *testing.T) {
+	a := List([]string{`"a-1"`, `"b-1"`})
+	if a != List([]string{`"a-1"`, `"b-1"`}) {
+		t.Errorf("want the same tag for the same items")
+	}
+	for i, tags := range [][]string{{`"b-1"`, `"a-1"`}, {`"a-1"`, `"b-2"`}, {`"a-1"`}, {`"a-1"`, `"b-1"`, `"c-1"`}} {
+		if List(tags) == a {
+			t.Errorf("%v: want a different tag", i)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	for i, tt := range []struct {
+		header string
+		tag    string
+		weak   bool
+		want   bool
+	}{
+		{`"a-1"`, `"a-1"`, false, true},
+		{`"a-0", "a-1"`, `"a-1"`, false, true},
+		{`"a-0"`, `"a-1"`, false, false},
+		{`*`, `"a-1"`, false, true},
+		{`W/"a-1"`, `"a-1"`, false, false},
+		{`W/"a-1"`, `"a-1"`, true, true},
+		{`"x"`, `W/"x"`, false, false},
+		{`"x"`, `W/"x"`, true, true},
+		{``, `"a-1"`, true, false},
+	} {
+		t.Run(fmt.Sprintf("%v", i), func(t *testing.T) {
+			if got := Match(tt.header, tt.tag, tt.weak); got != tt.want {
+				t.Errorf("want %v; got %v", tt.want, got)
+			}
+		})
+	}
+}
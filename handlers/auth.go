@@ -2,12 +2,13 @@ package handlers
 
 import (
 	"context"
-	"fmt"
 	"net/http"
 	"os"
 	"time"
 
+	"github.com/TranQuocToan1996/ginProject/apierrors"
 	"github.com/TranQuocToan1996/ginProject/models"
+	"github.com/TranQuocToan1996/ginProject/negotiate"
 	"github.com/TranQuocToan1996/ginProject/utils"
 	"github.com/auth0-community/go-auth0"
 	"github.com/gin-contrib/sessions"
@@ -46,10 +47,8 @@ type JWTOutput struct {
 
 func (handler *AuthHandler) SignInHandler(c *gin.Context) {
 	var user models.User
-	if err := c.ShouldBindJSON(&user); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": fmt.Errorf("%v:%w", "[Bind]", err).Error(),
-		})
+	if err := negotiate.Bind(c, &user); err != nil {
+		c.Error(apierrors.InvalidInput(err))
 		return
 	}
 
@@ -59,9 +58,7 @@ func (handler *AuthHandler) SignInHandler(c *gin.Context) {
 	}).Decode(&userHash)
 
 	if !utils.ValidPassword(userHash.Password, user.Password) {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "Invalid username or password!",
-		})
+		c.Error(apierrors.Unauthorized("Invalid username or password!"))
 		return
 	}
 
@@ -106,10 +103,8 @@ func (handler *AuthHandler) SignInHandler(c *gin.Context) {
 
 func (handler *AuthHandler) RegisterAccount(c *gin.Context) {
 	var user, userFind models.User
-	if err := c.ShouldBindJSON(&user); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": fmt.Errorf("%v:%w", "[Bind]", err).Error(),
-		})
+	if err := negotiate.Bind(c, &user); err != nil {
+		c.Error(apierrors.InvalidInput(err))
 		return
 	}
 	//TODO: validate username and password length
@@ -119,17 +114,13 @@ func (handler *AuthHandler) RegisterAccount(c *gin.Context) {
 	}).Decode(&userFind)
 
 	if user.Name == userFind.Name {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": fmt.Errorf("%v:%v", "[ExistUser]", user.Name),
-		})
+		c.Error(apierrors.Conflict("User " + user.Name + " already exists"))
 		return
 	}
 
 	hash, err := utils.HashPassword(user.Password, models.Cost)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": fmt.Errorf("%v:%w", "[Hash]", err).Error(),
-		})
+		c.Error(err)
 		return
 	}
 
@@ -139,9 +130,7 @@ func (handler *AuthHandler) RegisterAccount(c *gin.Context) {
 	})
 
 	if err != nil {
-		c.JSON(http.StatusOK, gin.H{
-			"error": fmt.Errorf("%v:%w", "[Insert]", err).Error(),
-		})
+		c.Error(err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{
@@ -159,21 +148,15 @@ func (handler *AuthHandler) RefreshToken(c *gin.Context) {
 			return []byte(os.Getenv("JWT_SECRET")), nil
 		})
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": fmt.Errorf("%v:%w", "[ParseToken]", err).Error(),
-		})
+		c.Error(apierrors.Unauthorized(err.Error()))
 		return
 	}
 	if token == nil || !token.Valid {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "Invalid token",
-		})
+		c.Error(apierrors.Unauthorized("Invalid token"))
 		return
 	}
 	if time.Until(time.Unix(claims.ExpiresAt, 0)) >= time.Minute {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Token is not expired yet",
-		})
+		c.Error(apierrors.BadRequest("Token is not expired yet"))
 		return
 	}
 
@@ -182,9 +165,7 @@ func (handler *AuthHandler) RefreshToken(c *gin.Context) {
 	token = jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	tokenString, err := token.SignedString([]byte(os.Getenv("JWT_SECRET")))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": fmt.Errorf("%v:%w", "[SignToken]", err).Error(),
-		})
+		c.Error(err)
 		return
 	}
 	jwtOutPut := JWTOutput{
@@ -198,13 +179,20 @@ func (handler *AuthHandler) RefreshToken(c *gin.Context) {
 // AuthMiddleware_APIKEY apply APIKEY
 func (handler *AuthHandler) AuthMiddleware_APIKEY() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		if c.GetHeader("X-API-KEY") != os.Getenv("X_API_KEY") {
-			c.AbortWithStatus(http.StatusUnauthorized)
+		if !validAPIKey(c.GetHeader("X-API-KEY")) {
+			apierrors.Abort(c, apierrors.Unauthorized("Invalid API key"))
+			return
 		}
 		c.Next()
 	}
 }
 
+// validAPIKey reports whether key is the API key of X_API_KEY, which has to
+// be set
+func validAPIKey(key string) bool {
+	return key != "" && key == os.Getenv("X_API_KEY")
+}
+
 func (handler *AuthHandler) SignOut(c *gin.Context) {
 	session := sessions.Default(c)
 	session.Clear()
@@ -213,17 +201,37 @@ func (handler *AuthHandler) SignOut(c *gin.Context) {
 }
 
 // AuthMiddleware_session obtain the token from the request cookie. If
-// the cookie is not set, we return a 403 code (Forbidden) by returning an http.
-// StatusForbidden response, as illustrated in the following code snippet
+// the cookie is not set, we abort with an unauthorized error
 func (handler *AuthHandler) AuthMiddleware_session() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		session := sessions.Default(c)
 		sessionToken := session.Get("token")
 		if sessionToken == nil {
-			c.JSON(http.StatusForbidden, gin.H{
-				"message": "Not logged",
-			})
-			c.Abort()
+			apierrors.Abort(c, apierrors.Unauthorized("Not logged"))
+			return
+		}
+		c.Next()
+	}
+}
+
+// currentUser returns the name of the user signed in with a session, or an
+// empty string
+func currentUser(c *gin.Context) string {
+	userName, _ := sessions.Default(c).Get("username").(string)
+	return userName
+}
+
+// AdminMiddleware only lets signed in users with the admin role through. It
+// has to run after AuthMiddleware_session.
+func (handler *AuthHandler) AdminMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var user models.User
+		err := handler.collection.FindOne(handler.ctx, bson.M{
+			"username": currentUser(c),
+		}).Decode(&user)
+		if err != nil || user.Role != models.RoleAdmin {
+			apierrors.Abort(c, apierrors.Forbidden("Admin only"))
+			return
 		}
 		c.Next()
 	}
@@ -233,22 +241,27 @@ func (handler *AuthHandler) AuthMiddleware_session() gin.HandlerFunc {
 /*  Next, we update the authentication middleware in handler/auth.go to check for the Authorization header instead of the X-API-KEY attribute. The header is then passed to the ParseWithClaims method. It generates a signature using the header and payload from the Authorization header and the secret key. Ten, it verifies if the signature matches the one on the JWT. If not, the JWT is not considered valid, and a 401 status code is returned. The Go implementation is shown here */
 func (handler *AuthHandler) AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		tokenVal := c.GetHeader("Authorization")
-		claims := &Claims{}
-		token, err := jwt.ParseWithClaims(tokenVal, claims,
-			func(tkn *jwt.Token) (interface{}, error) {
-				return []byte(os.Getenv("JWT_SECRET")), nil
-			})
-		if err != nil {
-			c.AbortWithStatus(http.StatusUnauthorized)
-		}
-		if token == nil || !token.Valid {
-			c.AbortWithStatus(http.StatusUnauthorized)
+		if _, err := parseToken(c.GetHeader("Authorization")); err != nil {
+			apierrors.Abort(c, err)
+			return
 		}
 		c.Next()
 	}
 }
 
+// parseToken returns the claims of a JWT signed with JWT_SECRET
+func parseToken(tokenVal string) (*Claims, error) {
+	claims := &Claims{}
+	token, err := jwt.ParseWithClaims(tokenVal, claims,
+		func(tkn *jwt.Token) (interface{}, error) {
+			return []byte(os.Getenv("JWT_SECRET")), nil
+		})
+	if err != nil || token == nil || !token.Valid {
+		return nil, apierrors.Unauthorized("Invalid token")
+	}
+	return claims, nil
+}
+
 func (handler *AuthHandler) AuthMiddleware_Auth0() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var auth0Domain = "https://" + os.Getenv("AUTH0_DOMAIN") + "/"
@@ -257,8 +270,7 @@ func (handler *AuthHandler) AuthMiddleware_Auth0() gin.HandlerFunc {
 		validator := auth0.NewValidator(configuration, nil)
 		_, err := validator.ValidateRequest(c.Request)
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid token"})
-			c.Abort()
+			apierrors.Abort(c, apierrors.Unauthorized("Invalid token"))
 			return
 		}
 		c.Next()
@@ -0,0 +1,431 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/TranQuocToan1996/ginProject/apierrors"
+	"github.com/TranQuocToan1996/ginProject/etag"
+	"github.com/TranQuocToan1996/ginProject/history"
+	"github.com/TranQuocToan1996/ginProject/models"
+	"github.com/TranQuocToan1996/ginProject/negotiate"
+	"github.com/TranQuocToan1996/ginProject/validation"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// maxBulkOperations is the largest batch POST /recipes/bulk accepts
+const maxBulkOperations = 500
+
+// Bulk operations
+const (
+	BulkCreate = "create"
+	BulkUpdate = "update"
+	BulkDelete = "delete"
+)
+
+// BulkRequest is the body of a batch of recipe operations. With atomic set,
+// either every operation is applied or none is.
+type BulkRequest struct {
+	Atomic     bool            `json:"atomic"`
+	Operations []BulkOperation `json:"operations" binding:"required"`
+}
+
+// BulkOperation creates a recipe, or updates or deletes the recipe of ID.
+// Updates and deletes need Version, the current version of the recipe.
+type BulkOperation struct {
+	Op      string         `json:"op"`
+	ID      string         `json:"id,omitempty"`
+	Version *int           `json:"version,omitempty"`
+	Recipe  *models.Recipe `json:"recipe,omitempty"`
+}
+
+// BulkResult is the outcome of an operation of a batch
+type BulkResult struct {
+	Index  int               `json:"index"`
+	Op     string            `json:"op"`
+	ID     string            `json:"id,omitempty"`
+	Status int               `json:"status"`
+	ETag   string            `json:"etag,omitempty"`
+	Error  string            `json:"error,omitempty"`
+	Fields validation.Errors `json:"fields,omitempty"`
+}
+
+// BulkResponse lists the results of a batch in the order of its operations
+type BulkResponse struct {
+	Atomic    bool         `json:"atomic"`
+	Succeeded int          `json:"succeeded"`
+	Failed    int          `json:"failed"`
+	Results   []BulkResult `json:"results"`
+}
+
+// bulkWrite is an operation of a batch ready to be written
+type bulkWrite struct {
+	index  int
+	model  mongo.WriteModel
+	before *models.Recipe
+	after  models.Recipe
+}
+
+// BulkRecipes applies a batch of create, update and delete operations with a
+// single BulkWrite, reporting the result of each one. Invalid operations fail
+// on their own, unless the batch is atomic in which case nothing is written
+// and the batch runs in a transaction.
+func (handler *RecipesHandler) BulkRecipes(c *gin.Context) {
+	var request BulkRequest
+	if err := negotiate.Bind(c, &request); err != nil {
+		c.Error(apierrors.InvalidInput(err))
+		return
+	}
+	if len(request.Operations) == 0 || len(request.Operations) > maxBulkOperations {
+		c.Error(apierrors.BadRequest(fmt.Sprintf("a batch has 1 to %v operations", maxBulkOperations)))
+		return
+	}
+
+	user := currentUser(c)
+	batch := primitive.NewObjectID()
+	current, err := handler.bulkRecipes(request.Operations, user)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	admin, err := handler.isAdmin(user)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	results := make([]BulkResult, len(request.Operations))
+	writes := make([]bulkWrite, 0, len(request.Operations))
+	seen := make(map[string]bool, len(request.Operations))
+	for i, operation := range request.Operations {
+		results[i] = BulkResult{Index: i, Op: operation.Op, ID: operation.ID}
+		write, status, err := bulkModel(operation, current, user, admin, batch)
+		if err == nil && operation.Op != BulkCreate && seen[operation.ID] {
+			status, err = http.StatusBadRequest, errors.New("recipe has another operation in the batch")
+		}
+		seen[operation.ID] = true
+		if err != nil {
+			results[i].Status = status
+			results[i].Error = err.Error()
+			results[i].Fields, _ = err.(validation.Errors)
+			continue
+		}
+		write.index = i
+		results[i].ID = write.after.ID.Hex()
+		writes = append(writes, write)
+	}
+
+	response := BulkResponse{Atomic: request.Atomic, Results: results}
+	status := http.StatusOK
+	if request.Atomic {
+		status = handler.bulkAtomic(writes, results)
+	} else {
+		handler.bulkUnordered(writes, results, batch)
+	}
+	handler.bulkUnmark(writes, batch)
+
+	written := false
+	for i, result := range results {
+		if result.Error == "" {
+			response.Succeeded++
+			written = true
+		} else {
+			response.Failed++
+			results[i].ETag = ""
+		}
+	}
+	if written {
+		handler.redisClient.Del("recipes")
+		log.Println("Removed redis recipes!")
+		handler.bulkRevisions(writes, results, user)
+	}
+	c.JSON(status, response)
+}
+
+// bulkRecipes loads the recipes visible to user the operations update or delete
+func (handler *RecipesHandler) bulkRecipes(operations []BulkOperation, user string) (map[string]models.Recipe, error) {
+	ids := make([]primitive.ObjectID, 0, len(operations))
+	for _, operation := range operations {
+		if id, err := parseID(operation.ID); err == nil && operation.Op != BulkCreate {
+			ids = append(ids, id)
+		}
+	}
+	current := make(map[string]models.Recipe, len(ids))
+	if len(ids) == 0 {
+		return current, nil
+	}
+	cursor, err := handler.collection.Find(handler.ctx, visibleTo(bson.M{"_id": bson.M{"$in": ids}}, user))
+	if err != nil {
+		return nil, err
+	}
+	var recipes []models.Recipe
+	if err := cursor.All(handler.ctx, &recipes); err != nil {
+		return nil, err
+	}
+	for _, recipe := range recipes {
+		current[recipe.ID.Hex()] = recipe
+	}
+	return current, nil
+}
+
+// bulkModel checks an operation of user, an admin or not, and returns its
+// write, or the status and error of the operation when it can't be applied.
+// Updates and deletes mark the recipe with the id of their batch, which tells
+// the ones written.
+func bulkModel(operation BulkOperation, current map[string]models.Recipe, user string, admin bool, batch primitive.ObjectID) (bulkWrite, int, error) {
+	if operation.Op == BulkCreate {
+		if operation.Recipe == nil {
+			return bulkWrite{}, http.StatusBadRequest, errors.New("create needs a recipe")
+		}
+		recipe := *operation.Recipe
+		if err := validation.Struct(&recipe); err != nil {
+			return bulkWrite{}, http.StatusUnprocessableEntity, err
+		}
+		if err := newRecipe(&recipe, user); err != nil {
+			return bulkWrite{}, http.StatusBadRequest, err
+		}
+		return bulkWrite{model: mongo.NewInsertOneModel().SetDocument(recipe), after: recipe}, 0, nil
+	}
+	if operation.Op != BulkUpdate && operation.Op != BulkDelete {
+		return bulkWrite{}, http.StatusBadRequest, errors.New("op must be create, update or delete")
+	}
+	if _, err := parseID(operation.ID); err != nil {
+		return bulkWrite{}, http.StatusBadRequest, err
+	}
+	recipe, ok := current[operation.ID]
+	if !ok {
+		return bulkWrite{}, http.StatusNotFound, mongo.ErrNoDocuments
+	}
+	if !canChange(recipe, user, admin) {
+		return bulkWrite{}, http.StatusForbidden, errNotAuthor
+	}
+	if operation.Version == nil {
+		return bulkWrite{}, http.StatusPreconditionRequired, errors.New("Recipe version is required")
+	}
+	if *operation.Version != recipe.Version {
+		return bulkWrite{}, http.StatusPreconditionFailed, errors.New("Recipe has been modified")
+	}
+	before := recipe
+	filter := versioned(notDeleted(bson.M{"_id": recipe.ID}), recipe.Version)
+
+	if operation.Op == BulkDelete {
+		recipe.Version++
+		return bulkWrite{
+			model: mongo.NewUpdateOneModel().SetFilter(filter).SetUpdate(bson.M{
+				"$set": bson.M{"deletedAt": time.Now(), "deletedBy": user, "batch": batch},
+				"$inc": nextVersion,
+			}),
+			after: recipe,
+		}, 0, nil
+	}
+	if operation.Recipe == nil {
+		return bulkWrite{}, http.StatusBadRequest, errors.New("update needs a recipe")
+	}
+	if err := validation.Struct(operation.Recipe); err != nil {
+		return bulkWrite{}, http.StatusUnprocessableEntity, err
+	}
+	history.Apply(&recipe, history.Snapshot(*operation.Recipe))
+	prepareRecipe(&recipe)
+	recipe.Version++
+	return bulkWrite{
+		model: mongo.NewUpdateOneModel().SetFilter(filter).SetUpdate(bson.D{
+			{Key: "$set", Value: append(append(contentFields(recipe), derivedFields(recipe)...), bson.E{Key: "batch", Value: batch})},
+			{Key: "$inc", Value: nextVersion},
+		}),
+		before: &before,
+		after:  recipe,
+	}, 0, nil
+}
+
+// bulkUnordered writes every valid operation of batch, the failure of one
+// doesn't stop the others
+func (handler *RecipesHandler) bulkUnordered(writes []bulkWrite, results []BulkResult, batch primitive.ObjectID) {
+	if len(writes) == 0 {
+		return
+	}
+	_, err := handler.collection.BulkWrite(handler.ctx, bulkModels(writes), options.BulkWrite().SetOrdered(false))
+	var bulkErr mongo.BulkWriteException
+	switch {
+	case errors.As(err, &bulkErr):
+		for _, writeErr := range bulkErr.WriteErrors {
+			result := &results[writes[writeErr.Index].index]
+			result.Status = http.StatusInternalServerError
+			result.Error = writeErr.Message
+		}
+	case err != nil:
+		for _, write := range writes {
+			results[write.index].Status = http.StatusInternalServerError
+			results[write.index].Error = err.Error()
+		}
+		return
+	}
+	handler.bulkCheckWrites(writes, results, batch)
+}
+
+// bulkAtomic writes every operation in a transaction when all of them are
+// valid, and returns the status of the batch
+func (handler *RecipesHandler) bulkAtomic(writes []bulkWrite, results []BulkResult) int {
+	for _, result := range results {
+		if result.Error != "" {
+			bulkAbort(results, "Batch rolled back")
+			return http.StatusUnprocessableEntity
+		}
+	}
+
+	session, err := handler.collection.Database().Client().StartSession()
+	if err != nil {
+		bulkAbort(results, err.Error())
+		return http.StatusInternalServerError
+	}
+	defer session.EndSession(handler.ctx)
+	expected := int64(0)
+	for _, write := range writes {
+		if _, ok := write.model.(*mongo.UpdateOneModel); ok {
+			expected++
+		}
+	}
+	_, err = session.WithTransaction(handler.ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		result, err := handler.collection.BulkWrite(sc, bulkModels(writes))
+		if err != nil {
+			return nil, err
+		}
+		if result.MatchedCount != expected {
+			return nil, errBulkConflict
+		}
+		return result, nil
+	})
+	if err == errBulkConflict {
+		bulkAbort(results, "Batch rolled back, a recipe has been modified")
+		return http.StatusPreconditionFailed
+	}
+	if err != nil {
+		bulkAbort(results, "Batch rolled back: "+err.Error())
+		return http.StatusInternalServerError
+	}
+	for _, write := range writes {
+		bulkDone(&results[write.index], write)
+	}
+	return http.StatusOK
+}
+
+var errBulkConflict = errors.New("recipe has been modified")
+
+// bulkCheckWrites sets the results of the written operations of batch. An
+// update or delete whose recipe changed since it was loaded matched nothing,
+// so its recipe isn't marked with the batch, and failed.
+func (handler *RecipesHandler) bulkCheckWrites(writes []bulkWrite, results []BulkResult, batch primitive.ObjectID) {
+	ids := make([]primitive.ObjectID, 0, len(writes))
+	for _, write := range writes {
+		if _, ok := write.model.(*mongo.UpdateOneModel); ok {
+			ids = append(ids, write.after.ID)
+		}
+	}
+	written := make(map[primitive.ObjectID]bool, len(ids))
+	var err error
+	if len(ids) > 0 {
+		var cursor *mongo.Cursor
+		cursor, err = handler.collection.Find(handler.ctx, bson.M{"_id": bson.M{"$in": ids}, "batch": batch},
+			options.Find().SetProjection(bson.M{"_id": 1}))
+		if err == nil {
+			var recipes []models.Recipe
+			if err = cursor.All(handler.ctx, &recipes); err == nil {
+				for _, recipe := range recipes {
+					written[recipe.ID] = true
+				}
+			}
+		}
+	}
+	for _, write := range writes {
+		result := &results[write.index]
+		if result.Error != "" {
+			continue
+		}
+		if _, ok := write.model.(*mongo.UpdateOneModel); ok {
+			switch {
+			case err != nil:
+				result.Status = http.StatusInternalServerError
+				result.Error = "Recipe may have been written: " + err.Error()
+				continue
+			case !written[write.after.ID]:
+				result.Status = http.StatusPreconditionFailed
+				result.Error = "Recipe has been modified"
+				continue
+			}
+		}
+		bulkDone(result, write)
+	}
+}
+
+// bulkUnmark removes the batch from the recipes it marked, once the writes
+// have been checked
+func (handler *RecipesHandler) bulkUnmark(writes []bulkWrite, batch primitive.ObjectID) {
+	ids := make([]primitive.ObjectID, 0, len(writes))
+	for _, write := range writes {
+		if _, ok := write.model.(*mongo.UpdateOneModel); ok {
+			ids = append(ids, write.after.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return
+	}
+	_, err := handler.collection.UpdateMany(handler.ctx, bson.M{"_id": bson.M{"$in": ids}, "batch": batch},
+		bson.M{"$unset": bson.M{"batch": ""}})
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+// bulkRevisions records the revisions of the created and updated recipes
+func (handler *RecipesHandler) bulkRevisions(writes []bulkWrite, results []BulkResult, user string) {
+	for _, write := range writes {
+		if results[write.index].Error != "" || results[write.index].Op == BulkDelete {
+			continue
+		}
+		var err error
+		if write.before == nil {
+			err = handler.recordRevision(write.after.ID, nil, history.Snapshot(write.after), user, history.Create, 0)
+		} else {
+			before := history.Snapshot(*write.before)
+			err = handler.recordRevision(write.after.ID, &before, history.Snapshot(write.after), user, history.Update, 0)
+		}
+		if err != nil {
+			log.Println(err)
+		}
+	}
+}
+
+func bulkModels(writes []bulkWrite) []mongo.WriteModel {
+	writeModels := make([]mongo.WriteModel, 0, len(writes))
+	for _, write := range writes {
+		writeModels = append(writeModels, write.model)
+	}
+	return writeModels
+}
+
+// bulkDone sets the result of a written operation
+func bulkDone(result *BulkResult, write bulkWrite) {
+	switch result.Op {
+	case BulkCreate:
+		result.Status, result.ETag = http.StatusCreated, etag.Of(write.after)
+	case BulkUpdate:
+		result.Status, result.ETag = http.StatusOK, etag.Of(write.after)
+	default:
+		result.Status = http.StatusOK
+	}
+}
+
+// bulkAbort marks every operation of a batch as failed, keeping their own errors
+func bulkAbort(results []BulkResult, message string) {
+	for i := range results {
+		if results[i].Error == "" {
+			results[i].Status = http.StatusFailedDependency
+			results[i].Error = message
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/TranQuocToan1996/ginProject/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestBulkModel(t *testing.T) {
+	id, other := primitive.NewObjectID(), primitive.NewObjectID()
+	current := map[string]models.Recipe{
+		id.Hex():    {ID: id, Name: "Soup", Ingredients: []string{"1 onion"}, Author: testUser, Version: 3},
+		other.Hex(): {ID: other, Name: "Stew", Ingredients: []string{"1 leek"}, Author: "alice", Version: 3},
+	}
+	version := func(v int) *int { return &v }
+	recipe := &models.Recipe{Name: "Onion soup", Ingredients: []string{"2 onions"}}
+	for i, tt := range []struct {
+		operation BulkOperation
+		status    int
+		version   int
+		update    bool
+		admin     bool
+	}{
+		{BulkOperation{Op: BulkCreate, Recipe: recipe}, 0, 1, false, false},
+		{BulkOperation{Op: BulkCreate}, http.StatusBadRequest, 0, false, false},
+		{BulkOperation{Op: BulkCreate, Recipe: &models.Recipe{Name: "Soup"}}, http.StatusUnprocessableEntity, 0, false, false},
+		{BulkOperation{Op: "upsert", ID: id.Hex()}, http.StatusBadRequest, 0, false, false},
+		{BulkOperation{Op: BulkUpdate, ID: "1", Recipe: recipe}, http.StatusBadRequest, 0, false, false},
+		{BulkOperation{Op: BulkUpdate, ID: primitive.NewObjectID().Hex(), Recipe: recipe}, http.StatusNotFound, 0, false, false},
+		{BulkOperation{Op: BulkUpdate, ID: id.Hex(), Version: version(2), Recipe: recipe}, http.StatusPreconditionFailed, 0, false, false},
+		{BulkOperation{Op: BulkUpdate, ID: id.Hex(), Recipe: recipe}, http.StatusPreconditionRequired, 0, false, false},
+		{BulkOperation{Op: BulkUpdate, ID: id.Hex(), Version: version(3)}, http.StatusBadRequest, 0, false, false},
+		{BulkOperation{Op: BulkUpdate, ID: id.Hex(), Version: version(3), Recipe: recipe}, 0, 4, true, false},
+		{BulkOperation{Op: BulkDelete, ID: id.Hex()}, http.StatusPreconditionRequired, 0, false, false},
+		{BulkOperation{Op: BulkDelete, ID: id.Hex(), Version: version(3)}, 0, 4, true, false},
+		{BulkOperation{Op: BulkDelete, ID: other.Hex(), Version: version(3)}, http.StatusForbidden, 0, false, false},
+		{BulkOperation{Op: BulkDelete, ID: other.Hex(), Version: version(3)}, 0, 4, true, true},
+	} {
+		t.Run(fmt.Sprintf("%v", i), func(t *testing.T) {
+			batch := primitive.NewObjectID()
+			write, status, err := bulkModel(tt.operation, current, testUser, tt.admin, batch)
+			if status != tt.status || (err != nil) != (tt.status != 0) {
+				t.Fatalf("want status %v; got %v %v", tt.status, status, err)
+			}
+			if err != nil {
+				return
+			}
+			if write.after.Version != tt.version {
+				t.Errorf("want version %v; got %v", tt.version, write.after.Version)
+			}
+			model, update := write.model.(*mongo.UpdateOneModel)
+			if update != tt.update {
+				t.Fatalf("want an update %v; got %T", tt.update, write.model)
+			}
+			if !update {
+				return
+			}
+			filter, _ := bson.Marshal(model.Filter)
+			u, _ := bson.Marshal(model.Update)
+			if v := bson.Raw(filter).Lookup("version").Int32(); v != 3 {
+				t.Errorf("want the write filtered on version 3; got %v", bson.Raw(filter))
+			}
+			if b, ok := bson.Raw(u).Lookup("$set", "batch").ObjectIDOK(); !ok || b != batch {
+				t.Errorf("want the recipe marked with the batch; got %v", bson.Raw(u))
+			}
+		})
+	}
+}
+
+func TestBulkDone(t *testing.T) {
+	recipe := models.Recipe{ID: primitive.NewObjectID(), Version: 4}
+	for i, tt := range []struct {
+		op     string
+		status int
+		etag   string
+	}{
+		{BulkCreate, http.StatusCreated, fmt.Sprintf(`"%v-4"`, recipe.ID.Hex())},
+		{BulkUpdate, http.StatusOK, fmt.Sprintf(`"%v-4"`, recipe.ID.Hex())},
+		{BulkDelete, http.StatusOK, ""},
+	} {
+		t.Run(fmt.Sprintf("%v", i), func(t *testing.T) {
+			result := BulkResult{Op: tt.op}
+			bulkDone(&result, bulkWrite{after: recipe})
+			if result.Status != tt.status || result.ETag != tt.etag {
+				t.Errorf("want %v %v; got %v %v", tt.status, tt.etag, result.Status, result.ETag)
+			}
+		})
+	}
+}
+
+func TestBulkAbort(t *testing.T) {
+	results := []BulkResult{
+		{Status: http.StatusOK, ETag: `"1-1"`},
+		{Status: http.StatusNotFound, Error: "mongo: no documents in result"},
+	}
+	bulkAbort(results, "Batch rolled back")
+	for i, tt := range []struct {
+		status int
+		err    string
+	}{
+		{http.StatusFailedDependency, "Batch rolled back"},
+		{http.StatusNotFound, "mongo: no documents in result"},
+	} {
+		t.Run(fmt.Sprintf("%v", i), func(t *testing.T) {
+			if results[i].Status != tt.status || results[i].Error != tt.err {
+				t.Errorf("want %v %v; got %v %v", tt.status, tt.err, results[i].Status, results[i].Error)
+			}
+		})
+	}
+}
+
+func TestBulkUnordered(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+	mt.Run("conflict", func(mt *mtest.T) {
+		handler := newTestHandler(mt)
+		batch := primitive.NewObjectID()
+		written, modified := primitive.NewObjectID(), primitive.NewObjectID()
+		current := map[string]models.Recipe{
+			written.Hex():  {ID: written, Author: testUser, Version: 3},
+			modified.Hex(): {ID: modified, Author: testUser, Version: 3},
+		}
+		writes := make([]bulkWrite, 0)
+		results := make([]BulkResult, 0)
+		version := 3
+		for i, id := range []primitive.ObjectID{written, modified} {
+			write, _, err := bulkModel(BulkOperation{Op: BulkDelete, ID: id.Hex(), Version: &version}, current, testUser, false, batch)
+			if err != nil {
+				mt.Fatal(err)
+			}
+			write.index = i
+			writes = append(writes, write)
+			results = append(results, BulkResult{Index: i, Op: BulkDelete, ID: id.Hex()})
+		}
+		// The second recipe moved to version 4 before the batch, so only the
+		// first one is marked with it
+		mt.AddMockResponses(
+			updated(1),
+			found(mt, recipeDoc(written, 4)),
+		)
+
+		handler.bulkUnordered(writes, results, batch)
+		if results[0].Status != http.StatusOK || results[0].Error != "" {
+			mt.Errorf("want the first delete done; got %+v", results[0])
+		}
+		if results[1].Status != http.StatusPreconditionFailed {
+			mt.Errorf("want the second delete failed; got %+v", results[1])
+		}
+
+		mt.AddMockResponses(updated(1))
+		handler.bulkUnmark(writes, batch)
+		updates := commands(mt, "update")
+		if len(updates) != 2 {
+			mt.Fatalf("want the batch unmarked; got %v updates", len(updates))
+		}
+		update := updates[1].Lookup("updates", "0", "u", "$unset", "batch")
+		filter := updates[1].Lookup("updates", "0", "q", "batch").ObjectID()
+		if update.Type == 0 || filter != batch {
+			mt.Errorf("want batch unset on recipes of the batch; got %v", updates[1])
+		}
+	})
+}
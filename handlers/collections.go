@@ -0,0 +1,409 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/TranQuocToan1996/ginProject/apierrors"
+	"github.com/TranQuocToan1996/ginProject/models"
+	"github.com/TranQuocToan1996/ginProject/negotiate"
+	"github.com/TranQuocToan1996/ginProject/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type CollectionsHandler struct {
+	favorites   *mongo.Collection
+	collections *mongo.Collection
+	recipes     *mongo.Collection
+	ctx         context.Context
+	redisClient *redis.Client
+}
+
+func NewCollectionsHandler(ctx context.Context, favorites *mongo.Collection, collections *mongo.Collection, recipes *mongo.Collection, redisClient *redis.Client) *CollectionsHandler {
+	return &CollectionsHandler{
+		favorites:   favorites,
+		collections: collections,
+		recipes:     recipes,
+		ctx:         ctx,
+		redisClient: redisClient,
+	}
+}
+
+// EnsureIndexes creates the unique indexes allowing a user to favorite a recipe
+// once and looking up shared collections by token
+func (handler *CollectionsHandler) EnsureIndexes() error {
+	_, err := handler.favorites.Indexes().CreateOne(handler.ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "username", Value: 1}, {Key: "recipeId", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return err
+	}
+	_, err = handler.collections.Indexes().CreateOne(handler.ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "shareToken", Value: 1}},
+		Options: options.Index().SetUnique(true).SetSparse(true),
+	})
+	return err
+}
+
+// CollectionRequest is the body to create or rename a collection
+type CollectionRequest struct {
+	Name string `json:"name" binding:"required,max=100"`
+}
+
+// CollectionRecipeRequest is the body to add a recipe to a collection
+type CollectionRecipeRequest struct {
+	RecipeID string `json:"recipeId" binding:"required"`
+}
+
+// AddFavorite favorites a recipe for the signed in user
+func (handler *CollectionsHandler) AddFavorite(c *gin.Context) {
+	recipeID, err := recipeObjectID(handler.ctx, handler.recipes, c.Param("id"), currentUser(c))
+	if err != nil {
+		c.Error(recipeError(err))
+		return
+	}
+	_, err = handler.favorites.InsertOne(handler.ctx, models.Favorite{
+		ID:        primitive.NewObjectID(),
+		UserName:  currentUser(c),
+		RecipeID:  recipeID,
+		CreatedAt: time.Now(),
+	})
+	if mongo.IsDuplicateKeyError(err) {
+		c.JSON(http.StatusOK, gin.H{"message": "Recipe is already a favorite"})
+		return
+	}
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if err := handler.incFavoriteCount(recipeID, 1); err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "Recipe has been added to favorites"})
+}
+
+// RemoveFavorite removes a recipe from the favorites of the signed in user
+func (handler *CollectionsHandler) RemoveFavorite(c *gin.Context) {
+	recipeID, err := parseID(c.Param("id"))
+	if err != nil {
+		c.Error(apierrors.InvalidInput(err))
+		return
+	}
+	result, err := handler.favorites.DeleteOne(handler.ctx, bson.M{
+		"username": currentUser(c),
+		"recipeId": recipeID,
+	})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if result.DeletedCount == 0 {
+		c.Error(apierrors.NotFound("Recipe is not a favorite"))
+		return
+	}
+	if err := handler.incFavoriteCount(recipeID, -1); err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Recipe has been removed from favorites"})
+}
+
+// ListFavorites returns the recipes favorited by the signed in user, most
+// recently favorited first
+func (handler *CollectionsHandler) ListFavorites(c *gin.Context) {
+	cursor, err := handler.favorites.Find(handler.ctx, bson.M{"username": currentUser(c)},
+		options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}}))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	var favorites []models.Favorite
+	if err := cursor.All(handler.ctx, &favorites); err != nil {
+		c.Error(err)
+		return
+	}
+	ids := make([]primitive.ObjectID, 0, len(favorites))
+	for _, favorite := range favorites {
+		ids = append(ids, favorite.RecipeID)
+	}
+	recipes, err := handler.findRecipes(ids, currentUser(c))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, recipes)
+}
+
+// ListCollections returns the collections of the signed in user
+func (handler *CollectionsHandler) ListCollections(c *gin.Context) {
+	cursor, err := handler.collections.Find(handler.ctx, bson.M{"owner": currentUser(c)},
+		options.Find().SetSort(bson.D{{Key: "name", Value: 1}}))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	collections := make([]models.Collection, 0)
+	if err := cursor.All(handler.ctx, &collections); err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, collections)
+}
+
+// AddCollection creates an empty collection for the signed in user
+func (handler *CollectionsHandler) AddCollection(c *gin.Context) {
+	var request CollectionRequest
+	if err := negotiate.Bind(c, &request); err != nil {
+		c.Error(apierrors.InvalidInput(err))
+		return
+	}
+	now := time.Now()
+	collection := models.Collection{
+		ID:        primitive.NewObjectID(),
+		Owner:     currentUser(c),
+		Name:      request.Name,
+		Recipes:   make([]primitive.ObjectID, 0),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if _, err := handler.collections.InsertOne(handler.ctx, collection); err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusCreated, collection)
+}
+
+// GetCollection returns a collection of the signed in user with its recipes
+func (handler *CollectionsHandler) GetCollection(c *gin.Context) {
+	collection, err := handler.ownCollection(c)
+	if err != nil {
+		c.Error(notFound(err, "Collection not found"))
+		return
+	}
+	handler.writeCollection(c, collection, currentUser(c))
+}
+
+// RenameCollection renames a collection of the signed in user
+func (handler *CollectionsHandler) RenameCollection(c *gin.Context) {
+	var request CollectionRequest
+	if err := negotiate.Bind(c, &request); err != nil {
+		c.Error(apierrors.InvalidInput(err))
+		return
+	}
+	handler.updateCollection(c, bson.M{"$set": bson.M{"name": request.Name}})
+}
+
+// DeleteCollection deletes a collection of the signed in user, the recipes
+// themselves are kept
+func (handler *CollectionsHandler) DeleteCollection(c *gin.Context) {
+	collectionID, err := parseID(c.Param("collectionId"))
+	if err != nil {
+		c.Error(apierrors.InvalidInput(err))
+		return
+	}
+	result, err := handler.collections.DeleteOne(handler.ctx, bson.M{
+		"_id":   collectionID,
+		"owner": currentUser(c),
+	})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if result.DeletedCount == 0 {
+		c.Error(apierrors.NotFound("Collection not found"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Collection has been deleted"})
+}
+
+// AddCollectionRecipe adds a recipe to a collection of the signed in user
+func (handler *CollectionsHandler) AddCollectionRecipe(c *gin.Context) {
+	var request CollectionRecipeRequest
+	if err := negotiate.Bind(c, &request); err != nil {
+		c.Error(apierrors.InvalidInput(err))
+		return
+	}
+	recipeID, err := recipeObjectID(handler.ctx, handler.recipes, request.RecipeID, currentUser(c))
+	if err != nil {
+		c.Error(recipeError(err))
+		return
+	}
+	handler.updateCollection(c, bson.M{"$addToSet": bson.M{"recipes": recipeID}})
+}
+
+// RemoveCollectionRecipe removes a recipe from a collection of the signed in user
+func (handler *CollectionsHandler) RemoveCollectionRecipe(c *gin.Context) {
+	recipeID, err := parseID(c.Param("recipeId"))
+	if err != nil {
+		c.Error(apierrors.InvalidInput(err))
+		return
+	}
+	handler.updateCollection(c, bson.M{"$pull": bson.M{"recipes": recipeID}})
+}
+
+// ShareCollection gives a collection of the signed in user a public read-only
+// link. Sharing an already shared collection keeps its link.
+func (handler *CollectionsHandler) ShareCollection(c *gin.Context) {
+	collection, err := handler.ownCollection(c)
+	if err != nil {
+		c.Error(notFound(err, "Collection not found"))
+		return
+	}
+	if collection.ShareToken == "" {
+		token, err := utils.GenerateRandomString(24)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		_, err = handler.collections.UpdateOne(handler.ctx, bson.M{
+			"_id": collection.ID,
+		}, bson.M{"$set": bson.M{"shareToken": token, "updatedAt": time.Now()}})
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		collection.ShareToken = token
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"shareToken": collection.ShareToken,
+		"url":        "/shared/collections/" + collection.ShareToken,
+	})
+}
+
+// UnshareCollection revokes the public link of a collection
+func (handler *CollectionsHandler) UnshareCollection(c *gin.Context) {
+	handler.updateCollection(c, bson.M{"$unset": bson.M{"shareToken": ""}})
+}
+
+// SharedCollection returns a shared collection with its recipes to anyone
+// holding its link
+func (handler *CollectionsHandler) SharedCollection(c *gin.Context) {
+	var collection models.Collection
+	err := handler.collections.FindOne(handler.ctx, bson.M{
+		"shareToken": c.Param("token"),
+	}).Decode(&collection)
+	if err != nil {
+		c.Error(notFound(err, "Collection not found"))
+		return
+	}
+	collection.ShareToken = ""
+	// Holders of the link aren't signed in, they only see published recipes
+	handler.writeCollection(c, collection, "")
+}
+
+// ownCollection loads the collection of the collectionId parameter when it
+// belongs to the signed in user
+func (handler *CollectionsHandler) ownCollection(c *gin.Context) (models.Collection, error) {
+	var collection models.Collection
+	collectionID, err := parseID(c.Param("collectionId"))
+	if err != nil {
+		return collection, err
+	}
+	err = handler.collections.FindOne(handler.ctx, bson.M{
+		"_id":   collectionID,
+		"owner": currentUser(c),
+	}).Decode(&collection)
+	return collection, err
+}
+
+// updateCollection applies update to the collection of the collectionId
+// parameter when it belongs to the signed in user and responds with the result
+func (handler *CollectionsHandler) updateCollection(c *gin.Context, update bson.M) {
+	collectionID, err := parseID(c.Param("collectionId"))
+	if err != nil {
+		c.Error(apierrors.InvalidInput(err))
+		return
+	}
+	set, _ := update["$set"].(bson.M)
+	if set == nil {
+		set = bson.M{}
+		update["$set"] = set
+	}
+	set["updatedAt"] = time.Now()
+
+	var collection models.Collection
+	err = handler.collections.FindOneAndUpdate(handler.ctx, bson.M{
+		"_id":   collectionID,
+		"owner": currentUser(c),
+	}, update, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&collection)
+	if err != nil {
+		c.Error(notFound(err, "Collection not found"))
+		return
+	}
+	c.JSON(http.StatusOK, collection)
+}
+
+// writeCollection writes collection with its recipes visible to user
+func (handler *CollectionsHandler) writeCollection(c *gin.Context, collection models.Collection, user string) {
+	recipes, err := handler.findRecipes(collection.Recipes, user)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, models.CollectionWithRecipes{Collection: collection, Items: recipes})
+}
+
+// findRecipes loads the recipes of ids visible to user in their order,
+// skipping deleted ones and the unpublished ones of other users
+func (handler *CollectionsHandler) findRecipes(ids []primitive.ObjectID, user string) ([]models.Recipe, error) {
+	recipes := make([]models.Recipe, 0, len(ids))
+	if len(ids) == 0 {
+		return recipes, nil
+	}
+	cursor, err := handler.recipes.Find(handler.ctx, visibleTo(bson.M{"_id": bson.M{"$in": ids}}, user))
+	if err != nil {
+		return nil, err
+	}
+	var found []models.Recipe
+	if err := cursor.All(handler.ctx, &found); err != nil {
+		return nil, err
+	}
+	byID := make(map[primitive.ObjectID]models.Recipe, len(found))
+	for _, recipe := range found {
+		byID[recipe.ID] = recipe
+	}
+	for _, id := range ids {
+		if recipe, ok := byID[id]; ok {
+			recipes = append(recipes, recipe)
+		}
+	}
+	return recipes, nil
+}
+
+func (handler *CollectionsHandler) incFavoriteCount(recipeID primitive.ObjectID, delta int) error {
+	_, err := handler.recipes.UpdateOne(handler.ctx, bson.M{
+		"_id": recipeID,
+	}, bson.M{"$inc": bson.M{"favoriteCount": delta, "version": 1}})
+	if err != nil {
+		return err
+	}
+	handler.redisClient.Del("recipes")
+	log.Println("Removed redis recipes!")
+	return nil
+}
+
+// PurgeRecipes deletes the favorites of recipes purged from the trash and
+// removes them from the collections holding them
+func (handler *CollectionsHandler) PurgeRecipes(recipes []models.Recipe) error {
+	ids := recipeIDs(recipes)
+	_, err := handler.favorites.DeleteMany(handler.ctx, bson.M{"recipeId": bson.M{"$in": ids}})
+	if err != nil {
+		return err
+	}
+	_, err = handler.collections.UpdateMany(handler.ctx, bson.M{
+		"recipes": bson.M{"$in": ids},
+	}, bson.M{
+		"$pull": bson.M{"recipes": bson.M{"$in": ids}},
+		"$set":  bson.M{"updatedAt": time.Now()},
+	})
+	return err
+}
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestCollectionRecipesVisibility(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+	recipeID := primitive.NewObjectID()
+	for i, tt := range []struct {
+		route   string
+		target  string
+		first   bson.D
+		handler func(*CollectionsHandler) func(c *gin.Context)
+		authors []string
+	}{
+		{"/shared/collections/:token", "/shared/collections/abc",
+			bson.D{{Key: "_id", Value: primitive.NewObjectID()}, {Key: "owner", Value: "alice"}, {Key: "recipes", Value: bson.A{recipeID}}},
+			func(h *CollectionsHandler) func(c *gin.Context) { return h.SharedCollection }, []string{}},
+		{"/recipes/favorites", "/recipes/favorites",
+			bson.D{{Key: "username", Value: testUser}, {Key: "recipeId", Value: recipeID}},
+			func(h *CollectionsHandler) func(c *gin.Context) { return h.ListFavorites }, []string{testUser}},
+	} {
+		mt.Run(fmt.Sprintf("%v", i), func(mt *mtest.T) {
+			handler := NewCollectionsHandler(context.Background(), mt.Coll, mt.Coll, mt.Coll, nil)
+			mt.AddMockResponses(found(mt, tt.first), found(mt))
+
+			w := serve(http.MethodGet, tt.route, tt.target, nil, nil, tt.handler(handler))
+			if w.Code != http.StatusOK {
+				mt.Fatalf("want %v; got %v %s", http.StatusOK, w.Code, w.Body)
+			}
+			finds := commands(mt, "find")
+			if len(finds) != 2 {
+				mt.Fatalf("want 2 finds; got %v", len(finds))
+			}
+			if authors := visibleAuthors(finds[1]); !reflect.DeepEqual(authors, tt.authors) {
+				mt.Errorf("want the unpublished recipes of %v; got %v", tt.authors, authors)
+			}
+		})
+	}
+}
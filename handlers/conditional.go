@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/TranQuocToan1996/ginProject/apierrors"
+	"github.com/TranQuocToan1996/ginProject/etag"
+	"github.com/TranQuocToan1996/ginProject/models"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// nextVersion is the $inc document bumping the version of a recipe, every
+// write to a recipe applies it so that its entity tag changes
+var nextVersion = bson.M{"version": 1}
+
+// versioned restricts a filter on a recipe to one of its versions. Recipes
+// stored before versions existed have version 0.
+func versioned(filter bson.M, version int) bson.M {
+	if version == 0 {
+		filter["version"] = bson.M{"$in": bson.A{0, nil}}
+	} else {
+		filter["version"] = version
+	}
+	return filter
+}
+
+// ifMatch checks the If-Match header of a request modifying recipe, writing
+// 428 when it is missing and 412 when it doesn't match the current version
+func ifMatch(c *gin.Context, recipe models.Recipe) bool {
+	header := c.GetHeader("If-Match")
+	if header == "" {
+		c.Error(apierrors.PreconditionRequired("If-Match header is required"))
+		return false
+	}
+	if !etag.Match(header, etag.Of(recipe), false) {
+		c.Header("ETag", etag.Of(recipe))
+		c.Error(apierrors.PreconditionFailed("Recipe has been modified"))
+		return false
+	}
+	return true
+}
+
+// notModified sets the ETag header to tag and writes 304 when the If-None-Match
+// header of the request matches it
+func notModified(c *gin.Context, tag string) bool {
+	c.Header("ETag", tag)
+	if header := c.GetHeader("If-None-Match"); header != "" && etag.Match(header, tag, true) {
+		c.Status(http.StatusNotModified)
+		return true
+	}
+	return false
+}
+
+// withETags sets the entity tag of each recipe and returns the tag of the list
+func withETags(recipes []models.Recipe) string {
+	tags := make([]string, 0, len(recipes))
+	for i := range recipes {
+		recipes[i].ETag = etag.Of(recipes[i])
+		tags = append(tags, recipes[i].ETag)
+	}
+	return etag.List(tags)
+}
@@ -0,0 +1,817 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+
+	"github.com/TranQuocToan1996/ginProject/apierrors"
+	"github.com/TranQuocToan1996/ginProject/dataloader"
+	"github.com/TranQuocToan1996/ginProject/etag"
+	"github.com/TranQuocToan1996/ginProject/models"
+	"github.com/TranQuocToan1996/ginProject/negotiate"
+	"github.com/gin-gonic/gin"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Pages of recipes have defaultPageSize recipes unless asked for up to
+// maxPageSize
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// maxQueryDepth is the deepest nesting of fields a query may select. Each
+// level may load a page of items, so deeper queries are rejected before they
+// run.
+const maxQueryDepth = 10
+
+// maxQueryFields is the most fields a query may select, counting aliases of
+// the same field apart, so that a query can't fan out by repeating a field
+// under many aliases.
+const maxQueryFields = 100
+
+// GraphQLHandler serves a GraphQL schema over the recipes, their authors,
+// reviews and collections. The fields of the items of a list are loaded in
+// batches, one query per field instead of one per item.
+type GraphQLHandler struct {
+	recipes     *RecipesHandler
+	users       *mongo.Collection
+	reviews     *mongo.Collection
+	collections *mongo.Collection
+	ctx         context.Context
+	schema      graphql.Schema
+}
+
+// NewGraphQLHandler creates the GraphQL handler, writing recipes through the
+// store of the recipes handler
+func NewGraphQLHandler(ctx context.Context, recipes *RecipesHandler, users *mongo.Collection, reviews *mongo.Collection, collections *mongo.Collection) (*GraphQLHandler, error) {
+	handler := &GraphQLHandler{
+		recipes:     recipes,
+		users:       users,
+		reviews:     reviews,
+		collections: collections,
+		ctx:         ctx,
+	}
+	schema, err := handler.newSchema()
+	if err != nil {
+		return nil, err
+	}
+	handler.schema = schema
+	return handler, nil
+}
+
+// GraphQLRequest is the body of a GraphQL query
+type GraphQLRequest struct {
+	Query         string                 `json:"query" binding:"required"`
+	Variables     map[string]interface{} `json:"variables"`
+	OperationName string                 `json:"operationName"`
+}
+
+// Query runs a GraphQL query or mutation on behalf of the signed in user. As
+// GraphQL servers do, errors of fields are listed in the errors of a 200
+// response, with the code and status of the error in their extensions.
+func (handler *GraphQLHandler) Query(c *gin.Context) {
+	var request GraphQLRequest
+	if err := negotiate.Bind(c, &request); err != nil {
+		c.Error(apierrors.InvalidInput(err))
+		return
+	}
+	var rejected *apierrors.Error
+	switch depth, fields := measureQuery(request.Query); {
+	case depth > maxQueryDepth:
+		rejected = apierrors.BadRequest(fmt.Sprintf("Query nests %v fields deep, more than %v", depth, maxQueryDepth))
+	case fields > maxQueryFields:
+		rejected = apierrors.BadRequest(fmt.Sprintf("Query selects more than %v fields", maxQueryFields))
+	}
+	if rejected != nil {
+		err := graphQLError{rejected}
+		c.JSON(http.StatusOK, &graphql.Result{Errors: []gqlerrors.FormattedError{
+			{Message: err.Error(), Extensions: err.Extensions()},
+		}})
+		return
+	}
+	result := graphql.Do(graphql.Params{
+		Schema:         handler.schema,
+		RequestString:  request.Query,
+		VariableValues: request.Variables,
+		OperationName:  request.OperationName,
+		Context:        context.WithValue(handler.ctx, loadersKey{}, handler.newLoaders(currentUser(c))),
+	})
+	c.JSON(http.StatusOK, result)
+}
+
+// measureQuery returns the deepest nesting of fields the operations of query
+// select, through their fragments, and the number of fields they select. The
+// fields of a fragment count at each of its spreads, and any number past
+// maxQueryFields counts as maxQueryFields+1. A query that doesn't parse
+// measures 0, graphql.Do reports its syntax errors.
+func measureQuery(query string) (depth, fields int) {
+	document, err := parser.Parse(parser.ParseParams{Source: query})
+	if err != nil {
+		return 0, 0
+	}
+	fragments := make(map[string]*ast.FragmentDefinition)
+	for _, definition := range document.Definitions {
+		if fragment, ok := definition.(*ast.FragmentDefinition); ok {
+			fragments[fragment.Name.Value] = fragment
+		}
+	}
+	count := func(a, b int) int {
+		if a+b > maxQueryFields {
+			return maxQueryFields + 1
+		}
+		return a + b
+	}
+
+	// Fragments are measured once, and a fragment spreading itself, which
+	// validation rejects, adds nothing
+	type measure struct{ depth, fields int }
+	measured := make(map[string]measure)
+	var walk func(set *ast.SelectionSet) measure
+	walk = func(set *ast.SelectionSet) measure {
+		total := measure{}
+		if set == nil {
+			return total
+		}
+		for _, selection := range set.Selections {
+			m := measure{}
+			switch selection := selection.(type) {
+			case *ast.Field:
+				m = walk(selection.SelectionSet)
+				m.depth, m.fields = m.depth+1, count(m.fields, 1)
+			case *ast.InlineFragment:
+				m = walk(selection.SelectionSet)
+			case *ast.FragmentSpread:
+				name := selection.Name.Value
+				var ok bool
+				m, ok = measured[name]
+				if fragment, defined := fragments[name]; !ok && defined {
+					measured[name] = measure{}
+					m = walk(fragment.SelectionSet)
+					measured[name] = m
+				}
+			}
+			if m.depth > total.depth {
+				total.depth = m.depth
+			}
+			total.fields = count(total.fields, m.fields)
+		}
+		return total
+	}
+
+	for _, definition := range document.Definitions {
+		if operation, ok := definition.(*ast.OperationDefinition); ok {
+			m := walk(operation.SelectionSet)
+			if m.depth > depth {
+				depth = m.depth
+			}
+			fields = count(fields, m.fields)
+		}
+	}
+	return depth, fields
+}
+
+type loadersKey struct{}
+
+// loaders batch the loads of one request on behalf of user
+type loaders struct {
+	user        string
+	users       *dataloader.Loader[*models.User]
+	recipes     *dataloader.Loader[*models.Recipe]
+	authored    *dataloader.Loader[[]models.Recipe]
+	reviews     *dataloader.Loader[[]models.Review]
+	collections *dataloader.Loader[[]models.Collection]
+}
+
+func loadersOf(p graphql.ResolveParams) *loaders {
+	return p.Context.Value(loadersKey{}).(*loaders)
+}
+
+func (handler *GraphQLHandler) newLoaders(user string) *loaders {
+	return &loaders{
+		user:        user,
+		users:       dataloader.New(handler.loadUsers),
+		recipes:     dataloader.New(func(ids []string) (map[string]*models.Recipe, error) { return handler.loadRecipes(ids, user) }),
+		authored:    dataloader.New(func(names []string) (map[string][]models.Recipe, error) { return handler.loadAuthored(names, user) }),
+		reviews:     dataloader.New(handler.loadReviews),
+		collections: dataloader.New(func(ids []string) (map[string][]models.Collection, error) { return handler.loadCollections(ids, user) }),
+	}
+}
+
+// loadUsers loads users by name, without their password
+func (handler *GraphQLHandler) loadUsers(names []string) (map[string]*models.User, error) {
+	cursor, err := handler.users.Find(handler.ctx, bson.M{"username": bson.M{"$in": names}},
+		options.Find().SetProjection(bson.M{"password": 0}))
+	if err != nil {
+		return nil, err
+	}
+	var users []models.User
+	if err := cursor.All(handler.ctx, &users); err != nil {
+		return nil, err
+	}
+	byName := make(map[string]*models.User, len(users))
+	for i := range users {
+		byName[users[i].Name] = &users[i]
+	}
+	return byName, nil
+}
+
+// loadRecipes loads the recipes of hex ids user can see
+func (handler *GraphQLHandler) loadRecipes(ids []string, user string) (map[string]*models.Recipe, error) {
+	cursor, err := handler.recipes.collection.Find(handler.ctx, visibleTo(bson.M{
+		"_id": bson.M{"$in": objectIDs(ids)},
+	}, user))
+	if err != nil {
+		return nil, err
+	}
+	var recipes []models.Recipe
+	if err := cursor.All(handler.ctx, &recipes); err != nil {
+		return nil, err
+	}
+	byID := make(map[string]*models.Recipe, len(recipes))
+	for i := range recipes {
+		byID[recipes[i].ID.Hex()] = &recipes[i]
+	}
+	return byID, nil
+}
+
+// loadAuthored loads the recipes user can see of authors by name, whatever
+// their status
+func (handler *GraphQLHandler) loadAuthored(names []string, user string) (map[string][]models.Recipe, error) {
+	cursor, err := handler.recipes.collection.Find(handler.ctx, visibleTo(bson.M{
+		"author": bson.M{"$in": names},
+	}, user))
+	if err != nil {
+		return nil, err
+	}
+	var recipes []models.Recipe
+	if err := cursor.All(handler.ctx, &recipes); err != nil {
+		return nil, err
+	}
+	byAuthor := make(map[string][]models.Recipe)
+	for _, recipe := range recipes {
+		byAuthor[recipe.Author] = append(byAuthor[recipe.Author], recipe)
+	}
+	return byAuthor, nil
+}
+
+// loadReviews loads the visible reviews of recipes by hex id, newest first
+func (handler *GraphQLHandler) loadReviews(ids []string) (map[string][]models.Review, error) {
+	cursor, err := handler.reviews.Find(handler.ctx, bson.M{
+		"recipeId": bson.M{"$in": objectIDs(ids)},
+		"hidden":   false,
+	}, options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}}))
+	if err != nil {
+		return nil, err
+	}
+	var reviews []models.Review
+	if err := cursor.All(handler.ctx, &reviews); err != nil {
+		return nil, err
+	}
+	byRecipe := make(map[string][]models.Review)
+	for _, review := range reviews {
+		id := review.RecipeID.Hex()
+		byRecipe[id] = append(byRecipe[id], review)
+	}
+	return byRecipe, nil
+}
+
+// loadCollections loads the collections of user holding recipes by hex id
+func (handler *GraphQLHandler) loadCollections(ids []string, user string) (map[string][]models.Collection, error) {
+	cursor, err := handler.collections.Find(handler.ctx, bson.M{
+		"owner":   user,
+		"recipes": bson.M{"$in": objectIDs(ids)},
+	}, options.Find().SetSort(bson.D{{Key: "name", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	var collections []models.Collection
+	if err := cursor.All(handler.ctx, &collections); err != nil {
+		return nil, err
+	}
+	byRecipe := make(map[string][]models.Collection)
+	for _, collection := range collections {
+		for _, recipeID := range collection.Recipes {
+			id := recipeID.Hex()
+			byRecipe[id] = append(byRecipe[id], collection)
+		}
+	}
+	return byRecipe, nil
+}
+
+// userCollections returns the collections of user
+func (handler *GraphQLHandler) userCollections(user string) ([]models.Collection, error) {
+	cursor, err := handler.collections.Find(handler.ctx, bson.M{"owner": user},
+		options.Find().SetSort(bson.D{{Key: "name", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	collections := make([]models.Collection, 0)
+	err = cursor.All(handler.ctx, &collections)
+	return collections, err
+}
+
+// objectIDs converts hex ids, skipping the invalid ones
+func objectIDs(ids []string) []primitive.ObjectID {
+	objectIDs := make([]primitive.ObjectID, 0, len(ids))
+	for _, id := range ids {
+		if objectID, err := parseID(id); err == nil {
+			objectIDs = append(objectIDs, objectID)
+		}
+	}
+	return objectIDs
+}
+
+// recipeConnection is a page of recipes, the cursor of a recipe being its id
+type recipeConnection struct {
+	TotalCount int             `json:"totalCount"`
+	Nodes      []models.Recipe `json:"nodes"`
+	PageInfo   pageInfo        `json:"pageInfo"`
+}
+
+type pageInfo struct {
+	EndCursor   string `json:"endCursor"`
+	HasNextPage bool   `json:"hasNextPage"`
+}
+
+// paginate returns the first recipes after the cursor after, in the order
+// of their ids
+func paginate(recipes []models.Recipe, first int, after string) (recipeConnection, error) {
+	if first < 0 || first > maxPageSize {
+		return recipeConnection{}, apierrors.BadRequest("first must be between 0 and 100")
+	}
+	sort.Slice(recipes, func(i, j int) bool {
+		return recipes[i].ID.Hex() < recipes[j].ID.Hex()
+	})
+	start := 0
+	if after != "" {
+		start = sort.Search(len(recipes), func(i int) bool {
+			return recipes[i].ID.Hex() > after
+		})
+	}
+	end := start + first
+	if end > len(recipes) {
+		end = len(recipes)
+	}
+	connection := recipeConnection{
+		TotalCount: len(recipes),
+		Nodes:      recipes[start:end],
+		PageInfo:   pageInfo{HasNextPage: end < len(recipes)},
+	}
+	if end > start {
+		connection.PageInfo.EndCursor = recipes[end-1].ID.Hex()
+	}
+	return connection, nil
+}
+
+// graphQLError is an error of the API carrying its code, status and invalid
+// fields in the extensions of GraphQL errors
+type graphQLError struct {
+	err *apierrors.Error
+}
+
+func (e graphQLError) Error() string {
+	return e.err.Error()
+}
+
+func (e graphQLError) Extensions() map[string]interface{} {
+	extensions := map[string]interface{}{"code": e.err.Code, "status": e.err.Status()}
+	if len(e.err.Fields) > 0 {
+		extensions["fields"] = e.err.Fields
+	}
+	return extensions
+}
+
+// resolved returns the result of a resolver, mapping err to a GraphQL error
+func resolved(value interface{}, err error) (interface{}, error) {
+	if err == nil {
+		return value, nil
+	}
+	apiErr := apierrors.From(err)
+	if apiErr.Status() >= http.StatusInternalServerError {
+		log.Println("graphql:", err)
+	}
+	return nil, graphQLError{apiErr}
+}
+
+// thunk defers a resolver until its batch is loaded
+func thunk(resolve func() (interface{}, error)) func() (interface{}, error) {
+	return func() (interface{}, error) {
+		return resolved(resolve())
+	}
+}
+
+// stringList converts a list argument
+func stringList(value interface{}) []string {
+	values, _ := value.([]interface{})
+	list := make([]string, 0, len(values))
+	for _, v := range values {
+		if s, ok := v.(string); ok {
+			list = append(list, s)
+		}
+	}
+	return list
+}
+
+// recipeInput converts a RecipeInput argument to a recipe
+func recipeInput(value interface{}) (models.Recipe, error) {
+	var recipe models.Recipe
+	data, err := json.Marshal(value)
+	if err != nil {
+		return recipe, err
+	}
+	err = json.Unmarshal(data, &recipe)
+	return recipe, err
+}
+
+// hexID resolves the id of a source with an ObjectID
+func hexID(id func(source interface{}) primitive.ObjectID) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		return id(p.Source).Hex(), nil
+	}
+}
+
+func (handler *GraphQLHandler) newSchema() (graphql.Schema, error) {
+	instructionType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Instruction",
+		Fields: graphql.Fields{
+			"step":    &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"section": &graphql.Field{Type: graphql.String},
+			"text":    &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		},
+	})
+	ratingType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Rating",
+		Fields: graphql.Fields{
+			"average": &graphql.Field{Type: graphql.NewNonNull(graphql.Float)},
+			"count":   &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		},
+	})
+	pageInfoType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "PageInfo",
+		Fields: graphql.Fields{
+			"endCursor":   &graphql.Field{Type: graphql.String},
+			"hasNextPage": &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+		},
+	})
+
+	var recipeType, userType, reviewType, collectionType, connectionType *graphql.Object
+
+	// author resolves the user of a name, null for the recipes and reviews of
+	// deleted users
+	author := func(name string, p graphql.ResolveParams) (interface{}, error) {
+		if name == "" {
+			return nil, nil
+		}
+		user := loadersOf(p).users.Load(name)
+		return thunk(func() (interface{}, error) {
+			u, err := user()
+			if u == nil {
+				return nil, err
+			}
+			return *u, err
+		}), nil
+	}
+	pageArgs := graphql.FieldConfigArgument{
+		"first": &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: defaultPageSize},
+		"after": &graphql.ArgumentConfig{Type: graphql.String, Description: "endCursor of the previous page"},
+	}
+	page := func(p graphql.ResolveParams, recipes []models.Recipe) (recipeConnection, error) {
+		first, ok := p.Args["first"].(int)
+		if !ok {
+			first = defaultPageSize
+		}
+		after, _ := p.Args["after"].(string)
+		return paginate(recipes, first, after)
+	}
+	recipePage := func(p graphql.ResolveParams, filter RecipeFilter) (interface{}, error) {
+		recipes, err := handler.recipes.FilterRecipes(loadersOf(p).user, filter)
+		if err != nil {
+			return resolved(nil, err)
+		}
+		return resolved(page(p, recipes))
+	}
+
+	recipeType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Recipe",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"id": &graphql.Field{Type: graphql.NewNonNull(graphql.ID), Resolve: hexID(func(source interface{}) primitive.ObjectID {
+					return source.(models.Recipe).ID
+				})},
+				"name":          &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+				"tags":          &graphql.Field{Type: graphql.NewList(graphql.String)},
+				"ingredients":   &graphql.Field{Type: graphql.NewList(graphql.String)},
+				"instructions":  &graphql.Field{Type: graphql.NewList(instructionType)},
+				"servings":      &graphql.Field{Type: graphql.Int},
+				"status":        &graphql.Field{Type: graphql.String},
+				"publishedAt":   &graphql.Field{Type: graphql.DateTime},
+				"version":       &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+				"rating":        &graphql.Field{Type: ratingType},
+				"favoriteCount": &graphql.Field{Type: graphql.Int},
+				"dietLabels":    &graphql.Field{Type: graphql.NewList(graphql.String)},
+				"allergens":     &graphql.Field{Type: graphql.NewList(graphql.String)},
+				"etag": &graphql.Field{
+					Type:        graphql.NewNonNull(graphql.String),
+					Description: "Entity tag of the version, for the If-Match header of REST writes",
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return etag.Of(p.Source.(models.Recipe)), nil
+					},
+				},
+				"author": &graphql.Field{Type: userType, Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return author(p.Source.(models.Recipe).Author, p)
+				}},
+				"reviews": &graphql.Field{
+					Type:        graphql.NewList(reviewType),
+					Description: "Visible reviews, newest first",
+					Args: graphql.FieldConfigArgument{
+						"first": &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 10},
+					},
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						reviews := loadersOf(p).reviews.Load(p.Source.(models.Recipe).ID.Hex())
+						first, ok := p.Args["first"].(int)
+						return thunk(func() (interface{}, error) {
+							list, err := reviews()
+							if ok && first >= 0 && first < len(list) {
+								list = list[:first]
+							}
+							return list, err
+						}), nil
+					},
+				},
+				"collections": &graphql.Field{
+					Type:        graphql.NewList(collectionType),
+					Description: "Collections of the signed in user holding the recipe",
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return thunk(dataloader.Thunk(loadersOf(p).collections.Load(p.Source.(models.Recipe).ID.Hex()))), nil
+					},
+				},
+			}
+		}),
+	})
+
+	reviewType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Review",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"id": &graphql.Field{Type: graphql.NewNonNull(graphql.ID), Resolve: hexID(func(source interface{}) primitive.ObjectID {
+					return source.(models.Review).ID
+				})},
+				"rating":    &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+				"text":      &graphql.Field{Type: graphql.String},
+				"createdAt": &graphql.Field{Type: graphql.DateTime},
+				"author": &graphql.Field{Type: userType, Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return author(p.Source.(models.Review).UserName, p)
+				}},
+			}
+		}),
+	})
+
+	collectionType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Collection",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"id": &graphql.Field{Type: graphql.NewNonNull(graphql.ID), Resolve: hexID(func(source interface{}) primitive.ObjectID {
+					return source.(models.Collection).ID
+				})},
+				"name":      &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+				"createdAt": &graphql.Field{Type: graphql.DateTime},
+				"updatedAt": &graphql.Field{Type: graphql.DateTime},
+				"recipes": &graphql.Field{
+					Type:        graphql.NewList(recipeType),
+					Description: "Recipes of the collection, in its order",
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						ids := p.Source.(models.Collection).Recipes
+						loads := make([]func() (*models.Recipe, error), 0, len(ids))
+						for _, id := range ids {
+							loads = append(loads, loadersOf(p).recipes.Load(id.Hex()))
+						}
+						return thunk(func() (interface{}, error) {
+							recipes := make([]models.Recipe, 0, len(loads))
+							for _, load := range loads {
+								recipe, err := load()
+								if err != nil {
+									return nil, err
+								}
+								if recipe != nil {
+									recipes = append(recipes, *recipe)
+								}
+							}
+							return recipes, nil
+						}), nil
+					},
+				},
+			}
+		}),
+	})
+
+	connectionType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "RecipeConnection",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"totalCount": &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+				"nodes":      &graphql.Field{Type: graphql.NewList(recipeType)},
+				"pageInfo":   &graphql.Field{Type: graphql.NewNonNull(pageInfoType)},
+			}
+		}),
+	})
+
+	userType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "User",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			recipesArgs := graphql.FieldConfigArgument{
+				"statuses": &graphql.ArgumentConfig{Type: graphql.NewList(graphql.String), Description: "Statuses of the recipes, published by default"},
+			}
+			for name, arg := range pageArgs {
+				recipesArgs[name] = arg
+			}
+			return graphql.Fields{
+				"username": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+				"recipes": &graphql.Field{
+					Type: graphql.NewNonNull(connectionType),
+					Args: recipesArgs,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						filter := RecipeFilter{Statuses: stringList(p.Args["statuses"])}
+						if err := filter.validate(); err != nil {
+							return resolved(nil, err)
+						}
+						recipes := loadersOf(p).authored.Load(p.Source.(models.User).Name)
+						return thunk(func() (interface{}, error) {
+							list, err := recipes()
+							if err != nil {
+								return nil, err
+							}
+							return page(p, visibleRecipes(list, loadersOf(p).user, filter.Statuses))
+						}), nil
+					},
+				},
+				"collections": &graphql.Field{
+					Type:        graphql.NewList(collectionType),
+					Description: "Collections of the user, null unless it is the signed in user",
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						name := p.Source.(models.User).Name
+						if name != loadersOf(p).user {
+							return nil, nil
+						}
+						return resolved(handler.userCollections(name))
+					},
+				},
+			}
+		}),
+	})
+
+	filterType := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "RecipeFilter",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"tag":              &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"author":           &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"query":            &graphql.InputObjectFieldConfig{Type: graphql.String, Description: "Text the name contains"},
+			"statuses":         &graphql.InputObjectFieldConfig{Type: graphql.NewList(graphql.String)},
+			"diets":            &graphql.InputObjectFieldConfig{Type: graphql.NewList(graphql.String)},
+			"excludeAllergens": &graphql.InputObjectFieldConfig{Type: graphql.NewList(graphql.String)},
+		},
+	})
+	instructionInputType := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "InstructionInput",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"section": &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"text":    &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+		},
+	})
+	recipeInputType := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "RecipeInput",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"name":         &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+			"tags":         &graphql.InputObjectFieldConfig{Type: graphql.NewList(graphql.String)},
+			"ingredients":  &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.NewList(graphql.String))},
+			"instructions": &graphql.InputObjectFieldConfig{Type: graphql.NewList(instructionInputType)},
+			"servings":     &graphql.InputObjectFieldConfig{Type: graphql.Int},
+			"status":       &graphql.InputObjectFieldConfig{Type: graphql.String},
+		},
+	})
+
+	queryArgs := graphql.FieldConfigArgument{
+		"filter": &graphql.ArgumentConfig{Type: filterType},
+	}
+	for name, arg := range pageArgs {
+		queryArgs[name] = arg
+	}
+	idArgs := graphql.FieldConfigArgument{
+		"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+	}
+	user := func(p graphql.ResolveParams, name string) (interface{}, error) {
+		u, err := loadersOf(p).users.Load(name)()
+		if u == nil {
+			return resolved(nil, err)
+		}
+		return *u, nil
+	}
+
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"recipe": &graphql.Field{
+				Type: recipeType,
+				Args: idArgs,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return resolved(handler.recipes.FindRecipe(p.Args["id"].(string), loadersOf(p).user))
+				},
+			},
+			"recipes": &graphql.Field{
+				Type: graphql.NewNonNull(connectionType),
+				Args: queryArgs,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					filter, _ := p.Args["filter"].(map[string]interface{})
+					tag, _ := filter["tag"].(string)
+					name, _ := filter["author"].(string)
+					text, _ := filter["query"].(string)
+					return recipePage(p, RecipeFilter{
+						Tag:              tag,
+						Author:           name,
+						Query:            text,
+						Statuses:         stringList(filter["statuses"]),
+						Diets:            stringList(filter["diets"]),
+						ExcludeAllergens: stringList(filter["excludeAllergens"]),
+					})
+				},
+			},
+			"user": &graphql.Field{
+				Type: userType,
+				Args: graphql.FieldConfigArgument{
+					"username": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return user(p, p.Args["username"].(string))
+				},
+			},
+			"me": &graphql.Field{
+				Type:        userType,
+				Description: "The signed in user",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return user(p, loadersOf(p).user)
+				},
+			},
+		},
+	})
+
+	mutation := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Mutation",
+		Fields: graphql.Fields{
+			"createRecipe": &graphql.Field{
+				Type: recipeType,
+				Args: graphql.FieldConfigArgument{
+					"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(recipeInputType)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					recipe, err := recipeInput(p.Args["input"])
+					if err != nil {
+						return resolved(nil, apierrors.InvalidInput(err))
+					}
+					err = handler.recipes.CreateRecipe(&recipe, loadersOf(p).user)
+					return resolved(recipe, err)
+				},
+			},
+			"updateRecipe": &graphql.Field{
+				Type:        recipeType,
+				Description: "Replaces the content of a recipe, if version is still its current version",
+				Args: graphql.FieldConfigArgument{
+					"id":      &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+					"version": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+					"input":   &graphql.ArgumentConfig{Type: graphql.NewNonNull(recipeInputType)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					recipe, err := recipeInput(p.Args["input"])
+					if err != nil {
+						return resolved(nil, apierrors.InvalidInput(err))
+					}
+					return resolved(handler.recipes.UpdateRecipe(p.Args["id"].(string), loadersOf(p).user, p.Args["version"].(int), recipe))
+				},
+			},
+			"deleteRecipe": &graphql.Field{
+				Type:        graphql.Boolean,
+				Description: "Moves a recipe to the trash, if version is still its current version",
+				Args: graphql.FieldConfigArgument{
+					"id":      &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+					"version": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					err := handler.recipes.DeleteRecipe(p.Args["id"].(string), loadersOf(p).user, p.Args["version"].(int))
+					return resolved(err == nil, err)
+				},
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{Query: query, Mutation: mutation})
+}
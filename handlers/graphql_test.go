@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/TranQuocToan1996/ginProject/workflow"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestMeasureQuery(t *testing.T) {
+	for i, tt := range []struct {
+		query  string
+		depth  int
+		fields int
+	}{
+		{`{ me { username } }`, 2, 2},
+		{`{ recipes { nodes { name } } me { recipes { nodes { author { username } } } } }`, 5, 8},
+		{`query { me { ...mine } } fragment mine on User { recipes { nodes { name } } }`, 4, 4},
+		{`{ me { ...mine ...mine } } fragment mine on User { recipes { nodes { name } } }`, 4, 7},
+		{`{ me { ... on User { collections { name } } } }`, 3, 3},
+		{`{ me { ...loop } } fragment loop on User { ...loop }`, 1, 1},
+		{`{ me {`, 0, 0},
+		{`{` + strings.Repeat(` r: recipes(first: 100) { nodes { name } }`, 40) + ` }`, 3, maxQueryFields + 1},
+	} {
+		t.Run(fmt.Sprintf("%v", i), func(t *testing.T) {
+			if depth, fields := measureQuery(tt.query); depth != tt.depth || fields != tt.fields {
+				t.Errorf("want %v deep, %v fields; got %v, %v", tt.depth, tt.fields, depth, fields)
+			}
+		})
+	}
+}
+
+func TestGraphQLQuery(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+	mt.Run("nested", func(mt *mtest.T) {
+		handler, err := NewGraphQLHandler(context.Background(), newTestHandler(mt), mt.Coll, mt.Coll, mt.Coll)
+		if err != nil {
+			mt.Fatal(err)
+		}
+		soup, stew, draft := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+		recipe := func(id primitive.ObjectID, name, author, status string) bson.D {
+			return recipeDoc(id, 1, bson.E{Key: "name", Value: name}, bson.E{Key: "author", Value: author}, bson.E{Key: "status", Value: status})
+		}
+		mt.AddMockResponses(
+			found(mt, bson.D{{Key: "username", Value: testUser}}),
+			found(mt, bson.D{
+				{Key: "_id", Value: primitive.NewObjectID()}, {Key: "owner", Value: testUser}, {Key: "name", Value: "Winter"},
+				{Key: "recipes", Value: bson.A{soup, stew}},
+			}),
+			found(mt,
+				recipe(soup, "Soup", "alice", workflow.Published), recipe(stew, "Stew", "bob", workflow.Published)),
+			found(mt,
+				bson.D{{Key: "username", Value: "alice"}}, bson.D{{Key: "username", Value: "bob"}}),
+			found(mt,
+				recipe(soup, "Soup", "alice", workflow.Published), recipe(stew, "Stew", "bob", workflow.Published),
+				recipe(draft, "Draft", "bob", workflow.Draft)),
+		)
+
+		query := `{ me { collections { name recipes { name author { username recipes { totalCount nodes { name } } } } } } }`
+		body, _ := json.Marshal(GraphQLRequest{Query: query})
+		w := serve(http.MethodPost, "/graphql", "/graphql", strings.NewReader(string(body)),
+			http.Header{"Content-Type": {"application/json"}}, handler.Query)
+
+		want := `{"data":{"me":{"collections":[{"name":"Winter","recipes":[` +
+			`{"author":{"recipes":{"nodes":[{"name":"Soup"}],"totalCount":1},"username":"alice"},"name":"Soup"},` +
+			`{"author":{"recipes":{"nodes":[{"name":"Stew"}],"totalCount":1},"username":"bob"},"name":"Stew"}]}]}}}`
+		if w.Code != http.StatusOK || w.Body.String() != want {
+			mt.Errorf("want %v %s; got %v %s", http.StatusOK, want, w.Code, w.Body)
+		}
+		// The recipes of both authors are loaded by a single find
+		finds := commands(mt, "find")
+		if len(finds) != 5 {
+			mt.Fatalf("want 5 finds; got %v", len(finds))
+		}
+		var filter struct {
+			Author struct {
+				In []string `bson:"$in"`
+			} `bson:"author"`
+		}
+		if err := bson.Unmarshal(finds[4].Lookup("filter").Document(), &filter); err != nil {
+			mt.Fatal(err)
+		}
+		if want := []string{"alice", "bob"}; !reflect.DeepEqual(filter.Author.In, want) {
+			mt.Errorf("want the recipes of %v; got %v", want, filter.Author.In)
+		}
+	})
+	mt.Run("too deep", func(mt *mtest.T) {
+		handler, err := NewGraphQLHandler(context.Background(), newTestHandler(mt), mt.Coll, mt.Coll, mt.Coll)
+		if err != nil {
+			mt.Fatal(err)
+		}
+		query := `{ me` + strings.Repeat(` { recipes { nodes { author`, 4) + ` { username }` + strings.Repeat(` } } }`, 4) + ` }`
+		body, _ := json.Marshal(GraphQLRequest{Query: query})
+		w := serve(http.MethodPost, "/graphql", "/graphql", strings.NewReader(string(body)),
+			http.Header{"Content-Type": {"application/json"}}, handler.Query)
+		if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"code":"bad_request"`) {
+			mt.Errorf("want a bad_request error; got %v %s", w.Code, w.Body)
+		}
+		if finds := commands(mt, "find"); len(finds) != 0 {
+			mt.Errorf("want no query run; got %v finds", len(finds))
+		}
+	})
+	mt.Run("aliased fan-out", func(mt *mtest.T) {
+		handler, err := NewGraphQLHandler(context.Background(), newTestHandler(mt), mt.Coll, mt.Coll, mt.Coll)
+		if err != nil {
+			mt.Fatal(err)
+		}
+		query := `{`
+		for i := 0; i < 20; i++ {
+			query += fmt.Sprintf(` r%v: recipes(first: 100) { nodes { author { recipes(first: 100) { nodes { name } } } } }`, i)
+		}
+		query += ` }`
+		body, _ := json.Marshal(GraphQLRequest{Query: query})
+		w := serve(http.MethodPost, "/graphql", "/graphql", strings.NewReader(string(body)),
+			http.Header{"Content-Type": {"application/json"}}, handler.Query)
+		if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"code":"bad_request"`) {
+			mt.Errorf("want a bad_request error; got %v %s", w.Code, w.Body)
+		}
+		if finds := commands(mt, "find"); len(finds) != 0 {
+			mt.Errorf("want no query run; got %v finds", len(finds))
+		}
+	})
+}
@@ -0,0 +1,246 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/TranQuocToan1996/ginProject/apierrors"
+	"github.com/TranQuocToan1996/ginProject/etag"
+	"github.com/TranQuocToan1996/ginProject/models"
+	"github.com/TranQuocToan1996/ginProject/recipespb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// RecipeServer serves the gRPC RecipeService through the store and cache of
+// the recipes handler
+type RecipeServer struct {
+	recipespb.UnimplementedRecipeServiceServer
+	recipes *RecipesHandler
+}
+
+// NewRecipeServer creates the gRPC recipe service
+func NewRecipeServer(recipes *RecipesHandler) *RecipeServer {
+	return &RecipeServer{recipes: recipes}
+}
+
+// Get returns a recipe by id
+func (server *RecipeServer) Get(ctx context.Context, request *recipespb.GetRequest) (*recipespb.Recipe, error) {
+	recipe, err := server.recipes.FindRecipe(request.Id, callUser(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return recipeMessage(recipe), nil
+}
+
+// List streams the recipes of the requested statuses
+func (server *RecipeServer) List(request *recipespb.ListRequest, stream recipespb.RecipeService_ListServer) error {
+	recipes, err := server.recipes.FilterRecipes(callUser(stream.Context()), RecipeFilter{
+		Statuses: request.Statuses,
+	})
+	if err != nil {
+		return err
+	}
+	for _, recipe := range recipes {
+		if err := stream.Send(recipeMessage(recipe)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Search returns the recipes matching the filter of the request
+func (server *RecipeServer) Search(ctx context.Context, request *recipespb.SearchRequest) (*recipespb.SearchResponse, error) {
+	recipes, err := server.recipes.FilterRecipes(callUser(ctx), RecipeFilter{
+		Tag:              request.Tag,
+		Author:           request.Author,
+		Query:            request.Query,
+		Statuses:         request.Statuses,
+		Diets:            request.Diets,
+		ExcludeAllergens: request.ExcludeAllergens,
+	})
+	if err != nil {
+		return nil, err
+	}
+	response := &recipespb.SearchResponse{Recipes: make([]*recipespb.Recipe, 0, len(recipes))}
+	for _, recipe := range recipes {
+		response.Recipes = append(response.Recipes, recipeMessage(recipe))
+	}
+	return response, nil
+}
+
+// Create stores a new recipe of the user of the JWT
+func (server *RecipeServer) Create(ctx context.Context, request *recipespb.CreateRequest) (*recipespb.Recipe, error) {
+	user, err := writer(ctx)
+	if err != nil {
+		return nil, err
+	}
+	recipe := recipeOf(request.Recipe)
+	if err := server.recipes.CreateRecipe(&recipe, user); err != nil {
+		return nil, err
+	}
+	return recipeMessage(recipe), nil
+}
+
+// Update replaces the content of a recipe, if the version of the request is
+// still its current version
+func (server *RecipeServer) Update(ctx context.Context, request *recipespb.UpdateRequest) (*recipespb.Recipe, error) {
+	user, err := writer(ctx)
+	if err != nil {
+		return nil, err
+	}
+	recipe, err := server.recipes.UpdateRecipe(request.Id, user, int(request.Version), recipeOf(request.Recipe))
+	if err != nil {
+		return nil, err
+	}
+	return recipeMessage(recipe), nil
+}
+
+// Delete moves a recipe to the trash, if the version of the request is still
+// its current version
+func (server *RecipeServer) Delete(ctx context.Context, request *recipespb.DeleteRequest) (*recipespb.DeleteResponse, error) {
+	user, err := writer(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := server.recipes.DeleteRecipe(request.Id, user, int(request.Version)); err != nil {
+		return nil, err
+	}
+	return &recipespb.DeleteResponse{}, nil
+}
+
+type callUserKey struct{}
+
+// callUser returns the name of the user of the JWT of a call, or an empty
+// string for the calls authenticated with the API key
+func callUser(ctx context.Context) string {
+	user, _ := ctx.Value(callUserKey{}).(string)
+	return user
+}
+
+// writer returns the user of the JWT of a call, the API key alone doesn't
+// allow writes as recipes have an author
+func writer(ctx context.Context) (string, error) {
+	user := callUser(ctx)
+	if user == "" {
+		return "", apierrors.Unauthorized("Writes need the JWT of a user")
+	}
+	return user, nil
+}
+
+// authenticate checks the credentials of the metadata of a call: a JWT in
+// authorization, with or without a Bearer prefix, whose user is added to the
+// context, or the API key in x-api-key
+func authenticate(ctx context.Context) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if tokens := md.Get("authorization"); len(tokens) > 0 {
+		claims, err := parseToken(strings.TrimPrefix(tokens[0], "Bearer "))
+		if err != nil {
+			return nil, err
+		}
+		return context.WithValue(ctx, callUserKey{}, claims.UserName), nil
+	}
+	if keys := md.Get("x-api-key"); len(keys) > 0 && validAPIKey(keys[0]) {
+		return ctx, nil
+	}
+	return nil, apierrors.Unauthorized("Missing or invalid credentials")
+}
+
+// UnaryInterceptor authenticates unary gRPC calls with the credentials of
+// AuthMiddleware and AuthMiddleware_APIKEY
+func (handler *AuthHandler) UnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, next grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := authenticate(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return next(ctx, req)
+	}
+}
+
+// StreamInterceptor authenticates streaming gRPC calls
+func (handler *AuthHandler) StreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, next grpc.StreamHandler) error {
+		ctx, err := authenticate(stream.Context())
+		if err != nil {
+			return err
+		}
+		return next(srv, &authenticatedStream{ServerStream: stream, ctx: ctx})
+	}
+}
+
+// authenticatedStream is a stream whose context carries the user of the call
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (stream *authenticatedStream) Context() context.Context {
+	return stream.ctx
+}
+
+// recipeMessage converts a recipe to its protocol buffer
+func recipeMessage(recipe models.Recipe) *recipespb.Recipe {
+	return &recipespb.Recipe{
+		Id:            recipe.ID.Hex(),
+		Name:          recipe.Name,
+		Tags:          recipe.Tags,
+		Ingredients:   recipe.Ingredients,
+		Instructions:  instructionMessages(recipe.Instructions),
+		Servings:      int32(recipe.Servings),
+		DietLabels:    recipe.DietLabels,
+		Allergens:     recipe.Allergens,
+		Rating:        &recipespb.Rating{Average: recipe.Rating.Average, Count: int32(recipe.Rating.Count)},
+		FavoriteCount: int32(recipe.FavoriteCount),
+		Status:        recipe.Status,
+		Author:        recipe.Author,
+		PublishAt:     timestamp(recipe.PublishAt),
+		PublishedAt:   timestamp(&recipe.PublishedAt),
+		Version:       int32(recipe.Version),
+		Etag:          etag.Of(recipe),
+	}
+}
+
+func instructionMessages(instructions []models.Instruction) []*recipespb.Instruction {
+	messages := make([]*recipespb.Instruction, 0, len(instructions))
+	for _, instruction := range instructions {
+		messages = append(messages, &recipespb.Instruction{
+			Step:    int32(instruction.Step),
+			Section: instruction.Section,
+			Text:    instruction.Text,
+		})
+	}
+	return messages
+}
+
+// timestamp converts an optional time, zero times are unset
+func timestamp(t *time.Time) *timestamppb.Timestamp {
+	if t == nil || t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
+
+// recipeOf converts the recipe input of a request
+func recipeOf(input *recipespb.RecipeInput) models.Recipe {
+	recipe := models.Recipe{
+		Name:        input.GetName(),
+		Tags:        input.GetTags(),
+		Ingredients: input.GetIngredients(),
+		Servings:    int(input.GetServings()),
+		Status:      input.GetStatus(),
+	}
+	for _, instruction := range input.GetInstructions() {
+		recipe.Instructions = append(recipe.Instructions, models.Instruction{
+			Section: instruction.Section,
+			Text:    instruction.Text,
+		})
+	}
+	if input.GetPublishAt() != nil {
+		publishAt := input.GetPublishAt().AsTime()
+		recipe.PublishAt = &publishAt
+	}
+	return recipe
+}
@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/TranQuocToan1996/ginProject/apierrors"
+	"github.com/TranQuocToan1996/ginProject/recipespb"
+	"github.com/go-redis/redis"
+	"github.com/golang-jwt/jwt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// dialRecipes serves the recipe service of handler behind the interceptors
+// of the API over an in-memory connection, and returns a client of it
+func dialRecipes(mt *mtest.T, handler *RecipesHandler) recipespb.RecipeServiceClient {
+	listener := bufconn.Listen(1 << 20)
+	auth := &AuthHandler{}
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(apierrors.UnaryInterceptor(), auth.UnaryInterceptor()),
+		grpc.ChainStreamInterceptor(apierrors.StreamInterceptor(), auth.StreamInterceptor()))
+	recipespb.RegisterRecipeServiceServer(server, NewRecipeServer(handler))
+	go server.Serve(listener)
+	mt.Cleanup(server.Stop)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		mt.Fatal(err)
+	}
+	mt.Cleanup(func() { conn.Close() })
+	return recipespb.NewRecipeServiceClient(conn)
+}
+
+// emptyRedis returns a client of a redis server that never has a value, so
+// that reads through the cache query mongo
+func emptyRedis(mt *mtest.T) *redis.Client {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		mt.Fatal(err)
+	}
+	mt.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveEmptyRedis(conn)
+		}
+	}()
+	return redis.NewClient(&redis.Options{Addr: listener.Addr().String(), DialTimeout: time.Second})
+}
+
+// serveEmptyRedis answers the commands of conn: GET finds nothing, the other
+// commands succeed
+func serveEmptyRedis(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	for {
+		var args int
+		if _, err := fmt.Fscanf(reader, "*%d\r\n", &args); err != nil {
+			return
+		}
+		command := make([]string, args)
+		for i := range command {
+			var size int
+			if _, err := fmt.Fscanf(reader, "$%d\r\n", &size); err != nil {
+				return
+			}
+			arg := make([]byte, size+2)
+			if _, err := io.ReadFull(reader, arg); err != nil {
+				return
+			}
+			command[i] = string(arg[:size])
+		}
+		reply := "+OK\r\n"
+		switch strings.ToUpper(command[0]) {
+		case "GET":
+			reply = "$-1\r\n"
+		case "DEL":
+			reply = ":1\r\n"
+		}
+		if _, err := io.WriteString(conn, reply); err != nil {
+			return
+		}
+	}
+}
+
+// signed returns a JWT of user signed with the secret of the tests
+func signed(mt *mtest.T, user string) string {
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, &Claims{
+		UserName:       user,
+		StandardClaims: jwt.StandardClaims{ExpiresAt: time.Now().Add(time.Hour).Unix()},
+	}).SignedString([]byte("secret"))
+	if err != nil {
+		mt.Fatal(err)
+	}
+	return token
+}
+
+func TestRecipeServerAuthentication(t *testing.T) {
+	t.Setenv("JWT_SECRET", "secret")
+	t.Setenv("X_API_KEY", "key")
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+	id := primitive.NewObjectID()
+	input := &recipespb.RecipeInput{Name: "Soup", Ingredients: []string{"1 onion"}}
+	for i, tt := range []struct {
+		metadata []string
+		call     func(ctx context.Context, client recipespb.RecipeServiceClient) error
+		lookup   bool
+		code     codes.Code
+	}{
+		{nil, func(ctx context.Context, client recipespb.RecipeServiceClient) error {
+			_, err := client.Get(ctx, &recipespb.GetRequest{Id: id.Hex()})
+			return err
+		}, false, codes.Unauthenticated},
+		{[]string{"authorization", "Bearer not-a-jwt"}, func(ctx context.Context, client recipespb.RecipeServiceClient) error {
+			_, err := client.Get(ctx, &recipespb.GetRequest{Id: id.Hex()})
+			return err
+		}, false, codes.Unauthenticated},
+		{[]string{"x-api-key", "other"}, func(ctx context.Context, client recipespb.RecipeServiceClient) error {
+			_, err := client.Get(ctx, &recipespb.GetRequest{Id: id.Hex()})
+			return err
+		}, false, codes.Unauthenticated},
+		{[]string{"x-api-key", "key"}, func(ctx context.Context, client recipespb.RecipeServiceClient) error {
+			_, err := client.Get(ctx, &recipespb.GetRequest{Id: id.Hex()})
+			return err
+		}, true, codes.OK},
+		{[]string{"x-api-key", "key"}, func(ctx context.Context, client recipespb.RecipeServiceClient) error {
+			_, err := client.Create(ctx, &recipespb.CreateRequest{Recipe: input})
+			return err
+		}, false, codes.Unauthenticated},
+		// The recipe is at version 3
+		{[]string{"authorization", "Bearer " + signed(mt, testUser)}, func(ctx context.Context, client recipespb.RecipeServiceClient) error {
+			_, err := client.Update(ctx, &recipespb.UpdateRequest{Id: id.Hex(), Version: 2, Recipe: input})
+			return err
+		}, true, codes.FailedPrecondition},
+	} {
+		mt.Run(fmt.Sprintf("%v", i), func(mt *mtest.T) {
+			client := dialRecipes(mt, newTestHandler(mt))
+			if tt.lookup {
+				mt.AddMockResponses(found(mt, recipeDoc(id, 3,
+					bson.E{Key: "name", Value: "Soup"},
+					bson.E{Key: "author", Value: testUser},
+				)))
+			}
+			ctx := metadata.NewOutgoingContext(context.Background(), metadata.Pairs(tt.metadata...))
+
+			err := tt.call(ctx, client)
+			if code := status.Code(err); code != tt.code {
+				mt.Errorf("want %v; got %v", tt.code, err)
+			}
+		})
+	}
+}
+
+func TestRecipeServerList(t *testing.T) {
+	t.Setenv("JWT_SECRET", "secret")
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+	mt.Run("drafts", func(mt *mtest.T) {
+		handler := newTestHandler(mt)
+		handler.redisClient = emptyRedis(mt)
+		client := dialRecipes(mt, handler)
+		own, other := primitive.NewObjectID(), primitive.NewObjectID()
+		mt.AddMockResponses(found(mt,
+			recipeDoc(own, 1, bson.E{Key: "status", Value: "draft"}, bson.E{Key: "author", Value: testUser}),
+			recipeDoc(other, 1, bson.E{Key: "status", Value: "draft"}, bson.E{Key: "author", Value: "alice"}),
+		))
+		ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", signed(mt, testUser))
+
+		stream, err := client.List(ctx, &recipespb.ListRequest{Statuses: []string{"draft"}})
+		if err != nil {
+			mt.Fatal(err)
+		}
+		ids := make([]string, 0)
+		for {
+			recipe, err := stream.Recv()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				mt.Fatal(err)
+			}
+			ids = append(ids, recipe.Id)
+		}
+		if len(ids) != 1 || ids[0] != own.Hex() {
+			mt.Errorf("want only the own draft %v; got %v", own.Hex(), ids)
+		}
+	})
+}
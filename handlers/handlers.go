@@ -3,33 +3,55 @@ package handlers
 import (
 	"context"
 	"encoding/json"
-	"fmt"
 	"log"
 	"net/http"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/TranQuocToan1996/ginProject/apierrors"
+	"github.com/TranQuocToan1996/ginProject/diet"
+	"github.com/TranQuocToan1996/ginProject/etag"
+	"github.com/TranQuocToan1996/ginProject/ingredients"
+	"github.com/TranQuocToan1996/ginProject/instructions"
 	"github.com/TranQuocToan1996/ginProject/models"
+	"github.com/TranQuocToan1996/ginProject/negotiate"
+	"github.com/TranQuocToan1996/ginProject/nutrition"
+	"github.com/TranQuocToan1996/ginProject/units"
+	"github.com/TranQuocToan1996/ginProject/validation"
+	"github.com/TranQuocToan1996/ginProject/workflow"
 	"github.com/gin-gonic/gin"
 	"github.com/go-redis/redis"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 const noExpirationTimeRedis = 0
 
 type RecipesHandler struct {
 	collection  *mongo.Collection
+	revisions   *mongo.Collection
+	users       *mongo.Collection
 	ctx         context.Context
 	redisClient *redis.Client
+	retention   time.Duration
+	purgeHooks  []PurgeHook
 }
 
-func NewRecipesHandler(ctx context.Context, collection *mongo.Collection, redisClient *redis.Client) *RecipesHandler {
+// NewRecipesHandler creates the recipes handler, deleted recipes stay in the
+// trash for retention before being purged. Users are looked up for the admins
+// allowed to change the recipes of others.
+func NewRecipesHandler(ctx context.Context, collection *mongo.Collection, revisions *mongo.Collection, users *mongo.Collection, redisClient *redis.Client, retention time.Duration) *RecipesHandler {
 	return &RecipesHandler{
 		collection:  collection,
+		revisions:   revisions,
+		users:       users,
 		ctx:         ctx,
 		redisClient: redisClient,
+		retention:   retention,
 	}
 }
 
@@ -37,44 +59,146 @@ func NewRecipesHandler(ctx context.Context, collection *mongo.Collection, redisC
 func (handler *RecipesHandler) AddNewRecipe(c *gin.Context) {
 
 	var recipe models.Recipe
-	if err := c.ShouldBindJSON(&recipe); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := negotiate.Bind(c, &recipe); err != nil {
+		c.Error(apierrors.InvalidInput(err))
 		return
 	}
-	recipe.ID = primitive.NewObjectID()
-	recipe.PublishedAt = time.Now()
-	_, err := handler.collection.InsertOne(handler.ctx, recipe)
-	if err != nil {
-		log.Println(err)
-		c.JSON(http.StatusInternalServerError,
-			gin.H{"error": "Error while inserting a new recipe"})
+	if err := handler.CreateRecipe(&recipe, currentUser(c)); err != nil {
+		c.Error(err)
 		return
 	}
+	c.Header("ETag", recipe.ETag)
+	c.JSON(http.StatusOK, recipe)
+}
 
-	// The data cached in memory, so if we install/update new recipe in mongo. The cached not update yet.
-	// There are 2 solutions for this situation.
-	// First, Set Time TO Live (TTL) for the recipes.
-	// Second is delete recipes after install/update new recipe. The redis will load again when we call ListRecipes.
-	// In the scope of project, the data not so much. So we choose 2nd solution
-	handler.redisClient.Del("recipes")
-	log.Println("Removed redis recipes!")
+// newRecipe turns a recipe sent by a client into a new recipe of author
+func newRecipe(recipe *models.Recipe, author string) error {
+	status, err := workflow.Initial(recipe.Status)
+	if err != nil {
+		return err
+	}
+	prepareRecipe(recipe)
+	recipe.ID = primitive.NewObjectID()
+	recipe.Author = author
+	recipe.Status = status
+	recipe.PublishedAt = time.Time{}
+	if status == workflow.Published {
+		recipe.PublishedAt = time.Now()
+	}
+	if !workflow.Scheduled(*recipe, time.Now()) {
+		recipe.PublishAt = nil
+	}
+	// Images, ratings and favorites are added through their own endpoints
+	recipe.Images = nil
+	recipe.Rating = models.RatingSummary{}
+	recipe.FavoriteCount = 0
+	recipe.DeletedAt = nil
+	recipe.DeletedBy = ""
+	recipe.Version = 1
+	return nil
+}
 
-	c.JSON(http.StatusOK, recipe)
+// prepareRecipe cleans up a recipe sent by a client and derives the fields
+// computed from its content before it is written to mongo
+func prepareRecipe(recipe *models.Recipe) {
+	recipe.Instructions = instructions.Normalize(recipe.Instructions)
+	recipe.Nutrition = nutrition.Calculate(recipe.Ingredients, recipe.Servings)
+	classification := diet.Classify(recipe.Ingredients)
+	recipe.DietLabels = classification.Labels
+	recipe.Allergens = classification.Allergens
 }
 
-// ListRecipes returns a list of recipes in JSON format
+// derivedFields returns the fields set by prepareRecipe as a $set document
+func derivedFields(recipe models.Recipe) bson.D {
+	return bson.D{
+		{Key: "instructions", Value: recipe.Instructions},
+		{Key: "nutrition", Value: recipe.Nutrition},
+		{Key: "dietLabels", Value: recipe.DietLabels},
+		{Key: "allergens", Value: recipe.Allergens},
+	}
+}
+
+// recipeOrders are the orders ListRecipes can sort recipes by with ?sort=
+var recipeOrders = map[string]func(a, b *models.Recipe) bool{
+	"rating": func(a, b *models.Recipe) bool {
+		if a.Rating.Average != b.Rating.Average {
+			return a.Rating.Average < b.Rating.Average
+		}
+		return a.Rating.Count < b.Rating.Count
+	},
+	"name": func(a, b *models.Recipe) bool {
+		return strings.ToLower(a.Name) < strings.ToLower(b.Name)
+	},
+	"publishedAt": func(a, b *models.Recipe) bool {
+		return a.PublishedAt.Before(b.PublishedAt)
+	},
+}
+
+// ListRecipes returns a list of recipes in JSON format. ?sort=rating|name|publishedAt
+// sorts them, in descending order with ?order=desc, the default for rating.
+// ?status= lists the recipes of other statuses than published, of which only
+// the ones of the signed in user are returned.
 func (handler *RecipesHandler) ListRecipes(c *gin.Context) {
+	system, err := units.ParseSystem(c.Query("units"))
+	if err != nil {
+		c.Error(apierrors.InvalidInput(err))
+		return
+	}
+	statuses, err := statusQuery(c)
+	if err != nil {
+		c.Error(apierrors.InvalidInput(err))
+		return
+	}
+	var less func(a, b *models.Recipe) bool
+	if key := c.Query("sort"); key != "" {
+		if less = recipeOrders[key]; less == nil {
+			c.Error(apierrors.BadRequest("Unknown sort " + key))
+			return
+		}
+	}
+	order := c.DefaultQuery("order", "asc")
+	if c.Query("order") == "" && c.Query("sort") == "rating" {
+		order = "desc"
+	}
+	if order != "asc" && order != "desc" {
+		c.Error(apierrors.BadRequest("order must be asc or desc"))
+		return
+	}
+	recipes, err := handler.loadRecipes()
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	recipes = visibleRecipes(recipes, currentUser(c), statuses)
+	if less != nil {
+		sort.SliceStable(recipes, func(i, j int) bool {
+			if order == "desc" {
+				return less(&recipes[j], &recipes[i])
+			}
+			return less(&recipes[i], &recipes[j])
+		})
+	}
+	if notModified(c, withETags(recipes)) {
+		return
+	}
+	for i := range recipes {
+		convertRecipeUnits(&recipes[i], system)
+	}
+	c.JSON(http.StatusOK, recipes)
+}
+
+// loadRecipes returns every recipe, from redis when cached or from mongo
+// otherwise, in which case the result is cached for the next call
+func (handler *RecipesHandler) loadRecipes() ([]models.Recipe, error) {
 	recipes := make([]models.Recipe, 0)
 
 	redisVal, err := handler.redisClient.Get("recipes").Result()
 	if err == redis.Nil {
 		log.Println("Redis nil, Need to query data from mongo!")
 		// Query mongo
-		cursor, err := handler.collection.Find(handler.ctx, bson.M{})
+		cursor, err := handler.collection.Find(handler.ctx, notDeleted(bson.M{}))
 		if err != nil {
-			c.JSON(http.StatusInternalServerError,
-				gin.H{"error": err.Error()})
-			return
+			return nil, err
 		}
 		defer cursor.Close(handler.ctx)
 
@@ -87,138 +211,364 @@ func (handler *RecipesHandler) ListRecipes(c *gin.Context) {
 		data, _ := json.Marshal(recipes)
 
 		handler.redisClient.Set("recipes", string(data), noExpirationTimeRedis)
-		c.JSON(http.StatusOK, recipes)
+		return recipes, nil
 	} else if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error(),
-		})
-	} else {
-		log.Println("Redis has data, starting query to Redis!")
-		json.Unmarshal([]byte(redisVal), &recipes)
-		c.JSON(http.StatusOK, recipes)
+		return nil, err
 	}
+	log.Println("Redis has data, starting query to Redis!")
+	err = json.Unmarshal([]byte(redisVal), &recipes)
+	return recipes, err
+}
 
+// convertRecipeUnits rewrites the ingredient quantities and oven temperatures
+// of recipe into system. An empty system leaves the recipe untouched.
+func convertRecipeUnits(recipe *models.Recipe, system units.System) {
+	if system == "" {
+		return
+	}
+	for i, line := range recipe.Ingredients {
+		ingredient, err := ingredients.Parse(line)
+		if err != nil {
+			continue
+		}
+		recipe.Ingredients[i] = ingredient.Convert(system)
+	}
+	for i := range recipe.Instructions {
+		recipe.Instructions[i].Text = units.ConvertTemperatures(recipe.Instructions[i].Text, system)
+	}
 }
 
-// UpdateRecipes updates a recipe
+// UpdateRecipes updates a recipe, keeping its previous content as a revision.
+// The If-Match header has to carry the current entity tag of the recipe.
 func (handler *RecipesHandler) UpdateRecipes(c *gin.Context) {
 	id := c.Param("id")
 	var recipe models.Recipe
-	if err := c.ShouldBindJSON(&recipe); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := negotiate.Bind(c, &recipe); err != nil {
+		c.Error(apierrors.InvalidInput(err))
 		return
 	}
-	objectId, _ := primitive.ObjectIDFromHex(id)
-	_, err := handler.collection.UpdateOne(handler.ctx, bson.M{
-		"_id": objectId,
-	},
-		bson.D{{"$set", bson.D{
-			{"name", recipe.Name},
-			{"instructions", recipe.Instructions},
-			{"ingredients", recipe.Ingredients},
-			{"tags", recipe.Tags},
-		}}})
+	if err := validation.Struct(&recipe); err != nil {
+		c.Error(apierrors.InvalidInput(err))
+		return
+	}
+	current, err := handler.findRecipeByID(id, currentUser(c))
 	if err != nil {
-		fmt.Println(err)
-		c.JSON(http.StatusInternalServerError,
-			gin.H{"error": err.Error()})
+		c.Error(recipeError(err))
 		return
 	}
-
-	handler.redisClient.Del("recipes")
-	log.Println("Removed redis recipes!")
+	if err := handler.authorize(current, currentUser(c)); err != nil {
+		c.Error(err)
+		return
+	}
+	if !ifMatch(c, current) {
+		return
+	}
+	updated, err := handler.replaceRecipe(current, recipe, currentUser(c))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Header("ETag", updated.ETag)
 	c.JSON(http.StatusOK, gin.H{"message": "Recipe	has been updated"})
 }
 
-// DeleteRecipes deletes a recipe
+// DeleteRecipes moves a recipe to the trash, from where it can be restored
+// until it is purged. The If-Match header has to carry the current entity tag
+// of the recipe.
 func (handler *RecipesHandler) DeleteRecipes(c *gin.Context) {
-	id := c.Param("id")
-	objectId, _ := primitive.ObjectIDFromHex(id)
-	_, err := handler.collection.DeleteOne(handler.ctx, bson.M{
-		"_id": objectId,
-	})
+	recipe, err := handler.findRecipeByID(c.Param("id"), currentUser(c))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(recipeError(err))
+		return
+	}
+	if err := handler.authorize(recipe, currentUser(c)); err != nil {
+		c.Error(err)
+		return
+	}
+	if !ifMatch(c, recipe) {
+		return
+	}
+	if err := handler.trashRecipe(recipe, currentUser(c)); err != nil {
+		c.Error(err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "Recipe has been deleted"})
-
 }
 
-// SearchRecipeById return 1 recipe by mongo _id
+// SearchRecipeById return 1 recipe by mongo _id, or 304 when the If-None-Match
+// header carries its current entity tag
 func (handler *RecipesHandler) SearchRecipeById(c *gin.Context) {
-	id := c.Param("id")
-	objectId, err := primitive.ObjectIDFromHex(id)
+	system, err := units.ParseSystem(c.Query("units"))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(apierrors.InvalidInput(err))
 		return
 	}
-	singleResulst := handler.collection.FindOne(handler.ctx, bson.M{
-		"_id": objectId,
-	})
-	returnRecipe := models.Recipe{}
-	err = singleResulst.Decode(&returnRecipe)
+	returnRecipe, err := handler.findRecipeByID(c.Param("id"), currentUser(c))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(recipeError(err))
 		return
 	}
 
+	returnRecipe.ETag = etag.Of(returnRecipe)
+	if notModified(c, returnRecipe.ETag) {
+		return
+	}
+	convertRecipeUnits(&returnRecipe, system)
 	c.JSON(http.StatusOK, returnRecipe)
 }
 
-// SearchRecipes seaches recipes by the tags
-func (handler *RecipesHandler) SearchRecipes(c *gin.Context) {
+// findRecipeByID loads a single recipe by its hex mongo _id. Recipes in the
+// trash, and the unpublished recipes of other users than user, aren't found.
+func (handler *RecipesHandler) findRecipeByID(id, user string) (models.Recipe, error) {
+	var recipe models.Recipe
+	objectId, err := parseID(id)
+	if err != nil {
+		return recipe, err
+	}
+	err = handler.collection.FindOne(handler.ctx, visibleTo(bson.M{
+		"_id": objectId,
+	}, user)).Decode(&recipe)
+	return recipe, err
+}
 
-	recipes := make([]models.Recipe, 0)
+// notDeleted restricts a filter on recipes to the ones not in the trash
+func notDeleted(filter bson.M) bson.M {
+	filter["deletedAt"] = bson.M{"$exists": false}
+	return filter
+}
 
-	redisVal, err := handler.redisClient.Get("recipes").Result()
-	if err == redis.Nil {
-		log.Println("Redis nil, Need to query data from mongo!")
-		// Query mongo
-		cursor, err := handler.collection.Find(handler.ctx, bson.M{})
-		if err != nil {
-			c.JSON(http.StatusInternalServerError,
-				gin.H{"error": err.Error()})
-			return
+// visibleTo restricts a filter on recipes to the ones user can see: the
+// published ones and their own, not in the trash
+func visibleTo(filter bson.M, user string) bson.M {
+	visible := bson.A{bson.M{"status": bson.M{"$in": bson.A{nil, workflow.Published}}}}
+	if user != "" {
+		visible = append(visible, bson.M{"author": user})
+	}
+	filter["$or"] = visible
+	return notDeleted(filter)
+}
+
+// visibleRecipes keeps the recipes user can see having one of statuses
+func visibleRecipes(recipes []models.Recipe, user string, statuses []string) []models.Recipe {
+	result := make([]models.Recipe, 0, len(recipes))
+	for _, recipe := range recipes {
+		if workflow.Visible(recipe, user) && contains(statuses, workflow.Of(recipe)) {
+			result = append(result, recipe)
 		}
-		defer cursor.Close(handler.ctx)
+	}
+	return result
+}
 
-		for cursor.Next(handler.ctx) {
-			var recipe models.Recipe
-			cursor.Decode(&recipe)
-			recipes = append(recipes, recipe)
+// statusQuery returns the statuses of ?status=, published by default
+func statusQuery(c *gin.Context) ([]string, error) {
+	statuses := queryList(c, "status")
+	if len(statuses) == 0 {
+		return []string{workflow.Published}, nil
+	}
+	for _, status := range statuses {
+		if !workflow.IsStatus(status) {
+			return nil, workflow.ErrUnknownStatus
 		}
-		// Redis value has to be a string -> need encode
-		data, _ := json.Marshal(recipes)
+	}
+	return statuses, nil
+}
 
-		handler.redisClient.Set("recipes", string(data), noExpirationTimeRedis)
-	} else if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error(),
-		})
-	} else {
-		log.Println("Redis has data, starting query to Redis!")
-		json.Unmarshal([]byte(redisVal), &recipes)
+// errNotAuthor is the error of a change of a recipe by another user than its
+// author who isn't an admin
+var errNotAuthor = apierrors.Forbidden("Only the author or an admin can change a recipe")
+
+// canChange reports whether user, an admin or not, can change recipe: its
+// author or an admin can. Recipes without an author predate authors, only
+// admins change them.
+func canChange(recipe models.Recipe, user string, admin bool) bool {
+	return admin || (user != "" && recipe.Author == user)
+}
+
+// authorize returns errNotAuthor unless user can change recipe, looking up
+// whether they are an admin when they didn't write it
+func (handler *RecipesHandler) authorize(recipe models.Recipe, user string) error {
+	if canChange(recipe, user, false) {
+		return nil
+	}
+	admin, err := handler.isAdmin(user)
+	if err != nil {
+		return err
+	}
+	if !admin {
+		return errNotAuthor
+	}
+	return nil
+}
+
+// isAdmin reports whether user has the admin role
+func (handler *RecipesHandler) isAdmin(user string) (bool, error) {
+	if user == "" {
+		return false, nil
+	}
+	var account models.User
+	err := handler.users.FindOne(handler.ctx, bson.M{"username": user},
+		options.FindOne().SetProjection(bson.M{"role": 1})).Decode(&account)
+	if err == mongo.ErrNoDocuments {
+		return false, nil
 	}
+	return account.Role == models.RoleAdmin, err
+}
+
+// parseID parses a hex object id. Any malformed id, whether of the wrong
+// length or not hex, is an invalid_id error.
+func parseID(id string) (primitive.ObjectID, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return objectID, apierrors.InvalidInput(primitive.ErrInvalidHex)
+	}
+	return objectID, nil
+}
 
-	tag := c.Query("tag")
-	if len(tag) == 0 {
-		c.JSON(http.StatusOK, recipes)
+// recipeObjectID parses a hex recipe id and checks that the recipe exists and
+// is visible to user, returning mongo.ErrNoDocuments when it doesn't
+func recipeObjectID(ctx context.Context, collection *mongo.Collection, id, user string) (primitive.ObjectID, error) {
+	objectId, err := parseID(id)
+	if err != nil {
+		return objectId, err
+	}
+	count, err := collection.CountDocuments(ctx, visibleTo(bson.M{"_id": objectId}, user))
+	if err == nil && count == 0 {
+		err = mongo.ErrNoDocuments
+	}
+	return objectId, err
+}
+
+// recipeError maps an error from findRecipeByID or recipeObjectID to an api error
+func recipeError(err error) error {
+	return notFound(err, "Recipe not found")
+}
+
+// notFound reports a missing document as not found with detail, other
+// errors, such as the invalid ids of parseID, are returned unchanged
+func notFound(err error, detail string) error {
+	if err == mongo.ErrNoDocuments {
+		return apierrors.NotFound(detail)
+	}
+	return err
+}
+
+// ScaleRecipe returns a recipe with every parsed ingredient quantity scaled to
+// the servings query parameter. Ingredients without a readable quantity are
+// returned unchanged and listed in unscaledIngredients.
+func (handler *RecipesHandler) ScaleRecipe(c *gin.Context) {
+	servings, err := strconv.Atoi(c.Query("servings"))
+	if err != nil || servings <= 0 {
+		c.Error(apierrors.BadRequest("servings must be a positive integer"))
+		return
+	}
+	recipe, err := handler.findRecipeByID(c.Param("id"), currentUser(c))
+	if err != nil {
+		c.Error(recipeError(err))
+		return
+	}
+	if recipe.Servings <= 0 {
+		c.Error(apierrors.Unprocessable("Recipe has no servings count"))
 		return
 	}
 
-	listOfRecipes := make([]models.Recipe, 0)
-	for i := 0; i < len(recipes); i++ {
-		found := false
-		for _, t := range recipes[i].Tags {
-			if strings.EqualFold(t, tag) {
-				found = true
-			}
+	factor := float64(servings) / float64(recipe.Servings)
+	scaled := models.ScaledRecipe{
+		Recipe:              recipe,
+		ScaleFactor:         factor,
+		UnscaledIngredients: make([]string, 0),
+	}
+	scaled.Servings = servings
+	scaled.Ingredients = make([]string, 0, len(recipe.Ingredients))
+	for _, line := range recipe.Ingredients {
+		if ingredients.IsSeparator(line) {
+			scaled.Ingredients = append(scaled.Ingredients, strings.TrimSpace(line))
+			continue
 		}
-		if found {
-			listOfRecipes = append(listOfRecipes, recipes[i])
+		ingredient, err := ingredients.Parse(line)
+		if err != nil {
+			scaled.Ingredients = append(scaled.Ingredients, ingredient.Raw)
+			scaled.UnscaledIngredients = append(scaled.UnscaledIngredients, ingredient.Raw)
+			continue
 		}
+		scaled.Ingredients = append(scaled.Ingredients, ingredient.Scale(factor))
 	}
+	scaled.Nutrition = nutrition.Calculate(scaled.Ingredients, servings)
+
+	c.JSON(http.StatusOK, scaled)
+}
 
+// SearchRecipes seaches recipes by the tags. Recipes can also be filtered by
+// derived diet labels (?diet=vegan) and allergens (?exclude_allergen=peanut),
+// both of which may be repeated or comma separated.
+func (handler *RecipesHandler) SearchRecipes(c *gin.Context) {
+	system, err := units.ParseSystem(c.Query("units"))
+	if err != nil {
+		c.Error(apierrors.InvalidInput(err))
+		return
+	}
+	listOfRecipes, err := handler.FilterRecipes(currentUser(c), RecipeFilter{
+		Tag:              c.Query("tag"),
+		Statuses:         queryList(c, "status"),
+		Diets:            queryList(c, "diet"),
+		ExcludeAllergens: queryList(c, "exclude_allergen"),
+	})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	for i := range listOfRecipes {
+		convertRecipeUnits(&listOfRecipes[i], system)
+	}
+
+	if notModified(c, withETags(listOfRecipes)) {
+		return
+	}
 	c.JSON(http.StatusOK, listOfRecipes)
 }
+
+// matchesDiet reports whether recipe has every label of diets and none of
+// allergens. Recipes stored before classification existed are classified here.
+func matchesDiet(recipe *models.Recipe, diets, allergens []string) bool {
+	if len(diets) == 0 && len(allergens) == 0 {
+		return true
+	}
+	if recipe.DietLabels == nil {
+		classification := diet.Classify(recipe.Ingredients)
+		recipe.DietLabels = classification.Labels
+		recipe.Allergens = classification.Allergens
+	}
+	for _, label := range diets {
+		if !contains(recipe.DietLabels, label) {
+			return false
+		}
+	}
+	for _, allergen := range allergens {
+		if contains(recipe.Allergens, allergen) {
+			return false
+		}
+	}
+	return true
+}
+
+// queryList returns the values of a query parameter given either repeated
+// (?diet=vegan&diet=nut-free) or comma separated (?diet=vegan,nut-free)
+func queryList(c *gin.Context, key string) []string {
+	values := make([]string, 0)
+	for _, value := range c.QueryArray(key) {
+		for _, v := range strings.Split(value, ",") {
+			if v = strings.ToLower(strings.TrimSpace(v)); v != "" {
+				values = append(values, v)
+			}
+		}
+	}
+	return values
+}
+
+func contains(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
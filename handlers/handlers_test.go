@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/TranQuocToan1996/ginProject/apierrors"
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-contrib/sessions/cookie"
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+// testUser is the user the requests of the handler tests are signed in as
+const testUser = "admin"
+
+// newTestHandler returns a recipes handler storing recipes, revisions and
+// users in the mocked collection of mt. Its redis client has no server, clearing the
+// cache fails as the handlers tolerate.
+func newTestHandler(mt *mtest.T) *RecipesHandler {
+	redisClient := redis.NewClient(&redis.Options{Addr: "127.0.0.1:0", DialTimeout: time.Millisecond})
+	return NewRecipesHandler(context.Background(), mt.Coll, mt.Coll, mt.Coll, redisClient, time.Hour)
+}
+
+// serve runs a request through handler routed at route behind the sessions
+// and error middleware of the API, signed in as testUser
+func serve(method, route, target string, body io.Reader, header http.Header, handler gin.HandlerFunc) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(apierrors.Middleware(), sessions.Sessions("recipes_api", cookie.NewStore([]byte("secret"))))
+	router.Use(func(c *gin.Context) {
+		sessions.Default(c).Set("username", testUser)
+	})
+	router.Handle(method, route, handler)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(method, target, body)
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// namespace returns the namespace of the mocked collection of mt, which
+// cursor responses name
+func namespace(mt *mtest.T) string {
+	return mt.Coll.Database().Name() + "." + mt.Coll.Name()
+}
+
+// recipeDoc is the stored document of the recipe of id at version, with
+// fields
+func recipeDoc(id primitive.ObjectID, version int, fields ...bson.E) bson.D {
+	return append(bson.D{{Key: "_id", Value: id}, {Key: "version", Value: version}}, fields...)
+}
+
+// found is the response of mongo to a find of the mocked collection of mt
+// returning docs
+func found(mt *mtest.T, docs ...bson.D) bson.D {
+	return mtest.CreateCursorResponse(0, namespace(mt), mtest.FirstBatch, docs...)
+}
+
+// updated is the response of mongo to an update matching n documents
+func updated(n int) bson.D {
+	return mtest.CreateSuccessResponse(bson.E{Key: "n", Value: n}, bson.E{Key: "nModified", Value: n})
+}
+
+// commands returns the commands named name mt sent, in order
+func commands(mt *mtest.T, name string) []bson.Raw {
+	sent := make([]bson.Raw, 0)
+	for _, event := range mt.GetAllStartedEvents() {
+		if event.CommandName == name {
+			sent = append(sent, event.Command)
+		}
+	}
+	return sent
+}
+
+func TestParseID(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+	for i, tt := range []struct {
+		route   string
+		target  string
+		handler func(*RecipesHandler) gin.HandlerFunc
+	}{
+		{"/recipes/search/:id", "/recipes/search/abc", func(h *RecipesHandler) gin.HandlerFunc { return h.SearchRecipeById }},
+		{"/recipes/search/:id", "/recipes/search/" + strings.Repeat("z", 24), func(h *RecipesHandler) gin.HandlerFunc { return h.SearchRecipeById }},
+		{"/recipes/:id/revisions/:rev", "/recipes/" + strings.Repeat("z", 24) + "/revisions/1", func(h *RecipesHandler) gin.HandlerFunc { return h.GetRevision }},
+	} {
+		mt.Run(fmt.Sprintf("%v", i), func(mt *mtest.T) {
+			w := serve(http.MethodGet, tt.route, tt.target, nil, nil, tt.handler(newTestHandler(mt)))
+			if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), `"code":"invalid_id"`) {
+				mt.Errorf("want %v invalid_id; got %v %s", http.StatusBadRequest, w.Code, w.Body)
+			}
+			if strings.Contains(w.Body.String(), "encoding/hex") {
+				mt.Errorf("want no Go error in the problem; got %s", w.Body)
+			}
+		})
+	}
+	if _, err := parseID(primitive.NewObjectID().Hex()); err != nil {
+		t.Errorf("want a valid id parsed; got %v", err)
+	}
+}
+
+// visibleAuthors returns the authors whose unpublished recipes the recipes
+// filter of a find command lets through, none when it only finds published
+// recipes and nil when it doesn't filter on visibility
+func visibleAuthors(find bson.Raw) []string {
+	or, ok := find.Lookup("filter", "$or").ArrayOK()
+	if !ok {
+		return nil
+	}
+	authors := make([]string, 0)
+	clauses, _ := or.Values()
+	for _, clause := range clauses {
+		if author, ok := clause.Document().Lookup("author").StringValueOK(); ok {
+			authors = append(authors, author)
+		}
+	}
+	return authors
+}
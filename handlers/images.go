@@ -0,0 +1,255 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/TranQuocToan1996/ginProject/apierrors"
+	"github.com/TranQuocToan1996/ginProject/blobstore"
+	"github.com/TranQuocToan1996/ginProject/models"
+	"github.com/TranQuocToan1996/ginProject/negotiate"
+	"github.com/TranQuocToan1996/ginProject/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis"
+	"github.com/rs/xid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	maxImageSize = 5 << 20
+	// Larger images are refused before being decoded to keep memory bounded
+	maxImagePixels = 40_000_000
+)
+
+var allowedImageTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// imageVariants are the sizes generated for every uploaded image, each one
+// fitting in a square of the given side
+var imageVariants = map[string]int{
+	"thumbnail": 150,
+	"medium":    600,
+	"large":     1200,
+}
+
+type ImagesHandler struct {
+	collection  *mongo.Collection
+	ctx         context.Context
+	redisClient *redis.Client
+	store       blobstore.BlobStore
+}
+
+func NewImagesHandler(ctx context.Context, collection *mongo.Collection, redisClient *redis.Client, store blobstore.BlobStore) *ImagesHandler {
+	return &ImagesHandler{
+		collection:  collection,
+		ctx:         ctx,
+		redisClient: redisClient,
+		store:       store,
+	}
+}
+
+// Base64Image is the JSON body accepted by UploadImage, data may be a data URI
+type Base64Image struct {
+	Data string `json:"data" binding:"required"`
+}
+
+// UploadImage adds an image to a recipe. The image is sent either as the
+// "image" field of a multipart form or base64 encoded in a JSON body. It is
+// stored in thumbnail, medium and large sizes.
+func (handler *ImagesHandler) UploadImage(c *gin.Context) {
+	objectId, err := parseID(c.Param("id"))
+	if err != nil {
+		c.Error(apierrors.InvalidInput(err))
+		return
+	}
+	count, err := handler.collection.CountDocuments(handler.ctx, visibleTo(bson.M{"_id": objectId}, currentUser(c)))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if count == 0 {
+		c.Error(apierrors.NotFound("Recipe not found"))
+		return
+	}
+
+	data, err := readImage(c)
+	if err != nil {
+		c.Error(apierrors.InvalidInput(err))
+		return
+	}
+	contentType := http.DetectContentType(data)
+	if !allowedImageTypes[contentType] {
+		c.Error(apierrors.UnsupportedMedia("Unsupported image type " + contentType))
+		return
+	}
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		c.Error(apierrors.InvalidInput(err))
+		return
+	}
+	if config.Width*config.Height > maxImagePixels {
+		c.Error(apierrors.PayloadTooLarge("Image dimensions are too large"))
+		return
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		c.Error(apierrors.InvalidInput(err))
+		return
+	}
+
+	recipeImage := models.RecipeImage{
+		ID:         xid.New().String(),
+		Variants:   make(map[string]models.ImageVariant),
+		UploadedAt: time.Now(),
+	}
+	keys := make([]string, 0, len(imageVariants))
+	for name, side := range imageVariants {
+		resized := utils.Resize(img, side, side)
+		encoded, outputType, ext, err := encodeImage(resized, contentType)
+		if err != nil {
+			handler.deleteBlobs(keys)
+			c.Error(err)
+			return
+		}
+		key := fmt.Sprintf("recipes/%v/%v/%v.%v", objectId.Hex(), recipeImage.ID, name, ext)
+		if err := handler.store.Put(handler.ctx, key, outputType, encoded); err != nil {
+			handler.deleteBlobs(keys)
+			c.Error(err)
+			return
+		}
+		keys = append(keys, key)
+		recipeImage.Variants[name] = models.ImageVariant{
+			URL:    "/images/" + key,
+			Width:  resized.Bounds().Dx(),
+			Height: resized.Bounds().Dy(),
+		}
+	}
+
+	// The recipe may have been deleted since it was counted, its variants
+	// would then be stored for nothing
+	result, err := handler.collection.UpdateOne(handler.ctx, notDeleted(bson.M{
+		"_id": objectId,
+	}), bson.M{"$push": bson.M{"images": recipeImage}, "$inc": nextVersion})
+	if err != nil {
+		handler.deleteBlobs(keys)
+		c.Error(err)
+		return
+	}
+	if result.MatchedCount == 0 {
+		handler.deleteBlobs(keys)
+		c.Error(apierrors.NotFound("Recipe not found"))
+		return
+	}
+
+	handler.redisClient.Del("recipes")
+	log.Println("Removed redis recipes!")
+	c.JSON(http.StatusCreated, recipeImage)
+}
+
+// ServeImage writes a stored image variant
+func (handler *ImagesHandler) ServeImage(c *gin.Context) {
+	reader, contentType, err := handler.store.Open(handler.ctx, strings.TrimPrefix(c.Param("key"), "/"))
+	if err == blobstore.ErrNotFound {
+		c.Error(apierrors.NotFound("Image not found"))
+		return
+	}
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	defer reader.Close()
+
+	c.Header("Cache-Control", "public, max-age=31536000, immutable")
+	c.DataFromReader(http.StatusOK, -1, contentType, reader, nil)
+}
+
+// PurgeRecipes deletes the stored variants of the images of recipes purged
+// from the trash
+func (handler *ImagesHandler) PurgeRecipes(recipes []models.Recipe) error {
+	for _, recipe := range recipes {
+		for _, recipeImage := range recipe.Images {
+			for _, variant := range recipeImage.Variants {
+				err := handler.store.Delete(handler.ctx, strings.TrimPrefix(variant.URL, "/images/"))
+				if err != nil && err != blobstore.ErrNotFound {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// deleteBlobs deletes the stored variants of an upload that failed
+func (handler *ImagesHandler) deleteBlobs(keys []string) {
+	for _, key := range keys {
+		if err := handler.store.Delete(handler.ctx, key); err != nil && err != blobstore.ErrNotFound {
+			log.Println(err)
+		}
+	}
+}
+
+// readImage reads the uploaded image from a multipart form or a base64 JSON body
+func readImage(c *gin.Context) ([]byte, error) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImageSize*2)
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		header, err := c.FormFile("image")
+		if err != nil {
+			return nil, err
+		}
+		if header.Size > maxImageSize {
+			return nil, fmt.Errorf("image is larger than %v bytes", maxImageSize)
+		}
+		file, err := header.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		return io.ReadAll(file)
+	}
+
+	var body Base64Image
+	if err := negotiate.Bind(c, &body); err != nil {
+		return nil, err
+	}
+	encoded := body.Data
+	if strings.HasPrefix(encoded, "data:") {
+		if i := strings.Index(encoded, ","); i >= 0 {
+			encoded = encoded[i+1:]
+		}
+	}
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxImageSize {
+		return nil, fmt.Errorf("image is larger than %v bytes", maxImageSize)
+	}
+	return data, nil
+}
+
+// encodeImage encodes a variant as PNG when the upload may carry transparency
+// and as JPEG otherwise
+func encodeImage(img image.Image, contentType string) ([]byte, string, string, error) {
+	var buf bytes.Buffer
+	if contentType == "image/png" || contentType == "image/gif" {
+		err := png.Encode(&buf, img)
+		return buf.Bytes(), "image/png", "png", err
+	}
+	err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 85})
+	return buf.Bytes(), "image/jpeg", "jpg", err
+}
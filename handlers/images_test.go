@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/png"
+	"io/fs"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/TranQuocToan1996/ginProject/blobstore"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestUploadImageCleanup(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 20, 10))); err != nil {
+		t.Fatal(err)
+	}
+	body, _ := json.Marshal(Base64Image{Data: base64.StdEncoding.EncodeToString(buf.Bytes())})
+	for i, tt := range []struct {
+		update func(mt *mtest.T) bson.D
+		status int
+		blobs  int
+	}{
+		{func(*mtest.T) bson.D { return updated(1) }, http.StatusCreated, len(imageVariants)},
+		// The recipe was deleted after it was counted
+		{func(*mtest.T) bson.D { return updated(0) }, http.StatusNotFound, 0},
+		{func(*mtest.T) bson.D {
+			return mtest.CreateCommandErrorResponse(mtest.CommandError{Code: 1, Message: "write failed"})
+		}, http.StatusInternalServerError, 0},
+	} {
+		mt.Run(fmt.Sprintf("%v", i), func(mt *mtest.T) {
+			dir := mt.TempDir()
+			store, err := blobstore.NewLocalStore(dir)
+			if err != nil {
+				mt.Fatal(err)
+			}
+			recipes := newTestHandler(mt)
+			handler := NewImagesHandler(context.Background(), mt.Coll, recipes.redisClient, store)
+			id := primitive.NewObjectID()
+			mt.AddMockResponses(found(mt, bson.D{{Key: "n", Value: 1}}), tt.update(mt))
+
+			w := serve(http.MethodPost, "/recipes/:id/images", "/recipes/"+id.Hex()+"/images", bytes.NewReader(body),
+				http.Header{"Content-Type": {"application/json"}}, handler.UploadImage)
+			if w.Code != tt.status {
+				mt.Fatalf("want %v; got %v %v", tt.status, w.Code, w.Body)
+			}
+			blobs := 0
+			err = filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+				if err == nil && !entry.IsDir() {
+					blobs++
+				}
+				return err
+			})
+			if err != nil {
+				mt.Fatal(err)
+			}
+			if blobs != tt.blobs {
+				mt.Errorf("want %v stored blobs; got %v", tt.blobs, blobs)
+			}
+		})
+	}
+}
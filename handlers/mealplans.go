@@ -0,0 +1,277 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/TranQuocToan1996/ginProject/apierrors"
+	"github.com/TranQuocToan1996/ginProject/mealplan"
+	"github.com/TranQuocToan1996/ginProject/models"
+	"github.com/TranQuocToan1996/ginProject/negotiate"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type MealPlansHandler struct {
+	collection *mongo.Collection
+	recipes    *mongo.Collection
+	ctx        context.Context
+}
+
+func NewMealPlansHandler(ctx context.Context, collection *mongo.Collection, recipes *mongo.Collection) *MealPlansHandler {
+	return &MealPlansHandler{
+		collection: collection,
+		recipes:    recipes,
+		ctx:        ctx,
+	}
+}
+
+// EnsureIndexes creates the unique index allowing one plan per user per week
+func (handler *MealPlansHandler) EnsureIndexes() error {
+	_, err := handler.collection.Indexes().CreateOne(handler.ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "owner", Value: 1}, {Key: "weekStart", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return err
+}
+
+// MealEntryRequest is the body to assign a recipe to a meal slot. Servings
+// defaults to the servings of the recipe.
+type MealEntryRequest struct {
+	RecipeID string `json:"recipeId" binding:"required"`
+	Servings int    `json:"servings" binding:"min=0,max=100"`
+}
+
+// ServingsRequest is the body to adjust the servings of a meal slot
+type ServingsRequest struct {
+	Servings int `json:"servings" binding:"required,min=1,max=100"`
+}
+
+// CopyWeekRequest is the body to copy a week, From defaults to the previous week
+type CopyWeekRequest struct {
+	From string `json:"from"`
+}
+
+// GetMealPlan returns the plan of the signed in user for a week, empty when
+// nothing is planned yet. The week is any date in it or an ISO week such as 2026-W43.
+func (handler *MealPlansHandler) GetMealPlan(c *gin.Context) {
+	plan, err := handler.loadPlan(c)
+	if err != nil {
+		c.Error(mealPlanError(err))
+		return
+	}
+	c.JSON(http.StatusOK, plan)
+}
+
+// SetMeal assigns a recipe to a day and slot of a week, replacing the recipe
+// planned there
+func (handler *MealPlansHandler) SetMeal(c *gin.Context) {
+	var request MealEntryRequest
+	if err := negotiate.Bind(c, &request); err != nil {
+		c.Error(apierrors.InvalidInput(err))
+		return
+	}
+	day, slot, ok := daySlot(c)
+	if !ok {
+		return
+	}
+	objectId, err := parseID(request.RecipeID)
+	if err != nil {
+		c.Error(apierrors.InvalidInput(err))
+		return
+	}
+	var recipe models.Recipe
+	err = handler.recipes.FindOne(handler.ctx, visibleTo(bson.M{"_id": objectId}, currentUser(c)),
+		options.FindOne().SetProjection(bson.M{"name": 1, "servings": 1})).Decode(&recipe)
+	if err != nil {
+		c.Error(recipeError(err))
+		return
+	}
+	plan, err := handler.loadPlan(c)
+	if err != nil {
+		c.Error(mealPlanError(err))
+		return
+	}
+
+	servings := request.Servings
+	if servings == 0 {
+		servings = recipe.Servings
+	}
+	if servings == 0 {
+		servings = 1
+	}
+	plan.Entries = mealplan.Set(plan.Entries, models.MealEntry{
+		Day:        day,
+		Slot:       slot,
+		RecipeID:   recipe.ID,
+		RecipeName: recipe.Name,
+		Servings:   servings,
+	})
+	handler.savePlan(c, plan)
+}
+
+// SetMealServings adjusts the servings of a planned meal
+func (handler *MealPlansHandler) SetMealServings(c *gin.Context) {
+	var request ServingsRequest
+	if err := negotiate.Bind(c, &request); err != nil {
+		c.Error(apierrors.InvalidInput(err))
+		return
+	}
+	day, slot, ok := daySlot(c)
+	if !ok {
+		return
+	}
+	plan, err := handler.loadPlan(c)
+	if err != nil {
+		c.Error(mealPlanError(err))
+		return
+	}
+	for i, entry := range plan.Entries {
+		if entry.Day == day && entry.Slot == slot {
+			plan.Entries[i].Servings = request.Servings
+			handler.savePlan(c, plan)
+			return
+		}
+	}
+	c.Error(apierrors.NotFound("No meal is planned in this slot"))
+}
+
+// RemoveMeal clears a day and slot of a week
+func (handler *MealPlansHandler) RemoveMeal(c *gin.Context) {
+	day, slot, ok := daySlot(c)
+	if !ok {
+		return
+	}
+	plan, err := handler.loadPlan(c)
+	if err != nil {
+		c.Error(mealPlanError(err))
+		return
+	}
+	entries := mealplan.Remove(plan.Entries, day, slot)
+	if len(entries) == len(plan.Entries) {
+		c.Error(apierrors.NotFound("No meal is planned in this slot"))
+		return
+	}
+	plan.Entries = entries
+	handler.savePlan(c, plan)
+}
+
+// CopyWeek replaces the plan of a week with a copy of the plan of another week
+func (handler *MealPlansHandler) CopyWeek(c *gin.Context) {
+	var request CopyWeekRequest
+	if err := negotiate.Bind(c, &request); err != nil && c.Request.ContentLength > 0 {
+		c.Error(apierrors.InvalidInput(err))
+		return
+	}
+	plan, err := handler.loadPlan(c)
+	if err != nil {
+		c.Error(mealPlanError(err))
+		return
+	}
+	var from time.Time
+	if request.From == "" {
+		week, _ := time.Parse(mealplan.DateFormat, plan.WeekStart)
+		from = week.AddDate(0, 0, -7)
+	} else if from, err = mealplan.ParseWeek(request.From); err != nil {
+		c.Error(apierrors.InvalidInput(err))
+		return
+	}
+
+	source, err := handler.findPlan(currentUser(c), from.Format(mealplan.DateFormat))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if len(source.Entries) == 0 {
+		c.Error(apierrors.NotFound("Nothing is planned in week " + source.WeekStart))
+		return
+	}
+	plan.Entries = source.Entries
+	handler.savePlan(c, plan)
+}
+
+// MealPlanCalendar returns the plan of a week as an iCalendar feed
+func (handler *MealPlansHandler) MealPlanCalendar(c *gin.Context) {
+	plan, err := handler.loadPlan(c)
+	if err != nil {
+		c.Error(mealPlanError(err))
+		return
+	}
+	cal, err := mealplan.Calendar(plan)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Header("Content-Disposition", `attachment; filename="mealplan-`+plan.WeekStart+`.ics"`)
+	c.Header("Content-Type", "text/calendar; charset=utf-8")
+	c.Status(http.StatusOK)
+	cal.WriteTo(c.Writer)
+}
+
+// loadPlan returns the plan of the signed in user for the week parameter
+func (handler *MealPlansHandler) loadPlan(c *gin.Context) (models.MealPlan, error) {
+	week, err := mealplan.ParseWeek(c.Param("week"))
+	if err != nil {
+		return models.MealPlan{}, err
+	}
+	return handler.findPlan(currentUser(c), week.Format(mealplan.DateFormat))
+}
+
+// findPlan returns the plan of owner for the week starting on weekStart, or a
+// new empty plan
+func (handler *MealPlansHandler) findPlan(owner, weekStart string) (models.MealPlan, error) {
+	var plan models.MealPlan
+	err := handler.collection.FindOne(handler.ctx, bson.M{
+		"owner":     owner,
+		"weekStart": weekStart,
+	}).Decode(&plan)
+	if err == mongo.ErrNoDocuments {
+		return models.MealPlan{
+			ID:        primitive.NewObjectID(),
+			Owner:     owner,
+			WeekStart: weekStart,
+			Entries:   make([]models.MealEntry, 0),
+		}, nil
+	}
+	return plan, err
+}
+
+// savePlan writes plan, creating it when it's new, and responds with it
+func (handler *MealPlansHandler) savePlan(c *gin.Context, plan models.MealPlan) {
+	plan.UpdatedAt = time.Now()
+	_, err := handler.collection.ReplaceOne(handler.ctx, bson.M{
+		"owner":     plan.Owner,
+		"weekStart": plan.WeekStart,
+	}, plan, options.Replace().SetUpsert(true))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, plan)
+}
+
+// daySlot reads the day and slot parameters, responding with an error when
+// they aren't valid
+func daySlot(c *gin.Context) (string, string, bool) {
+	day, slot := c.Param("day"), c.Param("slot")
+	if mealplan.DayIndex(day) < 0 {
+		c.Error(apierrors.BadRequest("day must be a day of the week such as monday"))
+		return "", "", false
+	}
+	if mealplan.SlotIndex(slot) < 0 {
+		c.Error(apierrors.BadRequest("slot must be breakfast, lunch, snack or dinner"))
+		return "", "", false
+	}
+	return day, slot, true
+}
+
+func mealPlanError(err error) error {
+	if err == mealplan.ErrInvalidWeek {
+		return apierrors.BadRequest(err.Error())
+	}
+	return err
+}
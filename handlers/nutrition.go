@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/TranQuocToan1996/ginProject/nutrition"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// GetNutrition returns the nutrition facts of a recipe, per recipe and per
+// serving. Recipes stored before nutrition was computed get it computed and
+// saved on their first request, without a new version as the recipe itself
+// didn't change.
+func (handler *RecipesHandler) GetNutrition(c *gin.Context) {
+	recipe, err := handler.findRecipeByID(c.Param("id"), currentUser(c))
+	if err != nil {
+		c.Error(recipeError(err))
+		return
+	}
+	if recipe.Nutrition == nil {
+		recipe.Nutrition = nutrition.Calculate(recipe.Ingredients, recipe.Servings)
+		_, err := handler.collection.UpdateOne(handler.ctx, versioned(bson.M{
+			"_id": recipe.ID,
+		}, recipe.Version), bson.M{"$set": bson.M{"nutrition": recipe.Nutrition}})
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		handler.redisClient.Del("recipes")
+		log.Println("Removed redis recipes!")
+	}
+
+	c.JSON(http.StatusOK, recipe.Nutrition)
+}
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestGetNutritionKeepsVersion(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+	mt.Run("fill", func(mt *mtest.T) {
+		handler := newTestHandler(mt)
+		id := primitive.NewObjectID()
+		mt.AddMockResponses(
+			found(mt, recipeDoc(id, 2,
+				bson.E{Key: "status", Value: "published"},
+				bson.E{Key: "ingredients", Value: bson.A{"1 onion"}},
+				bson.E{Key: "servings", Value: 2},
+			)),
+			updated(1),
+		)
+
+		w := serve(http.MethodGet, "/recipes/:id/nutrition", "/recipes/"+id.Hex()+"/nutrition", nil, nil, handler.GetNutrition)
+		if w.Code != http.StatusOK {
+			mt.Fatalf("want %v; got %v %v", http.StatusOK, w.Code, w.Body)
+		}
+		updates := commands(mt, "update")
+		if len(updates) != 1 {
+			mt.Fatalf("want 1 update; got %v", len(updates))
+		}
+		if _, err := updates[0].LookupErr("updates", "0", "u", "$inc"); err == nil {
+			mt.Errorf("want the version kept; got %v", updates[0])
+		}
+		if version := updates[0].Lookup("updates", "0", "q", "version").Int32(); version != 2 {
+			mt.Errorf("want the fill of version 2; got %v", version)
+		}
+	})
+}
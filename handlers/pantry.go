@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/TranQuocToan1996/ginProject/apierrors"
+	"github.com/TranQuocToan1996/ginProject/models"
+	"github.com/TranQuocToan1996/ginProject/negotiate"
+	"github.com/TranQuocToan1996/ginProject/pantry"
+	"github.com/TranQuocToan1996/ginProject/workflow"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type PantryHandler struct {
+	collection *mongo.Collection
+	recipes    *RecipesHandler
+	ctx        context.Context
+}
+
+func NewPantryHandler(ctx context.Context, collection *mongo.Collection, recipes *RecipesHandler) *PantryHandler {
+	return &PantryHandler{
+		collection: collection,
+		recipes:    recipes,
+		ctx:        ctx,
+	}
+}
+
+// PantryRequest is the body to add items to a pantry
+type PantryRequest struct {
+	Items []PantryItemRequest `json:"items" binding:"required,dive"`
+}
+
+type PantryItemRequest struct {
+	Name     string `json:"name" binding:"required,max=100"`
+	Quantity string `json:"quantity" binding:"max=50"`
+}
+
+// GetPantry returns the pantry of the signed in user
+func (handler *PantryHandler) GetPantry(c *gin.Context) {
+	p, err := handler.findPantry(currentUser(c))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, p)
+}
+
+// AddPantryItems adds items to the pantry of the signed in user. An item
+// already in the pantry under the same normalized name is replaced.
+func (handler *PantryHandler) AddPantryItems(c *gin.Context) {
+	var request PantryRequest
+	if err := negotiate.Bind(c, &request); err != nil {
+		c.Error(apierrors.InvalidInput(err))
+		return
+	}
+	p, err := handler.findPantry(currentUser(c))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	now := time.Now()
+	for _, item := range request.Items {
+		p.Items = removePantryItem(p.Items, item.Name)
+		p.Items = append(p.Items, models.PantryItem{Name: item.Name, Quantity: item.Quantity, AddedAt: now})
+	}
+	handler.savePantry(c, p)
+}
+
+// RemovePantryItem removes an item from the pantry of the signed in user
+func (handler *PantryHandler) RemovePantryItem(c *gin.Context) {
+	p, err := handler.findPantry(currentUser(c))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	items := removePantryItem(p.Items, c.Param("name"))
+	if len(items) == len(p.Items) {
+		c.Error(apierrors.NotFound("Item is not in the pantry"))
+		return
+	}
+	p.Items = items
+	handler.savePantry(c, p)
+}
+
+// CookableRecipes ranks every recipe by the share of its ingredients found in
+// the pantry of the signed in user and lists what is missing. Salt, pepper
+// and water are assumed available unless ?staples=false. ?max_missing= and
+// ?limit= narrow the results.
+func (handler *PantryHandler) CookableRecipes(c *gin.Context) {
+	maxMissing, err := intQuery(c, "max_missing", -1)
+	if err != nil {
+		c.Error(apierrors.InvalidInput(err))
+		return
+	}
+	limit, err := intQuery(c, "limit", 20)
+	if err != nil {
+		c.Error(apierrors.InvalidInput(err))
+		return
+	}
+	p, err := handler.findPantry(currentUser(c))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	recipes, err := handler.recipes.loadRecipes()
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	recipes = visibleRecipes(recipes, currentUser(c), []string{workflow.Published})
+
+	have := make([]string, 0, len(p.Items))
+	for _, item := range p.Items {
+		have = append(have, pantry.Key(item.Name))
+	}
+	ranked := pantry.Rank(recipes, have, c.Query("staples") == "false")
+	result := make([]models.CookableRecipe, 0, limit)
+	for _, r := range ranked {
+		if len(result) == limit {
+			break
+		}
+		if r.Available == 0 || (maxMissing >= 0 && len(r.Missing) > maxMissing) {
+			continue
+		}
+		result = append(result, r)
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// findPantry returns the pantry of owner, empty when nothing was added yet
+func (handler *PantryHandler) findPantry(owner string) (models.Pantry, error) {
+	var p models.Pantry
+	err := handler.collection.FindOne(handler.ctx, bson.M{"_id": owner}).Decode(&p)
+	if err == mongo.ErrNoDocuments {
+		return models.Pantry{Owner: owner, Items: make([]models.PantryItem, 0)}, nil
+	}
+	return p, err
+}
+
+func (handler *PantryHandler) savePantry(c *gin.Context, p models.Pantry) {
+	p.UpdatedAt = time.Now()
+	_, err := handler.collection.ReplaceOne(handler.ctx, bson.M{"_id": p.Owner}, p,
+		options.Replace().SetUpsert(true))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, p)
+}
+
+// removePantryItem removes the items with the same normalized name as name
+func removePantryItem(items []models.PantryItem, name string) []models.PantryItem {
+	key := pantry.Key(name)
+	result := make([]models.PantryItem, 0, len(items))
+	for _, item := range items {
+		if pantry.Key(item.Name) != key {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// intQuery reads a non negative integer query parameter
+func intQuery(c *gin.Context, key string, defaultValue int) (int, error) {
+	value, ok := c.GetQuery(key)
+	if !ok {
+		return defaultValue, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("%v must be a non negative number", key)
+	}
+	return n, nil
+}
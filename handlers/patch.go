@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"mime"
+	"net/http"
+
+	"github.com/TranQuocToan1996/ginProject/apierrors"
+	"github.com/TranQuocToan1996/ginProject/etag"
+	"github.com/TranQuocToan1996/ginProject/history"
+	"github.com/TranQuocToan1996/ginProject/patch"
+	"github.com/TranQuocToan1996/ginProject/validation"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// PatchRecipe applies a JSON Merge Patch or a JSON Patch to the name, tags,
+// ingredients, instructions and servings of a recipe, setting only the fields
+// the patch changes. The If-Match header has to carry the current entity tag
+// of the recipe.
+func (handler *RecipesHandler) PatchRecipe(c *gin.Context) {
+	mediaType, _, _ := mime.ParseMediaType(c.ContentType())
+	if mediaType != patch.MergePatch && mediaType != patch.JSONPatch {
+		c.Error(apierrors.UnsupportedMedia(patch.ErrMediaType.Error()))
+		return
+	}
+	doc, err := c.GetRawData()
+	if err != nil {
+		c.Error(apierrors.InvalidInput(err))
+		return
+	}
+	recipe, err := handler.findRecipeByID(c.Param("id"), currentUser(c))
+	if err != nil {
+		c.Error(recipeError(err))
+		return
+	}
+	if err := handler.authorize(recipe, currentUser(c)); err != nil {
+		c.Error(err)
+		return
+	}
+	if !ifMatch(c, recipe) {
+		return
+	}
+
+	before := history.Snapshot(recipe)
+	after, err := patch.Apply(before, mediaType, doc)
+	if err != nil {
+		c.Error(patchError(err))
+		return
+	}
+	// Only the touched fields are validated, so that data stored before a
+	// rule existed doesn't block patches of other fields
+	touched := patch.Touched(before, after)
+	patched := recipe
+	history.Apply(&patched, after)
+	if err := validation.Partial(&patched, touched...); err != nil {
+		c.Error(apierrors.InvalidInput(err))
+		return
+	}
+	if len(touched) == 0 {
+		c.Header("ETag", etag.Of(recipe))
+		c.JSON(http.StatusOK, recipe)
+		return
+	}
+
+	history.Apply(&recipe, after)
+	// The derived fields include the normalized instructions, which replace
+	// the patched ones as mongo rejects a field set twice
+	set := bson.D{}
+	if contains(touched, "ingredients") || contains(touched, "instructions") || contains(touched, "servings") {
+		prepareRecipe(&recipe)
+		set = derivedFields(recipe)
+	}
+	for _, field := range touched {
+		if !hasKey(set, field) {
+			set = append(set, bson.E{Key: field, Value: patch.Value(after, field)})
+		}
+	}
+	result, err := handler.collection.UpdateOne(handler.ctx, versioned(bson.M{
+		"_id": recipe.ID,
+	}, recipe.Version), bson.D{
+		{Key: "$set", Value: set},
+		{Key: "$inc", Value: nextVersion},
+	})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if result.MatchedCount == 0 {
+		c.Error(apierrors.PreconditionFailed("Recipe has been modified"))
+		return
+	}
+	recipe.Version++
+	handler.redisClient.Del("recipes")
+	log.Println("Removed redis recipes!")
+
+	err = handler.recordRevision(recipe.ID, &before, history.Snapshot(recipe), currentUser(c), history.Patch, 0)
+	if err != nil {
+		c.Error(fmt.Errorf("Recipe patched but its revision was not saved: %w", err))
+		return
+	}
+	c.Header("ETag", etag.Of(recipe))
+	c.JSON(http.StatusOK, recipe)
+}
+
+// hasKey reports whether doc has an element named key
+func hasKey(doc bson.D, key string) bool {
+	for _, e := range doc {
+		if e.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// patchError maps an error from patch.Apply to an api error
+func patchError(err error) error {
+	var patchErr *patch.Error
+	switch {
+	case errors.Is(err, patch.ErrTestFailed):
+		return apierrors.Conflict(err.Error())
+	case errors.As(err, &patchErr):
+		return apierrors.Unprocessable(err.Error())
+	case err == patch.ErrMediaType:
+		return apierrors.UnsupportedMedia(err.Error())
+	default:
+		return apierrors.BadRequest(err.Error())
+	}
+}
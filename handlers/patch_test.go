@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/TranQuocToan1996/ginProject/patch"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestPatchRecipe(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+	id := primitive.NewObjectID()
+	for i, tt := range []struct {
+		doc          string
+		status       int
+		set          []string
+		instructions []string
+	}{
+		{`{"instructions": ["Chop the onions", "Boil for 5 minutes"]}`, http.StatusOK,
+			[]string{"instructions", "nutrition", "dietLabels", "allergens"}, []string{"Chop the onions", "Boil for 5 minutes"}},
+		{`{"name": "Onion soup", "instructions": ["Boil"], "servings": 2}`, http.StatusOK,
+			[]string{"instructions", "nutrition", "dietLabels", "allergens", "name", "servings"}, []string{"Boil"}},
+		// The stored tag predates the tag rule, patches of other fields pass
+		{`{"name": "Onion soup"}`, http.StatusOK, []string{"name"}, nil},
+		{`{"tags": ["jalapeño"]}`, http.StatusOK, []string{"tags"}, nil},
+		{`{"tags": ["quick&easy", "dinner"]}`, http.StatusUnprocessableEntity, nil, nil},
+	} {
+		mt.Run(fmt.Sprintf("%v", i), func(mt *mtest.T) {
+			handler := newTestHandler(mt)
+			mt.AddMockResponses(
+				found(mt, recipeDoc(id, 2,
+					bson.E{Key: "name", Value: "Soup"},
+					bson.E{Key: "author", Value: testUser},
+					bson.E{Key: "tags", Value: bson.A{"quick&easy"}},
+					bson.E{Key: "ingredients", Value: bson.A{"1 onion"}},
+					bson.E{Key: "instructions", Value: bson.A{bson.D{{Key: "step", Value: 1}, {Key: "text", Value: "Boil"}}}},
+				)),
+				updated(1),
+				found(mt),
+				mtest.CreateSuccessResponse(),
+				mtest.CreateSuccessResponse(),
+			)
+			header := http.Header{}
+			header.Set("Content-Type", patch.MergePatch)
+			header.Set("If-Match", fmt.Sprintf(`"%v-2"`, id.Hex()))
+
+			w := serve(http.MethodPatch, "/recipes/:id", "/recipes/"+id.Hex(), strings.NewReader(tt.doc), header, handler.PatchRecipe)
+			if w.Code != tt.status {
+				mt.Fatalf("want %v; got %v %v", tt.status, w.Code, w.Body)
+			}
+			updates := commands(mt, "update")
+			if tt.set == nil {
+				if len(updates) != 0 {
+					mt.Errorf("want no update; got %v", updates)
+				}
+				return
+			}
+			if len(updates) != 1 {
+				mt.Fatalf("want 1 update; got %v", len(updates))
+			}
+			elements, err := updates[0].Lookup("updates", "0", "u", "$set").Document().Elements()
+			if err != nil {
+				mt.Fatal(err)
+			}
+			set := make([]string, 0)
+			for _, e := range elements {
+				set = append(set, e.Key())
+			}
+			if fmt.Sprint(set) != fmt.Sprint(tt.set) {
+				mt.Errorf("want $set of %v; got %v", tt.set, set)
+			}
+			if tt.instructions == nil {
+				return
+			}
+			steps, _ := updates[0].Lookup("updates", "0", "u", "$set", "instructions").Array().Values()
+			stored := make([]string, 0)
+			for j, step := range steps {
+				if number := step.Document().Lookup("step").Int32(); int(number) != j+1 {
+					mt.Errorf("want step %v numbered; got %v", j+1, number)
+				}
+				stored = append(stored, step.Document().Lookup("text").StringValue())
+			}
+			if fmt.Sprint(stored) != fmt.Sprint(tt.instructions) {
+				mt.Errorf("want instructions %v stored; got %v", tt.instructions, stored)
+			}
+		})
+	}
+}
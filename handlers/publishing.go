@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/TranQuocToan1996/ginProject/apierrors"
+	"github.com/TranQuocToan1996/ginProject/etag"
+	"github.com/TranQuocToan1996/ginProject/negotiate"
+	"github.com/TranQuocToan1996/ginProject/workflow"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// StatusRequest is the body to change the status of a recipe. A publishAt
+// in the future schedules the recipe to be published at that time.
+type StatusRequest struct {
+	Status    string     `json:"status" binding:"required"`
+	PublishAt *time.Time `json:"publishAt"`
+}
+
+// SetRecipeStatus moves a recipe of the signed in user, or any recipe for an
+// admin, to another status, or schedules it to be published. The If-Match
+// header has to carry the current entity tag of the recipe.
+func (handler *RecipesHandler) SetRecipeStatus(c *gin.Context) {
+	var request StatusRequest
+	if err := negotiate.Bind(c, &request); err != nil {
+		c.Error(apierrors.InvalidInput(err))
+		return
+	}
+	if !workflow.IsStatus(request.Status) {
+		c.Error(apierrors.BadRequest(workflow.ErrUnknownStatus.Error()))
+		return
+	}
+	recipe, err := handler.findRecipeByID(c.Param("id"), currentUser(c))
+	if err != nil {
+		c.Error(recipeError(err))
+		return
+	}
+	if err := handler.authorize(recipe, currentUser(c)); err != nil {
+		c.Error(err)
+		return
+	}
+	if !ifMatch(c, recipe) {
+		return
+	}
+
+	from := workflow.Of(recipe)
+	now := time.Now()
+	var update bson.M
+	switch {
+	case request.PublishAt != nil && request.PublishAt.After(now):
+		if request.Status != workflow.Published || from == workflow.Published {
+			c.Error(apierrors.Conflict("Only unpublished recipes can be scheduled to be published"))
+			return
+		}
+		update = bson.M{"$set": bson.M{"publishAt": request.PublishAt}, "$inc": nextVersion}
+	case !workflow.CanTransition(from, request.Status):
+		c.Error(apierrors.Conflict("Recipe can't move from " + from + " to " + request.Status))
+		return
+	case request.Status == workflow.Published:
+		update = bson.M{
+			"$set":   bson.M{"status": request.Status, "publishedAt": now},
+			"$unset": bson.M{"publishAt": ""},
+			"$inc":   nextVersion,
+		}
+	default:
+		update = bson.M{
+			"$set":   bson.M{"status": request.Status},
+			"$unset": bson.M{"publishAt": ""},
+			"$inc":   nextVersion,
+		}
+	}
+
+	err = handler.collection.FindOneAndUpdate(handler.ctx, versioned(bson.M{"_id": recipe.ID}, recipe.Version), update,
+		options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&recipe)
+	if err == mongo.ErrNoDocuments {
+		c.Error(ErrRecipeModified)
+		return
+	}
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	handler.redisClient.Del("recipes")
+	log.Println("Removed redis recipes!")
+	c.Header("ETag", etag.Of(recipe))
+	c.JSON(http.StatusOK, recipe)
+}
+
+// PublishScheduled publishes the recipes whose scheduled time has come
+func (handler *RecipesHandler) PublishScheduled() (int64, error) {
+	now := time.Now()
+	result, err := handler.collection.UpdateMany(handler.ctx, notDeleted(bson.M{
+		"publishAt": bson.M{"$lte": now},
+	}), bson.M{
+		"$set":   bson.M{"status": workflow.Published, "publishedAt": now},
+		"$unset": bson.M{"publishAt": ""},
+		"$inc":   nextVersion,
+	})
+	if err != nil {
+		return 0, err
+	}
+	if result.ModifiedCount > 0 {
+		handler.redisClient.Del("recipes")
+		log.Println("Removed redis recipes!")
+	}
+	return result.ModifiedCount, nil
+}
+
+// StartPublisher publishes the scheduled recipes every interval in the background
+func (handler *RecipesHandler) StartPublisher(interval time.Duration) {
+	handler.runEvery(interval, func() {
+		published, err := handler.PublishScheduled()
+		if err != nil {
+			log.Println("Publishing scheduled recipes failed:", err)
+		} else if published > 0 {
+			log.Printf("Published %v scheduled recipes", published)
+		}
+	})
+}
+
+// runEvery calls task every interval until the context of the handler is done
+func (handler *RecipesHandler) runEvery(interval time.Duration, task func()) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-handler.ctx.Done():
+				return
+			case <-ticker.C:
+				task()
+			}
+		}
+	}()
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/TranQuocToan1996/ginProject/models"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestAuthorize(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+	status := func(h *RecipesHandler) (string, string, string, func(*gin.Context)) {
+		return http.MethodPut, "/recipes/:id/status", `{"status": "archived"}`, h.SetRecipeStatus
+	}
+	admin := bson.D{{Key: "username", Value: testUser}, {Key: "role", Value: models.RoleAdmin}}
+	remove := func(h *RecipesHandler) (string, string, string, func(*gin.Context)) {
+		return http.MethodDelete, "/recipes/:id", "", h.DeleteRecipes
+	}
+	for i, tt := range []struct {
+		request func(*RecipesHandler) (string, string, string, func(*gin.Context))
+		author  string
+		// lookup is set when the signed in user is looked up, users are the
+		// documents it finds
+		lookup bool
+		users  []bson.D
+		status int
+	}{
+		// Recipes without an author predate authors, only admins change them
+		{status, "", true, []bson.D{{{Key: "username", Value: testUser}}}, http.StatusForbidden},
+		{status, "", true, []bson.D{admin}, http.StatusPreconditionRequired},
+		{remove, "alice", true, nil, http.StatusForbidden},
+		{remove, "alice", true, []bson.D{admin}, http.StatusPreconditionRequired},
+		{remove, testUser, false, nil, http.StatusPreconditionRequired},
+	} {
+		mt.Run(fmt.Sprintf("%v", i), func(mt *mtest.T) {
+			handler := newTestHandler(mt)
+			id := primitive.NewObjectID()
+			mt.AddMockResponses(found(mt, recipeDoc(id, 1, bson.E{Key: "author", Value: tt.author})))
+			if tt.lookup {
+				mt.AddMockResponses(found(mt, tt.users...))
+			}
+
+			method, route, body, h := tt.request(handler)
+			header := http.Header{"Content-Type": {"application/json"}}
+			w := serve(method, route, strings.Replace(route, ":id", id.Hex(), 1), strings.NewReader(body), header, h)
+			if w.Code != tt.status {
+				mt.Errorf("want %v; got %v %s", tt.status, w.Code, w.Body)
+			}
+			if finds, want := len(commands(mt, "find")), 1; tt.lookup && finds != want+1 || !tt.lookup && finds != want {
+				mt.Errorf("want the signed in user looked up only for recipes of others; got %v finds", finds)
+			}
+		})
+	}
+}
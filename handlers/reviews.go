@@ -0,0 +1,298 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/TranQuocToan1996/ginProject/apierrors"
+	"github.com/TranQuocToan1996/ginProject/models"
+	"github.com/TranQuocToan1996/ginProject/negotiate"
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ModerationHook inspects a review before it is saved. Returning hide as true
+// saves the review hidden, pending an admin decision, with note as the reason.
+type ModerationHook func(review models.Review) (hide bool, note string)
+
+type ReviewsHandler struct {
+	collection  *mongo.Collection
+	recipes     *mongo.Collection
+	ctx         context.Context
+	redisClient *redis.Client
+	hooks       []ModerationHook
+}
+
+func NewReviewsHandler(ctx context.Context, collection *mongo.Collection, recipes *mongo.Collection, redisClient *redis.Client) *ReviewsHandler {
+	return &ReviewsHandler{
+		collection:  collection,
+		recipes:     recipes,
+		ctx:         ctx,
+		redisClient: redisClient,
+	}
+}
+
+// AddModerationHook registers a hook run on every new or edited review
+func (handler *ReviewsHandler) AddModerationHook(hook ModerationHook) {
+	handler.hooks = append(handler.hooks, hook)
+}
+
+// EnsureIndexes creates the unique index allowing one review per user per recipe
+func (handler *ReviewsHandler) EnsureIndexes() error {
+	_, err := handler.collection.Indexes().CreateOne(handler.ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "recipeId", Value: 1}, {Key: "username", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return err
+}
+
+// ReviewRequest is the body to create or edit a review
+type ReviewRequest struct {
+	Rating int    `json:"rating" binding:"required,min=1,max=5"`
+	Text   string `json:"text" binding:"max=5000"`
+}
+
+// ModerationRequest is the body an admin sends to hide or restore a review
+type ModerationRequest struct {
+	Hidden bool   `json:"hidden"`
+	Note   string `json:"note"`
+}
+
+// ListReviews returns the visible reviews of a recipe, newest first
+func (handler *ReviewsHandler) ListReviews(c *gin.Context) {
+	recipeID, err := recipeObjectID(handler.ctx, handler.recipes, c.Param("id"), currentUser(c))
+	if err != nil {
+		c.Error(recipeError(err))
+		return
+	}
+	handler.listReviews(c, bson.M{"recipeId": recipeID, "hidden": false})
+}
+
+// AddReview adds the review of the signed in user to a recipe
+func (handler *ReviewsHandler) AddReview(c *gin.Context) {
+	var request ReviewRequest
+	if err := negotiate.Bind(c, &request); err != nil {
+		c.Error(apierrors.InvalidInput(err))
+		return
+	}
+	recipeID, err := recipeObjectID(handler.ctx, handler.recipes, c.Param("id"), currentUser(c))
+	if err != nil {
+		c.Error(recipeError(err))
+		return
+	}
+
+	now := time.Now()
+	review := models.Review{
+		ID:        primitive.NewObjectID(),
+		RecipeID:  recipeID,
+		UserName:  currentUser(c),
+		Rating:    request.Rating,
+		Text:      request.Text,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	handler.moderate(&review)
+	_, err = handler.collection.InsertOne(handler.ctx, review)
+	if mongo.IsDuplicateKeyError(err) {
+		c.Error(apierrors.Conflict("You already reviewed this recipe"))
+		return
+	}
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if err := handler.updateRating(recipeID); err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusCreated, review)
+}
+
+// UpdateReview edits the review of the signed in user on a recipe
+func (handler *ReviewsHandler) UpdateReview(c *gin.Context) {
+	var request ReviewRequest
+	if err := negotiate.Bind(c, &request); err != nil {
+		c.Error(apierrors.InvalidInput(err))
+		return
+	}
+	recipeID, err := parseID(c.Param("id"))
+	if err != nil {
+		c.Error(apierrors.InvalidInput(err))
+		return
+	}
+
+	var review models.Review
+	filter := bson.M{"recipeId": recipeID, "username": currentUser(c)}
+	if err := handler.collection.FindOne(handler.ctx, filter).Decode(&review); err != nil {
+		c.Error(notFound(err, "Review not found"))
+		return
+	}
+	review.Rating = request.Rating
+	review.Text = request.Text
+	review.UpdatedAt = time.Now()
+	review.Hidden, review.ModerationNote = false, ""
+	handler.moderate(&review)
+	_, err = handler.collection.ReplaceOne(handler.ctx, bson.M{"_id": review.ID}, review)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if err := handler.updateRating(recipeID); err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, review)
+}
+
+// DeleteReview deletes the review of the signed in user on a recipe
+func (handler *ReviewsHandler) DeleteReview(c *gin.Context) {
+	recipeID, err := parseID(c.Param("id"))
+	if err != nil {
+		c.Error(apierrors.InvalidInput(err))
+		return
+	}
+	result, err := handler.collection.DeleteOne(handler.ctx, bson.M{
+		"recipeId": recipeID,
+		"username": currentUser(c),
+	})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if result.DeletedCount == 0 {
+		c.Error(apierrors.NotFound("Review not found"))
+		return
+	}
+	if err := handler.updateRating(recipeID); err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Review has been deleted"})
+}
+
+// ListAllReviews lets admins browse reviews of every recipe, only hidden ones
+// with ?hidden=true
+func (handler *ReviewsHandler) ListAllReviews(c *gin.Context) {
+	filter := bson.M{}
+	if c.Query("hidden") == "true" {
+		filter["hidden"] = true
+	}
+	handler.listReviews(c, filter)
+}
+
+// ModerateReview lets admins hide a review, or restore a hidden one
+func (handler *ReviewsHandler) ModerateReview(c *gin.Context) {
+	var request ModerationRequest
+	if err := negotiate.Bind(c, &request); err != nil {
+		c.Error(apierrors.InvalidInput(err))
+		return
+	}
+	reviewID, err := parseID(c.Param("reviewId"))
+	if err != nil {
+		c.Error(apierrors.InvalidInput(err))
+		return
+	}
+
+	var review models.Review
+	err = handler.collection.FindOneAndUpdate(handler.ctx, bson.M{"_id": reviewID},
+		bson.M{"$set": bson.M{"hidden": request.Hidden, "moderationNote": request.Note}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&review)
+	if err != nil {
+		c.Error(notFound(err, "Review not found"))
+		return
+	}
+	if err := handler.updateRating(review.RecipeID); err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, review)
+}
+
+// RemoveReview lets admins delete any review
+func (handler *ReviewsHandler) RemoveReview(c *gin.Context) {
+	reviewID, err := parseID(c.Param("reviewId"))
+	if err != nil {
+		c.Error(apierrors.InvalidInput(err))
+		return
+	}
+	var review models.Review
+	if err := handler.collection.FindOneAndDelete(handler.ctx, bson.M{"_id": reviewID}).Decode(&review); err != nil {
+		c.Error(notFound(err, "Review not found"))
+		return
+	}
+	if err := handler.updateRating(review.RecipeID); err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Review has been deleted"})
+}
+
+func (handler *ReviewsHandler) listReviews(c *gin.Context, filter bson.M) {
+	cursor, err := handler.collection.Find(handler.ctx, filter,
+		options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}}))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	reviews := make([]models.Review, 0)
+	if err := cursor.All(handler.ctx, &reviews); err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, reviews)
+}
+
+func (handler *ReviewsHandler) moderate(review *models.Review) {
+	for _, hook := range handler.hooks {
+		if hide, note := hook(*review); hide {
+			review.Hidden, review.ModerationNote = true, note
+			return
+		}
+	}
+}
+
+// updateRating recomputes the average rating and the number of visible
+// reviews stored on a recipe
+func (handler *ReviewsHandler) updateRating(recipeID primitive.ObjectID) error {
+	cursor, err := handler.collection.Aggregate(handler.ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"recipeId": recipeID, "hidden": false}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":     nil,
+			"average": bson.M{"$avg": "$rating"},
+			"count":   bson.M{"$sum": 1},
+		}}},
+	})
+	if err != nil {
+		return err
+	}
+	var results []models.RatingSummary
+	if err := cursor.All(handler.ctx, &results); err != nil {
+		return err
+	}
+	rating := models.RatingSummary{}
+	if len(results) > 0 {
+		rating = results[0]
+	}
+
+	_, err = handler.recipes.UpdateOne(handler.ctx, bson.M{
+		"_id": recipeID,
+	}, bson.M{"$set": bson.M{"rating": rating}, "$inc": nextVersion})
+	if err != nil {
+		return err
+	}
+	handler.redisClient.Del("recipes")
+	log.Println("Removed redis recipes!")
+	return nil
+}
+
+// PurgeRecipes deletes the reviews of recipes purged from the trash
+func (handler *ReviewsHandler) PurgeRecipes(recipes []models.Recipe) error {
+	_, err := handler.collection.DeleteMany(handler.ctx, bson.M{"recipeId": bson.M{"$in": recipeIDs(recipes)}})
+	return err
+}
@@ -0,0 +1,228 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/TranQuocToan1996/ginProject/apierrors"
+	"github.com/TranQuocToan1996/ginProject/etag"
+	"github.com/TranQuocToan1996/ginProject/history"
+	"github.com/TranQuocToan1996/ginProject/models"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// EnsureIndexes creates the unique index numbering the revisions of each recipe
+func (handler *RecipesHandler) EnsureIndexes() error {
+	_, err := handler.revisions.Indexes().CreateOne(handler.ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "recipeId", Value: 1}, {Key: "number", Value: -1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return err
+}
+
+// ListRevisions returns the revisions of a recipe, newest first
+func (handler *RecipesHandler) ListRevisions(c *gin.Context) {
+	recipeID, err := recipeObjectID(handler.ctx, handler.collection, c.Param("id"), currentUser(c))
+	if err != nil {
+		c.Error(recipeError(err))
+		return
+	}
+	cursor, err := handler.revisions.Find(handler.ctx, bson.M{"recipeId": recipeID},
+		options.Find().SetSort(bson.D{{Key: "number", Value: -1}}))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	revisions := make([]models.Revision, 0)
+	if err := cursor.All(handler.ctx, &revisions); err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, revisions)
+}
+
+// GetRevision returns a single revision of a recipe by its number
+func (handler *RecipesHandler) GetRevision(c *gin.Context) {
+	revision, err := handler.revisionParam(c, c.Param("rev"))
+	if err != nil {
+		c.Error(revisionError(err))
+		return
+	}
+	c.JSON(http.StatusOK, revision)
+}
+
+// DiffRevisions compares a revision with the one numbered ?against=, by
+// default the revision before it
+func (handler *RecipesHandler) DiffRevisions(c *gin.Context) {
+	revision, err := handler.revisionParam(c, c.Param("rev"))
+	if err != nil {
+		c.Error(revisionError(err))
+		return
+	}
+	against := models.Revision{}
+	if value := c.Query("against"); value != "" {
+		against, err = handler.revisionParam(c, value)
+	} else if revision.Number > 1 {
+		against, err = handler.revisionParam(c, strconv.Itoa(revision.Number-1))
+	}
+	if err != nil {
+		c.Error(revisionError(err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"from":    against.Number,
+		"to":      revision.Number,
+		"changes": history.Diff(against.Content, revision.Content),
+	})
+}
+
+// RevertRecipe restores the content of a recipe to a revision, recorded as a
+// new revision. The If-Match header has to carry the current entity tag of
+// the recipe.
+func (handler *RecipesHandler) RevertRecipe(c *gin.Context) {
+	recipe, err := handler.findRecipeByID(c.Param("id"), currentUser(c))
+	if err != nil {
+		c.Error(recipeError(err))
+		return
+	}
+	revision, err := handler.revisionParam(c, c.Param("rev"))
+	if err != nil {
+		c.Error(revisionError(err))
+		return
+	}
+	if err := handler.authorize(recipe, currentUser(c)); err != nil {
+		c.Error(err)
+		return
+	}
+	if !ifMatch(c, recipe) {
+		return
+	}
+
+	before := history.Snapshot(recipe)
+	history.Apply(&recipe, revision.Content)
+	prepareRecipe(&recipe)
+	result, err := handler.collection.UpdateOne(handler.ctx, versioned(bson.M{
+		"_id": recipe.ID,
+	}, recipe.Version), bson.D{
+		{Key: "$set", Value: append(contentFields(recipe), derivedFields(recipe)...)},
+		{Key: "$inc", Value: nextVersion},
+	})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if result.MatchedCount == 0 {
+		c.Error(ErrRecipeModified)
+		return
+	}
+	recipe.Version++
+	handler.redisClient.Del("recipes")
+	log.Println("Removed redis recipes!")
+
+	err = handler.recordRevision(recipe.ID, &before, history.Snapshot(recipe), currentUser(c), history.Revert, revision.Number)
+	if err != nil {
+		c.Error(fmt.Errorf("Recipe reverted but its revision was not saved: %w", err))
+		return
+	}
+	c.Header("ETag", etag.Of(recipe))
+	c.JSON(http.StatusOK, recipe)
+}
+
+// revisionAttempts bounds how many times recordRevision numbers a revision
+// again when a concurrent change of the recipe took its number
+const revisionAttempts = 5
+
+// recordRevision stores after as the next revision of a recipe. The first
+// change of a recipe created before revisions existed also stores before as
+// its original revision, so it can be reverted to. The unique index on the
+// numbers rejects a revision numbered like a concurrent one, which is then
+// numbered again after it.
+func (handler *RecipesHandler) recordRevision(recipeID primitive.ObjectID, before *models.RecipeContent, after models.RecipeContent, author, action string, revertedTo int) error {
+	var err error
+	for attempt := 0; attempt < revisionAttempts; attempt++ {
+		err = handler.insertRevision(recipeID, before, after, author, action, revertedTo)
+		if !mongo.IsDuplicateKeyError(err) {
+			return err
+		}
+	}
+	return err
+}
+
+// insertRevision numbers after from the latest revision of the recipe and
+// inserts it
+func (handler *RecipesHandler) insertRevision(recipeID primitive.ObjectID, before *models.RecipeContent, after models.RecipeContent, author, action string, revertedTo int) error {
+	var last models.Revision
+	err := handler.revisions.FindOne(handler.ctx, bson.M{"recipeId": recipeID},
+		options.FindOne().SetSort(bson.D{{Key: "number", Value: -1}})).Decode(&last)
+	if err == mongo.ErrNoDocuments && before != nil {
+		last = models.Revision{
+			ID:        primitive.NewObjectID(),
+			RecipeID:  recipeID,
+			Number:    1,
+			Action:    history.Original,
+			Content:   *before,
+			Changes:   make([]models.FieldChange, 0),
+			CreatedAt: time.Now(),
+		}
+		_, err = handler.revisions.InsertOne(handler.ctx, last)
+	} else if err == mongo.ErrNoDocuments {
+		err = nil
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = handler.revisions.InsertOne(handler.ctx, models.Revision{
+		ID:         primitive.NewObjectID(),
+		RecipeID:   recipeID,
+		Number:     last.Number + 1,
+		Action:     action,
+		Author:     author,
+		RevertedTo: revertedTo,
+		Content:    after,
+		Changes:    history.Diff(last.Content, after),
+		CreatedAt:  time.Now(),
+	})
+	return err
+}
+
+// revisionParam loads the revision numbered number of the recipe of the id parameter
+func (handler *RecipesHandler) revisionParam(c *gin.Context, number string) (models.Revision, error) {
+	var revision models.Revision
+	recipeID, err := parseID(c.Param("id"))
+	if err != nil {
+		return revision, err
+	}
+	n, err := strconv.Atoi(number)
+	if err != nil || n < 1 {
+		return revision, errInvalidRevision
+	}
+	err = handler.revisions.FindOne(handler.ctx, bson.M{
+		"recipeId": recipeID,
+		"number":   n,
+	}).Decode(&revision)
+	return revision, err
+}
+
+// contentFields returns the content of recipe as a $set document
+func contentFields(recipe models.Recipe) bson.D {
+	return bson.D{
+		{Key: "name", Value: recipe.Name},
+		{Key: "ingredients", Value: recipe.Ingredients},
+		{Key: "tags", Value: recipe.Tags},
+		{Key: "servings", Value: recipe.Servings},
+	}
+}
+
+var errInvalidRevision = apierrors.BadRequest("revision must be a positive number")
+
+func revisionError(err error) error {
+	return notFound(err, "Revision not found")
+}
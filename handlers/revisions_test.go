@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/TranQuocToan1996/ginProject/history"
+	"github.com/TranQuocToan1996/ginProject/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestRecordRevision(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+	duplicate := mtest.CreateWriteErrorsResponse(mtest.WriteError{Code: 11000, Message: "duplicate key"})
+	for i, tt := range []struct {
+		// latest is the number of the latest revision before each insert,
+		// which fails with a duplicate key when concurrent is set
+		latest     []int
+		concurrent []bool
+		number     int
+		err        bool
+	}{
+		{[]int{1}, []bool{false}, 2, false},
+		{[]int{1, 2}, []bool{true, false}, 3, false},
+		{[]int{1, 2, 3, 4, 5}, []bool{true, true, true, true, true}, 0, true},
+	} {
+		mt.Run(fmt.Sprintf("%v", i), func(mt *mtest.T) {
+			handler := &RecipesHandler{ctx: context.Background(), revisions: mt.Coll}
+			for j, number := range tt.latest {
+				mt.AddMockResponses(found(mt, bson.D{{Key: "number", Value: number}}))
+				if tt.concurrent[j] {
+					mt.AddMockResponses(duplicate)
+				} else {
+					mt.AddMockResponses(mtest.CreateSuccessResponse())
+				}
+			}
+
+			err := handler.recordRevision(primitive.NewObjectID(), &models.RecipeContent{}, models.RecipeContent{Name: "Soup"}, "admin", history.Update, 0)
+			if tt.err {
+				if err == nil {
+					mt.Error("want an error after the last attempt")
+				}
+				return
+			}
+			if err != nil {
+				mt.Fatal(err)
+			}
+			number := 0
+			for _, event := range mt.GetAllStartedEvents() {
+				if event.CommandName == "insert" {
+					number = int(event.Command.Lookup("documents", "0", "number").Int32())
+				}
+			}
+			if number != tt.number {
+				mt.Errorf("want revision %v; got %v", tt.number, number)
+			}
+		})
+	}
+}
@@ -0,0 +1,298 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/TranQuocToan1996/ginProject/apierrors"
+	"github.com/TranQuocToan1996/ginProject/mealplan"
+	"github.com/TranQuocToan1996/ginProject/models"
+	"github.com/TranQuocToan1996/ginProject/negotiate"
+	"github.com/TranQuocToan1996/ginProject/shopping"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type ShoppingListsHandler struct {
+	collection *mongo.Collection
+	recipes    *mongo.Collection
+	mealPlans  *mongo.Collection
+	ctx        context.Context
+}
+
+func NewShoppingListsHandler(ctx context.Context, collection *mongo.Collection, recipes *mongo.Collection, mealPlans *mongo.Collection) *ShoppingListsHandler {
+	return &ShoppingListsHandler{
+		collection: collection,
+		recipes:    recipes,
+		mealPlans:  mealPlans,
+		ctx:        ctx,
+	}
+}
+
+// ShoppingListRequest is the body to generate a shopping list, either from
+// recipes or from the meal plan of a week
+type ShoppingListRequest struct {
+	Name      string   `json:"name" binding:"max=100"`
+	RecipeIDs []string `json:"recipeIds"`
+	Week      string   `json:"week"`
+}
+
+// CheckItemRequest is the body to check off an item of a shopping list
+type CheckItemRequest struct {
+	Checked bool `json:"checked"`
+}
+
+// AddShoppingList generates a shopping list for the signed in user from the
+// ingredients of recipes, or of the meals planned in a week scaled to their servings
+func (handler *ShoppingListsHandler) AddShoppingList(c *gin.Context) {
+	var request ShoppingListRequest
+	if err := negotiate.Bind(c, &request); err != nil {
+		c.Error(apierrors.InvalidInput(err))
+		return
+	}
+	if (len(request.RecipeIDs) == 0) == (request.Week == "") {
+		c.Error(apierrors.BadRequest("Send either recipeIds or week"))
+		return
+	}
+
+	var sources []shopping.Source
+	var err error
+	if request.Week != "" {
+		sources, err = handler.mealPlanSources(currentUser(c), request.Week)
+		if request.Name == "" {
+			request.Name = "Meal plan " + request.Week
+		}
+	} else {
+		sources, err = handler.recipeSources(request.RecipeIDs, currentUser(c))
+	}
+	if err == mealplan.ErrInvalidWeek {
+		c.Error(apierrors.InvalidInput(err))
+		return
+	}
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if len(sources) == 0 {
+		c.Error(apierrors.NotFound("No recipes found"))
+		return
+	}
+	if request.Name == "" {
+		request.Name = "Shopping list"
+	}
+
+	now := time.Now()
+	list := models.ShoppingList{
+		ID:        primitive.NewObjectID(),
+		Owner:     currentUser(c),
+		Name:      request.Name,
+		Recipes:   make([]string, 0, len(sources)),
+		Items:     shopping.Build(sources),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	for _, source := range sources {
+		if !contains(list.Recipes, source.Recipe) {
+			list.Recipes = append(list.Recipes, source.Recipe)
+		}
+	}
+	if _, err := handler.collection.InsertOne(handler.ctx, list); err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusCreated, list)
+}
+
+// ListShoppingLists returns the shopping lists of the signed in user, newest first
+func (handler *ShoppingListsHandler) ListShoppingLists(c *gin.Context) {
+	cursor, err := handler.collection.Find(handler.ctx, bson.M{"owner": currentUser(c)},
+		options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}}))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	lists := make([]models.ShoppingList, 0)
+	if err := cursor.All(handler.ctx, &lists); err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, lists)
+}
+
+// GetShoppingList returns a shopping list of the signed in user
+func (handler *ShoppingListsHandler) GetShoppingList(c *gin.Context) {
+	list, err := handler.ownList(c)
+	if err != nil {
+		c.Error(notFound(err, "Shopping list not found"))
+		return
+	}
+	c.JSON(http.StatusOK, list)
+}
+
+// ExportShoppingList returns a shopping list as plain text, or as Markdown
+// with ?format=markdown
+func (handler *ShoppingListsHandler) ExportShoppingList(c *gin.Context) {
+	list, err := handler.ownList(c)
+	if err != nil {
+		c.Error(notFound(err, "Shopping list not found"))
+		return
+	}
+	switch c.DefaultQuery("format", "text") {
+	case "text":
+		c.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(shopping.Text(list)))
+	case "markdown", "md":
+		c.Data(http.StatusOK, "text/markdown; charset=utf-8", []byte(shopping.Markdown(list)))
+	default:
+		c.Error(apierrors.BadRequest("format must be text or markdown"))
+	}
+}
+
+// CheckShoppingItem checks off an item of a shopping list, or unchecks it
+func (handler *ShoppingListsHandler) CheckShoppingItem(c *gin.Context) {
+	var request CheckItemRequest
+	if err := negotiate.Bind(c, &request); err != nil {
+		c.Error(apierrors.InvalidInput(err))
+		return
+	}
+	listID, err := parseID(c.Param("listId"))
+	if err != nil {
+		c.Error(apierrors.InvalidInput(err))
+		return
+	}
+	var list models.ShoppingList
+	err = handler.collection.FindOneAndUpdate(handler.ctx, bson.M{
+		"_id":      listID,
+		"owner":    currentUser(c),
+		"items.id": c.Param("itemId"),
+	}, bson.M{"$set": bson.M{
+		"items.$.checked": request.Checked,
+		"updatedAt":       time.Now(),
+	}}, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&list)
+	if err != nil {
+		c.Error(notFound(err, "Shopping list item not found"))
+		return
+	}
+	c.JSON(http.StatusOK, list)
+}
+
+// DeleteShoppingList deletes a shopping list of the signed in user
+func (handler *ShoppingListsHandler) DeleteShoppingList(c *gin.Context) {
+	listID, err := parseID(c.Param("listId"))
+	if err != nil {
+		c.Error(apierrors.InvalidInput(err))
+		return
+	}
+	result, err := handler.collection.DeleteOne(handler.ctx, bson.M{
+		"_id":   listID,
+		"owner": currentUser(c),
+	})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if result.DeletedCount == 0 {
+		c.Error(apierrors.NotFound("Shopping list not found"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Shopping list has been deleted"})
+}
+
+func (handler *ShoppingListsHandler) ownList(c *gin.Context) (models.ShoppingList, error) {
+	var list models.ShoppingList
+	listID, err := parseID(c.Param("listId"))
+	if err != nil {
+		return list, err
+	}
+	err = handler.collection.FindOne(handler.ctx, bson.M{
+		"_id":   listID,
+		"owner": currentUser(c),
+	}).Decode(&list)
+	return list, err
+}
+
+// recipeSources loads the recipes of ids, unknown ones and the ones user
+// can't see are skipped
+func (handler *ShoppingListsHandler) recipeSources(ids []string, user string) ([]shopping.Source, error) {
+	objectIds := make([]primitive.ObjectID, 0, len(ids))
+	for _, id := range ids {
+		objectId, err := parseID(id)
+		if err != nil {
+			return nil, err
+		}
+		objectIds = append(objectIds, objectId)
+	}
+	recipes, err := handler.findRecipes(objectIds, user)
+	if err != nil {
+		return nil, err
+	}
+	sources := make([]shopping.Source, 0, len(objectIds))
+	for _, id := range objectIds {
+		if recipe, ok := recipes[id]; ok {
+			sources = append(sources, shopping.Source{Recipe: recipe.Name, Lines: recipe.Ingredients})
+		}
+	}
+	return sources, nil
+}
+
+// mealPlanSources loads the recipes planned by owner in a week, each scaled
+// from the servings of the recipe to the servings of its meal
+func (handler *ShoppingListsHandler) mealPlanSources(owner, week string) ([]shopping.Source, error) {
+	monday, err := mealplan.ParseWeek(week)
+	if err != nil {
+		return nil, err
+	}
+	var plan models.MealPlan
+	err = handler.mealPlans.FindOne(handler.ctx, bson.M{
+		"owner":     owner,
+		"weekStart": monday.Format(mealplan.DateFormat),
+	}).Decode(&plan)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	objectIds := make([]primitive.ObjectID, 0, len(plan.Entries))
+	for _, entry := range plan.Entries {
+		objectIds = append(objectIds, entry.RecipeID)
+	}
+	recipes, err := handler.findRecipes(objectIds, owner)
+	if err != nil {
+		return nil, err
+	}
+	sources := make([]shopping.Source, 0, len(plan.Entries))
+	for _, entry := range plan.Entries {
+		recipe, ok := recipes[entry.RecipeID]
+		if !ok {
+			continue
+		}
+		factor := 1.0
+		if recipe.Servings > 0 && entry.Servings > 0 {
+			factor = float64(entry.Servings) / float64(recipe.Servings)
+		}
+		sources = append(sources, shopping.Source{Recipe: recipe.Name, Lines: recipe.Ingredients, Factor: factor})
+	}
+	return sources, nil
+}
+
+// findRecipes loads the recipes of ids visible to user by id
+func (handler *ShoppingListsHandler) findRecipes(ids []primitive.ObjectID, user string) (map[primitive.ObjectID]models.Recipe, error) {
+	cursor, err := handler.recipes.Find(handler.ctx, visibleTo(bson.M{"_id": bson.M{"$in": ids}}, user))
+	if err != nil {
+		return nil, err
+	}
+	var found []models.Recipe
+	if err := cursor.All(handler.ctx, &found); err != nil {
+		return nil, err
+	}
+	recipes := make(map[primitive.ObjectID]models.Recipe, len(found))
+	for _, recipe := range found {
+		recipes[recipe.ID] = recipe
+	}
+	return recipes, nil
+}
@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestAddShoppingListVisibility(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+	mt.Run("draft of another user", func(mt *mtest.T) {
+		handler := NewShoppingListsHandler(context.Background(), mt.Coll, mt.Coll, mt.Coll)
+		// The draft isn't visible to the signed in user, so none is found
+		mt.AddMockResponses(found(mt))
+
+		body := strings.NewReader(`{"recipeIds": ["` + primitive.NewObjectID().Hex() + `"]}`)
+		w := serve(http.MethodPost, "/shopping-lists", "/shopping-lists", body, nil, handler.AddShoppingList)
+		if w.Code != http.StatusNotFound {
+			mt.Errorf("want %v; got %v %s", http.StatusNotFound, w.Code, w.Body)
+		}
+		finds := commands(mt, "find")
+		if len(finds) != 1 {
+			mt.Fatalf("want 1 find; got %v", len(finds))
+		}
+		if authors := visibleAuthors(finds[0]); len(authors) != 1 || authors[0] != testUser {
+			mt.Errorf("want the unpublished recipes of %v only; got %v", testUser, authors)
+		}
+	})
+}
@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/TranQuocToan1996/ginProject/apierrors"
+	"github.com/TranQuocToan1996/ginProject/etag"
+	"github.com/TranQuocToan1996/ginProject/history"
+	"github.com/TranQuocToan1996/ginProject/instructions"
+	"github.com/TranQuocToan1996/ginProject/models"
+	"github.com/TranQuocToan1996/ginProject/negotiate"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// StepRequest is the body of InsertStep
+type StepRequest struct {
+	// Position is the 1-based step number the new step takes, 0 appends it
+	Position int    `json:"position"`
+	Section  string `json:"section"`
+	Text     string `json:"text" binding:"required"`
+}
+
+// ReorderRequest lists every current step number in the new order
+type ReorderRequest struct {
+	Order []int `json:"order" binding:"required"`
+}
+
+// InsertStep inserts a single instruction into a recipe
+func (handler *RecipesHandler) InsertStep(c *gin.Context) {
+	var request StepRequest
+	if err := negotiate.Bind(c, &request); err != nil {
+		c.Error(apierrors.InvalidInput(err))
+		return
+	}
+	recipe, err := handler.findRecipeByID(c.Param("id"), currentUser(c))
+	if err != nil {
+		c.Error(recipeError(err))
+		return
+	}
+	steps := append([]models.Instruction(nil), recipe.Instructions...)
+	position := request.Position
+	if position == 0 {
+		position = len(steps) + 1
+	}
+	if position < 1 || position > len(steps)+1 {
+		c.Error(apierrors.BadRequest("position is out of range"))
+		return
+	}
+
+	step := models.Instruction{Section: request.Section, Text: request.Text}
+	steps = append(steps[:position-1], append([]models.Instruction{step}, steps[position-1:]...)...)
+	handler.saveSteps(c, recipe, steps)
+}
+
+// RemoveStep removes a single instruction from a recipe by its step number
+func (handler *RecipesHandler) RemoveStep(c *gin.Context) {
+	number, err := strconv.Atoi(c.Param("step"))
+	if err != nil {
+		c.Error(apierrors.BadRequest("step must be a number"))
+		return
+	}
+	recipe, err := handler.findRecipeByID(c.Param("id"), currentUser(c))
+	if err != nil {
+		c.Error(recipeError(err))
+		return
+	}
+	steps := append([]models.Instruction(nil), recipe.Instructions...)
+	if number < 1 || number > len(steps) {
+		c.Error(apierrors.NotFound("Step not found"))
+		return
+	}
+
+	steps = append(steps[:number-1], steps[number:]...)
+	handler.saveSteps(c, recipe, steps)
+}
+
+// ReorderSteps reorders the instructions of a recipe. The body has to list
+// every current step number exactly once.
+func (handler *RecipesHandler) ReorderSteps(c *gin.Context) {
+	var request ReorderRequest
+	if err := negotiate.Bind(c, &request); err != nil {
+		c.Error(apierrors.InvalidInput(err))
+		return
+	}
+	recipe, err := handler.findRecipeByID(c.Param("id"), currentUser(c))
+	if err != nil {
+		c.Error(recipeError(err))
+		return
+	}
+	if len(request.Order) != len(recipe.Instructions) {
+		c.Error(apierrors.BadRequest("order must list every step"))
+		return
+	}
+
+	steps := make([]models.Instruction, 0, len(request.Order))
+	seen := make(map[int]bool)
+	for _, number := range request.Order {
+		if number < 1 || number > len(recipe.Instructions) || seen[number] {
+			c.Error(apierrors.BadRequest("order must list every step once"))
+			return
+		}
+		seen[number] = true
+		steps = append(steps, recipe.Instructions[number-1])
+	}
+	handler.saveSteps(c, recipe, steps)
+}
+
+// saveSteps renumbers steps, writes them as the instructions of recipe,
+// records the change as a revision and responds with the new instructions.
+// The If-Match header has to carry the current entity tag of the recipe.
+func (handler *RecipesHandler) saveSteps(c *gin.Context, recipe models.Recipe, steps []models.Instruction) {
+	if err := handler.authorize(recipe, currentUser(c)); err != nil {
+		c.Error(err)
+		return
+	}
+	if !ifMatch(c, recipe) {
+		return
+	}
+	before := history.Snapshot(recipe)
+	steps = instructions.Renumber(steps)
+	result, err := handler.collection.UpdateOne(handler.ctx, versioned(bson.M{
+		"_id": recipe.ID,
+	}, recipe.Version), bson.M{"$set": bson.M{"instructions": steps}, "$inc": nextVersion})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if result.MatchedCount == 0 {
+		c.Error(ErrRecipeModified)
+		return
+	}
+
+	handler.redisClient.Del("recipes")
+	log.Println("Removed redis recipes!")
+
+	recipe.Instructions = steps
+	recipe.Version++
+	c.Header("ETag", etag.Of(recipe))
+	err = handler.recordRevision(recipe.ID, &before, history.Snapshot(recipe), currentUser(c), history.Steps, 0)
+	if err != nil {
+		c.Error(fmt.Errorf("Steps saved but their revision was not: %w", err))
+		return
+	}
+	c.JSON(http.StatusOK, steps)
+}
+
+// NormalizeRecipes cleans the instructions of every stored recipe, splitting
+// section headings and detecting durations in data imported as plain strings,
+// and recomputes the fields derived from its content such as nutrition and diet labels
+func (handler *RecipesHandler) NormalizeRecipes(c *gin.Context) {
+	cursor, err := handler.collection.Find(handler.ctx, notDeleted(bson.M{}))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	defer cursor.Close(handler.ctx)
+
+	updated := 0
+	for cursor.Next(handler.ctx) {
+		var recipe models.Recipe
+		if err := cursor.Decode(&recipe); err != nil {
+			c.Error(err)
+			return
+		}
+		before := history.Snapshot(recipe)
+		prepareRecipe(&recipe)
+		result, err := handler.collection.UpdateOne(handler.ctx, versioned(bson.M{
+			"_id": recipe.ID,
+		}, recipe.Version), bson.M{"$set": derivedFields(recipe), "$inc": nextVersion})
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		// A recipe changed since it was read was prepared by that change
+		if result.MatchedCount == 0 {
+			continue
+		}
+		if after := history.Snapshot(recipe); len(history.Diff(before, after)) > 0 {
+			err := handler.recordRevision(recipe.ID, &before, after, currentUser(c), history.Normalize, 0)
+			if err != nil {
+				c.Error(err)
+				return
+			}
+		}
+		updated++
+	}
+
+	handler.redisClient.Del("recipes")
+	log.Println("Removed redis recipes!")
+	c.JSON(http.StatusOK, gin.H{"message": "Recipes have been normalized", "updated": updated})
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestRemoveStep(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+	id := primitive.NewObjectID()
+	current := fmt.Sprintf(`"%v-3"`, id.Hex())
+	for i, tt := range []struct {
+		ifMatch string
+		matched int
+		status  int
+		etag    string
+		updates int
+	}{
+		{"", 0, http.StatusPreconditionRequired, "", 0},
+		{fmt.Sprintf(`"%v-2"`, id.Hex()), 0, http.StatusPreconditionFailed, current, 0},
+		{current, 0, http.StatusPreconditionFailed, "", 1},
+		{current, 1, http.StatusOK, fmt.Sprintf(`"%v-4"`, id.Hex()), 1},
+	} {
+		mt.Run(fmt.Sprintf("%v", i), func(mt *mtest.T) {
+			handler := newTestHandler(mt)
+			mt.AddMockResponses(
+				found(mt, recipeDoc(id, 3,
+					bson.E{Key: "name", Value: "Soup"},
+					bson.E{Key: "author", Value: testUser},
+					bson.E{Key: "instructions", Value: bson.A{bson.D{{Key: "text", Value: "Chop"}}, bson.D{{Key: "text", Value: "Boil"}}}},
+				)),
+				updated(tt.matched),
+				found(mt),
+				mtest.CreateSuccessResponse(),
+				mtest.CreateSuccessResponse(),
+			)
+			header := http.Header{}
+			if tt.ifMatch != "" {
+				header.Set("If-Match", tt.ifMatch)
+			}
+
+			w := serve(http.MethodDelete, "/recipes/:id/steps/:step", "/recipes/"+id.Hex()+"/steps/1", nil, header, handler.RemoveStep)
+			if w.Code != tt.status || w.Header().Get("ETag") != tt.etag {
+				mt.Errorf("want %v %v; got %v %v %v", tt.status, tt.etag, w.Code, w.Header().Get("ETag"), w.Body)
+			}
+			updates := commands(mt, "update")
+			if len(updates) != tt.updates {
+				mt.Fatalf("want %v updates; got %v", tt.updates, len(updates))
+			}
+			for _, update := range updates {
+				if version := update.Lookup("updates", "0", "q", "version").Int32(); version != 3 {
+					mt.Errorf("want the update filtered on version 3; got %v", update)
+				}
+			}
+		})
+	}
+}
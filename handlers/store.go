@@ -0,0 +1,224 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/TranQuocToan1996/ginProject/apierrors"
+	"github.com/TranQuocToan1996/ginProject/diet"
+	"github.com/TranQuocToan1996/ginProject/etag"
+	"github.com/TranQuocToan1996/ginProject/history"
+	"github.com/TranQuocToan1996/ginProject/models"
+	"github.com/TranQuocToan1996/ginProject/validation"
+	"github.com/TranQuocToan1996/ginProject/workflow"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// ErrRecipeModified is returned by writes based on another version of a recipe
+var ErrRecipeModified = apierrors.PreconditionFailed("Recipe has been modified")
+
+// RecipeFilter selects recipes. Empty fields don't filter, Statuses defaults
+// to published.
+type RecipeFilter struct {
+	Tag              string
+	Author           string
+	Query            string
+	Statuses         []string
+	Diets            []string
+	ExcludeAllergens []string
+}
+
+func (filter *RecipeFilter) validate() error {
+	if len(filter.Statuses) == 0 {
+		filter.Statuses = []string{workflow.Published}
+	}
+	for _, status := range filter.Statuses {
+		if !workflow.IsStatus(status) {
+			return apierrors.InvalidInput(workflow.ErrUnknownStatus)
+		}
+	}
+	for _, label := range filter.Diets {
+		if !diet.IsLabel(label) {
+			return apierrors.BadRequest("Unknown diet " + label)
+		}
+	}
+	for _, allergen := range filter.ExcludeAllergens {
+		if !diet.IsAllergen(allergen) {
+			return apierrors.BadRequest("Unknown allergen " + allergen)
+		}
+	}
+	return nil
+}
+
+// FindRecipe returns the recipe of a hex id, unless it is in the trash or
+// unpublished and not written by user
+func (handler *RecipesHandler) FindRecipe(id, user string) (models.Recipe, error) {
+	recipe, err := handler.findRecipeByID(id, user)
+	if err != nil {
+		return recipe, recipeError(err)
+	}
+	recipe.ETag = etag.Of(recipe)
+	return recipe, nil
+}
+
+// FilterRecipes returns the recipes visible to user matching filter, read
+// through the redis cache
+func (handler *RecipesHandler) FilterRecipes(user string, filter RecipeFilter) ([]models.Recipe, error) {
+	if err := filter.validate(); err != nil {
+		return nil, err
+	}
+	recipes, err := handler.loadRecipes()
+	if err != nil {
+		return nil, err
+	}
+	recipes = visibleRecipes(recipes, user, filter.Statuses)
+
+	query := strings.ToLower(filter.Query)
+	result := make([]models.Recipe, 0, len(recipes))
+	for i := range recipes {
+		recipe := &recipes[i]
+		if filter.Tag != "" && !containsFold(recipe.Tags, filter.Tag) {
+			continue
+		}
+		if filter.Author != "" && recipe.Author != filter.Author {
+			continue
+		}
+		if query != "" && !strings.Contains(strings.ToLower(recipe.Name), query) {
+			continue
+		}
+		if matchesDiet(recipe, filter.Diets, filter.ExcludeAllergens) {
+			result = append(result, *recipe)
+		}
+	}
+	return result, nil
+}
+
+// CreateRecipe validates recipe and stores it as a new recipe of author
+func (handler *RecipesHandler) CreateRecipe(recipe *models.Recipe, author string) error {
+	if err := validation.Struct(recipe); err != nil {
+		return apierrors.InvalidInput(err)
+	}
+	if err := newRecipe(recipe, author); err != nil {
+		return apierrors.InvalidInput(err)
+	}
+	if _, err := handler.collection.InsertOne(handler.ctx, recipe); err != nil {
+		return err
+	}
+
+	// The data cached in memory, so if we install/update new recipe in mongo. The cached not update yet.
+	// There are 2 solutions for this situation.
+	// First, Set Time TO Live (TTL) for the recipes.
+	// Second is delete recipes after install/update new recipe. The redis will load again when we call ListRecipes.
+	// In the scope of project, the data not so much. So we choose 2nd solution
+	handler.redisClient.Del("recipes")
+	log.Println("Removed redis recipes!")
+
+	err := handler.recordRevision(recipe.ID, nil, history.Snapshot(*recipe), author, history.Create, 0)
+	if err != nil {
+		log.Println(err)
+	}
+	recipe.ETag = etag.Of(*recipe)
+	return nil
+}
+
+// UpdateRecipe replaces the content of the recipe of id with the one of
+// recipe on behalf of user, if version is still its current version
+func (handler *RecipesHandler) UpdateRecipe(id, user string, version int, recipe models.Recipe) (models.Recipe, error) {
+	if err := validation.Struct(&recipe); err != nil {
+		return recipe, apierrors.InvalidInput(err)
+	}
+	current, err := handler.FindRecipe(id, user)
+	if err != nil {
+		return current, err
+	}
+	if err := handler.authorize(current, user); err != nil {
+		return current, err
+	}
+	if current.Version != version {
+		return current, ErrRecipeModified
+	}
+	return handler.replaceRecipe(current, recipe, user)
+}
+
+// replaceRecipe writes the content of recipe over current, keeping current
+// as a revision, and returns the updated recipe
+func (handler *RecipesHandler) replaceRecipe(current, recipe models.Recipe, user string) (models.Recipe, error) {
+	prepareRecipe(&recipe)
+	result, err := handler.collection.UpdateOne(handler.ctx, versioned(bson.M{
+		"_id": current.ID,
+	}, current.Version), bson.D{
+		{Key: "$set", Value: append(contentFields(recipe), derivedFields(recipe)...)},
+		{Key: "$inc", Value: nextVersion},
+	})
+	if err != nil {
+		return current, err
+	}
+	if result.MatchedCount == 0 {
+		return current, ErrRecipeModified
+	}
+
+	handler.redisClient.Del("recipes")
+	log.Println("Removed redis recipes!")
+
+	updated := current
+	history.Apply(&updated, history.Snapshot(recipe))
+	updated.Nutrition = recipe.Nutrition
+	updated.DietLabels = recipe.DietLabels
+	updated.Allergens = recipe.Allergens
+	updated.Version++
+	updated.ETag = etag.Of(updated)
+
+	before := history.Snapshot(current)
+	err = handler.recordRevision(current.ID, &before, history.Snapshot(recipe), user, history.Update, 0)
+	if err != nil {
+		return updated, fmt.Errorf("Recipe updated but its revision was not saved: %w", err)
+	}
+	return updated, nil
+}
+
+// DeleteRecipe moves the recipe of id to the trash on behalf of user, if
+// version is still its current version
+func (handler *RecipesHandler) DeleteRecipe(id, user string, version int) error {
+	current, err := handler.FindRecipe(id, user)
+	if err != nil {
+		return err
+	}
+	if err := handler.authorize(current, user); err != nil {
+		return err
+	}
+	if current.Version != version {
+		return ErrRecipeModified
+	}
+	return handler.trashRecipe(current, user)
+}
+
+// trashRecipe moves recipe to the trash on behalf of user
+func (handler *RecipesHandler) trashRecipe(recipe models.Recipe, user string) error {
+	result, err := handler.collection.UpdateOne(handler.ctx, versioned(notDeleted(bson.M{
+		"_id": recipe.ID,
+	}), recipe.Version), bson.M{
+		"$set": bson.M{"deletedAt": time.Now(), "deletedBy": user},
+		"$inc": nextVersion,
+	})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrRecipeModified
+	}
+
+	handler.redisClient.Del("recipes")
+	log.Println("Removed redis recipes!")
+	return nil
+}
+
+func containsFold(list []string, value string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,206 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/TranQuocToan1996/ginProject/apierrors"
+	"github.com/TranQuocToan1996/ginProject/diet"
+	"github.com/TranQuocToan1996/ginProject/ingredients"
+	"github.com/TranQuocToan1996/ginProject/models"
+	"github.com/TranQuocToan1996/ginProject/negotiate"
+	"github.com/TranQuocToan1996/ginProject/pantry"
+	"github.com/TranQuocToan1996/ginProject/substitutions"
+	"github.com/TranQuocToan1996/ginProject/units"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type SubstitutionsHandler struct {
+	collection *mongo.Collection
+	recipes    *mongo.Collection
+	ctx        context.Context
+}
+
+func NewSubstitutionsHandler(ctx context.Context, collection *mongo.Collection, recipes *mongo.Collection) *SubstitutionsHandler {
+	return &SubstitutionsHandler{
+		collection: collection,
+		recipes:    recipes,
+		ctx:        ctx,
+	}
+}
+
+// EnsureDefaults stores the curated substitution table when the collection is empty
+func (handler *SubstitutionsHandler) EnsureDefaults() error {
+	count, err := handler.collection.CountDocuments(handler.ctx, bson.M{})
+	if err != nil || count > 0 {
+		return err
+	}
+	subs, err := substitutions.Defaults()
+	if err != nil {
+		return err
+	}
+	docs := make([]interface{}, 0, len(subs))
+	for _, sub := range subs {
+		sub.ID = primitive.NewObjectID()
+		sub.UpdatedAt = time.Now()
+		docs = append(docs, sub)
+	}
+	_, err = handler.collection.InsertMany(handler.ctx, docs)
+	return err
+}
+
+// RecipeSubstitutions suggests replacements for the ingredients of a recipe,
+// with quantities adjusted to the recipe. ?ingredient= limits the suggestions
+// to the ingredients matching a name, ?diet= and ?exclude_allergen= keep the
+// replacements fitting dietary restrictions and ?units= picks the unit system.
+func (handler *SubstitutionsHandler) RecipeSubstitutions(c *gin.Context) {
+	system, err := units.ParseSystem(c.Query("units"))
+	if err != nil {
+		c.Error(apierrors.InvalidInput(err))
+		return
+	}
+	diets := queryList(c, "diet")
+	for _, label := range diets {
+		if !diet.IsLabel(label) {
+			c.Error(apierrors.BadRequest("Unknown diet " + label))
+			return
+		}
+	}
+	allergens := queryList(c, "exclude_allergen")
+	for _, allergen := range allergens {
+		if !diet.IsAllergen(allergen) {
+			c.Error(apierrors.BadRequest("Unknown allergen " + allergen))
+			return
+		}
+	}
+	objectId, err := parseID(c.Param("id"))
+	if err != nil {
+		c.Error(apierrors.InvalidInput(err))
+		return
+	}
+	var recipe models.Recipe
+	if err := handler.recipes.FindOne(handler.ctx, visibleTo(bson.M{"_id": objectId}, currentUser(c))).Decode(&recipe); err != nil {
+		c.Error(recipeError(err))
+		return
+	}
+	subs, err := handler.findSubstitutions()
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	wanted := pantry.Key(c.Query("ingredient"))
+	result := make([]models.IngredientSubstitutions, 0)
+	for _, line := range recipe.Ingredients {
+		if ingredients.IsSeparator(line) {
+			continue
+		}
+		if wanted != "" && !strings.Contains(" "+pantry.Key(line)+" ", " "+wanted+" ") {
+			continue
+		}
+		suggestions := substitutions.Suggest(line, subs, diets, allergens, system)
+		if len(suggestions) > 0 {
+			result = append(result, models.IngredientSubstitutions{
+				Ingredient:  strings.TrimSpace(line),
+				Suggestions: suggestions,
+			})
+		}
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// ListSubstitutions returns the substitution table
+func (handler *SubstitutionsHandler) ListSubstitutions(c *gin.Context) {
+	subs, err := handler.findSubstitutions()
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, subs)
+}
+
+// AddSubstitution adds a substitution to the table
+func (handler *SubstitutionsHandler) AddSubstitution(c *gin.Context) {
+	var sub models.Substitution
+	if err := negotiate.Bind(c, &sub); err != nil {
+		c.Error(apierrors.InvalidInput(err))
+		return
+	}
+	if err := substitutions.Validate(sub); err != nil {
+		c.Error(apierrors.InvalidInput(err))
+		return
+	}
+	sub.ID = primitive.NewObjectID()
+	sub.UpdatedAt = time.Now()
+	if _, err := handler.collection.InsertOne(handler.ctx, sub); err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusCreated, sub)
+}
+
+// UpdateSubstitution replaces a substitution of the table
+func (handler *SubstitutionsHandler) UpdateSubstitution(c *gin.Context) {
+	var sub models.Substitution
+	if err := negotiate.Bind(c, &sub); err != nil {
+		c.Error(apierrors.InvalidInput(err))
+		return
+	}
+	if err := substitutions.Validate(sub); err != nil {
+		c.Error(apierrors.InvalidInput(err))
+		return
+	}
+	subID, err := parseID(c.Param("subId"))
+	if err != nil {
+		c.Error(apierrors.InvalidInput(err))
+		return
+	}
+	sub.ID = subID
+	sub.UpdatedAt = time.Now()
+	result, err := handler.collection.ReplaceOne(handler.ctx, bson.M{"_id": subID}, sub)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if result.MatchedCount == 0 {
+		c.Error(apierrors.NotFound("Substitution not found"))
+		return
+	}
+	c.JSON(http.StatusOK, sub)
+}
+
+// DeleteSubstitution removes a substitution from the table
+func (handler *SubstitutionsHandler) DeleteSubstitution(c *gin.Context) {
+	subID, err := parseID(c.Param("subId"))
+	if err != nil {
+		c.Error(apierrors.InvalidInput(err))
+		return
+	}
+	result, err := handler.collection.DeleteOne(handler.ctx, bson.M{"_id": subID})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if result.DeletedCount == 0 {
+		c.Error(apierrors.NotFound("Substitution not found"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Substitution has been deleted"})
+}
+
+func (handler *SubstitutionsHandler) findSubstitutions() ([]models.Substitution, error) {
+	cursor, err := handler.collection.Find(handler.ctx, bson.M{},
+		options.Find().SetSort(bson.D{{Key: "ingredient", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	subs := make([]models.Substitution, 0)
+	err = cursor.All(handler.ctx, &subs)
+	return subs, err
+}
@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/TranQuocToan1996/ginProject/apierrors"
+	"github.com/TranQuocToan1996/ginProject/etag"
+	"github.com/TranQuocToan1996/ginProject/models"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ListTrash returns the deleted recipes, most recently deleted first, with the
+// time each one will be purged at
+func (handler *RecipesHandler) ListTrash(c *gin.Context) {
+	cursor, err := handler.collection.Find(handler.ctx, inTrash(bson.M{}, currentUser(c)),
+		options.Find().SetSort(bson.D{{Key: "deletedAt", Value: -1}}))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	var recipes []models.Recipe
+	if err := cursor.All(handler.ctx, &recipes); err != nil {
+		c.Error(err)
+		return
+	}
+	trash := make([]models.TrashedRecipe, 0, len(recipes))
+	for _, recipe := range recipes {
+		trash = append(trash, models.TrashedRecipe{
+			Recipe:  recipe,
+			PurgeAt: recipe.DeletedAt.Add(handler.retention),
+		})
+	}
+	c.JSON(http.StatusOK, trash)
+}
+
+// RestoreRecipe takes a recipe out of the trash
+func (handler *RecipesHandler) RestoreRecipe(c *gin.Context) {
+	objectId, err := parseID(c.Param("id"))
+	if err != nil {
+		c.Error(apierrors.InvalidInput(err))
+		return
+	}
+	var recipe models.Recipe
+	err = handler.collection.FindOneAndUpdate(handler.ctx, inTrash(bson.M{
+		"_id": objectId,
+	}, currentUser(c)), bson.M{"$unset": bson.M{"deletedAt": "", "deletedBy": ""}, "$inc": nextVersion},
+		options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&recipe)
+	if err != nil {
+		c.Error(notFound(err, "Recipe not found in trash"))
+		return
+	}
+
+	handler.redisClient.Del("recipes")
+	log.Println("Removed redis recipes!")
+	c.Header("ETag", etag.Of(recipe))
+	c.JSON(http.StatusOK, recipe)
+}
+
+// inTrash restricts a filter on recipes to the ones in the trash user can see
+func inTrash(filter bson.M, user string) bson.M {
+	filter = visibleTo(filter, user)
+	filter["deletedAt"] = bson.M{"$exists": true}
+	return filter
+}
+
+// PurgeHook deletes the data referring to recipes purged from the trash, such
+// as their reviews. It runs before the recipes are deleted, so a failed purge
+// is retried with the same recipes.
+type PurgeHook func(recipes []models.Recipe) error
+
+// AddPurgeHook registers a hook run on the recipes of every purge
+func (handler *RecipesHandler) AddPurgeHook(hook PurgeHook) {
+	handler.purgeHooks = append(handler.purgeHooks, hook)
+}
+
+// PurgeTrash permanently deletes the recipes deleted longer than the
+// retention window ago, with their revisions and what the purge hooks delete
+func (handler *RecipesHandler) PurgeTrash() (int64, error) {
+	filter := bson.M{"deletedAt": bson.M{"$lt": time.Now().Add(-handler.retention)}}
+	cursor, err := handler.collection.Find(handler.ctx, filter,
+		options.Find().SetProjection(bson.M{"_id": 1, "images": 1}))
+	if err != nil {
+		return 0, err
+	}
+	var expired []models.Recipe
+	if err := cursor.All(handler.ctx, &expired); err != nil {
+		return 0, err
+	}
+	if len(expired) == 0 {
+		return 0, nil
+	}
+	for _, hook := range handler.purgeHooks {
+		if err := hook(expired); err != nil {
+			return 0, err
+		}
+	}
+	ids := recipeIDs(expired)
+	result, err := handler.collection.DeleteMany(handler.ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return 0, err
+	}
+	_, err = handler.revisions.DeleteMany(handler.ctx, bson.M{"recipeId": bson.M{"$in": ids}})
+	return result.DeletedCount, err
+}
+
+// recipeIDs returns the ids of recipes
+func recipeIDs(recipes []models.Recipe) []primitive.ObjectID {
+	ids := make([]primitive.ObjectID, 0, len(recipes))
+	for _, recipe := range recipes {
+		ids = append(ids, recipe.ID)
+	}
+	return ids
+}
+
+// StartTrashPurger purges the trash every interval in the background
+func (handler *RecipesHandler) StartTrashPurger(interval time.Duration) {
+	handler.runEvery(interval, func() {
+		purged, err := handler.PurgeTrash()
+		if err != nil {
+			log.Println("Purging trash failed:", err)
+		} else if purged > 0 {
+			log.Printf("Purged %v recipes from trash", purged)
+		}
+	})
+}
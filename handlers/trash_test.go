@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/TranQuocToan1996/ginProject/blobstore"
+	"github.com/TranQuocToan1996/ginProject/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestPurgeTrash(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+	for i, tt := range []struct {
+		hookErr error
+		purged  int64
+		deletes int
+	}{
+		{nil, 1, 2},
+		{errors.New("reviews unavailable"), 0, 0},
+	} {
+		mt.Run(fmt.Sprintf("%v", i), func(mt *mtest.T) {
+			handler := &RecipesHandler{ctx: context.Background(), collection: mt.Coll, revisions: mt.Coll, retention: time.Hour}
+			recipeID := primitive.NewObjectID()
+			mt.AddMockResponses(
+				found(mt, recipeDoc(recipeID, 1)),
+				mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}),
+				mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 3}),
+			)
+			var hooked []models.Recipe
+			handler.AddPurgeHook(func(recipes []models.Recipe) error {
+				hooked = recipes
+				return tt.hookErr
+			})
+
+			purged, err := handler.PurgeTrash()
+			if (err != nil) != (tt.hookErr != nil) || purged != tt.purged {
+				mt.Errorf("want %v purged, error %v; got %v %v", tt.purged, tt.hookErr, purged, err)
+			}
+			if len(hooked) != 1 || hooked[0].ID != recipeID {
+				mt.Errorf("want the hook run on the expired recipe; got %v", hooked)
+			}
+			deletes := 0
+			for _, event := range mt.GetAllStartedEvents() {
+				if event.CommandName == "delete" {
+					deletes++
+				}
+			}
+			if deletes != tt.deletes {
+				mt.Errorf("want %v deletes; got %v", tt.deletes, deletes)
+			}
+		})
+	}
+}
+
+func TestPurgeRecipeImages(t *testing.T) {
+	store, err := blobstore.NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	// The large variant is already gone, which doesn't fail the purge
+	keys := []string{"recipes/1/a/thumbnail.jpg", "recipes/1/a/large.jpg"}
+	if err := store.Put(ctx, keys[0], "image/jpeg", []byte("jpg")); err != nil {
+		t.Fatal(err)
+	}
+	handler := NewImagesHandler(ctx, nil, nil, store)
+	recipes := []models.Recipe{{Images: []models.RecipeImage{{Variants: map[string]models.ImageVariant{
+		"thumbnail": {URL: "/images/" + keys[0]},
+		"large":     {URL: "/images/" + keys[1]},
+	}}}}}
+	if err := handler.PurgeRecipes(recipes); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := store.Open(ctx, keys[0]); err != blobstore.ErrNotFound {
+		t.Errorf("want the variant deleted; got %v", err)
+	}
+}
@@ -0,0 +1,106 @@
+// Package history snapshots the content of recipes and compares snapshots
+package history
+
+import (
+	"strings"
+
+	"github.com/TranQuocToan1996/ginProject/models"
+)
+
+// Actions recorded in revisions
+const (
+	Create    = "create"
+	Original  = "original"
+	Update    = "update"
+	Patch     = "patch"
+	Steps     = "steps"
+	Normalize = "normalize"
+	Revert    = "revert"
+)
+
+// Snapshot returns the content of recipe
+func Snapshot(recipe models.Recipe) models.RecipeContent {
+	return models.RecipeContent{
+		Name:         recipe.Name,
+		Tags:         recipe.Tags,
+		Ingredients:  recipe.Ingredients,
+		Instructions: recipe.Instructions,
+		Servings:     recipe.Servings,
+	}
+}
+
+// Apply copies content into recipe
+func Apply(recipe *models.Recipe, content models.RecipeContent) {
+	recipe.Name = content.Name
+	recipe.Tags = content.Tags
+	recipe.Ingredients = content.Ingredients
+	recipe.Instructions = content.Instructions
+	recipe.Servings = content.Servings
+}
+
+// Diff returns the fields that differ between two snapshots, in a fixed
+// order. Lists are compared as multisets of lines, so reordering is a change
+// only for instructions, whose order matters, reported with the full lists.
+func Diff(before, after models.RecipeContent) []models.FieldChange {
+	changes := make([]models.FieldChange, 0)
+	if before.Name != after.Name {
+		changes = append(changes, models.FieldChange{Field: "name", Before: before.Name, After: after.Name})
+	}
+	if before.Servings != after.Servings {
+		changes = append(changes, models.FieldChange{Field: "servings", Before: before.Servings, After: after.Servings})
+	}
+	if change, ok := diffList("tags", before.Tags, after.Tags); ok {
+		changes = append(changes, change)
+	}
+	if change, ok := diffList("ingredients", before.Ingredients, after.Ingredients); ok {
+		changes = append(changes, change)
+	}
+	beforeSteps, afterSteps := steps(before.Instructions), steps(after.Instructions)
+	if change, ok := diffList("instructions", beforeSteps, afterSteps); ok {
+		changes = append(changes, change)
+	} else if strings.Join(beforeSteps, "\n") != strings.Join(afterSteps, "\n") {
+		// Same steps in another order
+		changes = append(changes, models.FieldChange{Field: "instructions", Before: beforeSteps, After: afterSteps})
+	}
+	return changes
+}
+
+// diffList returns the elements added to and removed from a list
+func diffList(field string, before, after []string) (models.FieldChange, bool) {
+	count := make(map[string]int)
+	for _, v := range before {
+		count[v]++
+	}
+	added := make([]string, 0)
+	for _, v := range after {
+		if count[v] > 0 {
+			count[v]--
+		} else {
+			added = append(added, v)
+		}
+	}
+	removed := make([]string, 0)
+	for _, v := range before {
+		if count[v] > 0 {
+			count[v]--
+			removed = append(removed, v)
+		}
+	}
+	if len(added) == 0 && len(removed) == 0 {
+		return models.FieldChange{}, false
+	}
+	return models.FieldChange{Field: field, Added: added, Removed: removed}, true
+}
+
+// steps renders instructions as lines, prefixed by their section
+func steps(instructions []models.Instruction) []string {
+	lines := make([]string, 0, len(instructions))
+	for _, step := range instructions {
+		line := step.Text
+		if step.Section != "" {
+			line = step.Section + ": " + line
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
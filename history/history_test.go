@@ -0,0 +1,54 @@
+package history
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/TranQuocToan1996/ginProject/models"
+)
+
+func TestDiff(t *testing.T) {
+	base := models.RecipeContent{
+		Name:         "Pancakes",
+		Tags:         []string{"breakfast"},
+		Ingredients:  []string{"1 cup flour", "1 egg", "1 cup milk"},
+		Instructions: []models.Instruction{{Step: 1, Text: "Mix"}, {Step: 2, Text: "Cook"}},
+		Servings:     2,
+	}
+	for i, tt := range []struct {
+		edit func(c *models.RecipeContent)
+		want []models.FieldChange
+	}{
+		{func(c *models.RecipeContent) {}, []models.FieldChange{}},
+		{func(c *models.RecipeContent) { c.Name, c.Servings = "Crepes", 4 }, []models.FieldChange{
+			{Field: "name", Before: "Pancakes", After: "Crepes"},
+			{Field: "servings", Before: 2, After: 4},
+		}},
+		{func(c *models.RecipeContent) {
+			c.Ingredients = []string{"1 egg", "1 cup flour", "2 cups milk"}
+			c.Tags = []string{"breakfast", "sweet"}
+		}, []models.FieldChange{
+			{Field: "tags", Added: []string{"sweet"}, Removed: []string{}},
+			{Field: "ingredients", Added: []string{"2 cups milk"}, Removed: []string{"1 cup milk"}},
+		}},
+		{func(c *models.RecipeContent) {
+			c.Instructions = []models.Instruction{{Step: 1, Text: "Cook"}, {Step: 2, Text: "Mix"}}
+		}, []models.FieldChange{
+			{Field: "instructions", Before: []string{"Mix", "Cook"}, After: []string{"Cook", "Mix"}},
+		}},
+		{func(c *models.RecipeContent) {
+			c.Instructions = []models.Instruction{{Step: 1, Text: "Mix"}, {Step: 2, Section: "Pan", Text: "Cook"}}
+		}, []models.FieldChange{
+			{Field: "instructions", Added: []string{"Pan: Cook"}, Removed: []string{"Cook"}},
+		}},
+	} {
+		t.Run(fmt.Sprintf("%v", i), func(t *testing.T) {
+			after := base
+			tt.edit(&after)
+			if result := Diff(base, after); !reflect.DeepEqual(result, tt.want) {
+				t.Errorf("want %+v; got %+v", tt.want, result)
+			}
+		})
+	}
+}
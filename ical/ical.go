@@ -0,0 +1,111 @@
+// Package ical writes iCalendar (RFC 5545) feeds
+package ical
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+const (
+	dateTimeFormat = "20060102T150405"
+	// Content lines longer than this many octets are folded
+	maxLineLength = 75
+)
+
+// Event is a single VEVENT. Start and End are written as floating local
+// times, so they show at the same hour whatever the reader's time zone.
+type Event struct {
+	UID         string
+	Start       time.Time
+	End         time.Time
+	Summary     string
+	Description string
+	URL         string
+}
+
+// Calendar is a VCALENDAR holding events
+type Calendar struct {
+	ProdID string
+	Name   string
+	Events []Event
+}
+
+// WriteTo writes the calendar to w with CRLF line endings
+func (cal *Calendar) WriteTo(w io.Writer) (int64, error) {
+	var b strings.Builder
+	line := func(name, value string) {
+		b.WriteString(fold(name + ":" + value))
+		b.WriteString("\r\n")
+	}
+
+	stamp := time.Now().UTC().Format(dateTimeFormat) + "Z"
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", escape(cal.ProdID))
+	line("CALSCALE", "GREGORIAN")
+	if cal.Name != "" {
+		line("X-WR-CALNAME", escape(cal.Name))
+	}
+	for _, event := range cal.Events {
+		line("BEGIN", "VEVENT")
+		line("UID", escape(event.UID))
+		line("DTSTAMP", stamp)
+		line("DTSTART", event.Start.Format(dateTimeFormat))
+		line("DTEND", event.End.Format(dateTimeFormat))
+		line("SUMMARY", escape(event.Summary))
+		if event.Description != "" {
+			line("DESCRIPTION", escape(event.Description))
+		}
+		if event.URL != "" {
+			line("URL", event.URL)
+		}
+		line("END", "VEVENT")
+	}
+	line("END", "VCALENDAR")
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// escape escapes a TEXT value
+func escape(s string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+	).Replace(s)
+}
+
+// fold splits a content line into lines of at most maxLineLength octets,
+// continued lines starting with a space. Multi-byte characters aren't split.
+func fold(s string) string {
+	if len(s) <= maxLineLength {
+		return s
+	}
+	var b strings.Builder
+	size := 0
+	for _, r := range s {
+		n := len(string(r))
+		if size+n > maxLineLength {
+			b.WriteString("\r\n ")
+			// The leading space counts towards the length of the next line
+			size = 1
+		}
+		b.WriteRune(r)
+		size += n
+	}
+	return b.String()
+}
+
+// UID joins parts into a unique identifier for Event.UID
+func UID(domain string, parts ...interface{}) string {
+	values := make([]string, len(parts))
+	for i, part := range parts {
+		values[i] = fmt.Sprint(part)
+	}
+	return strings.Join(values, "-") + "@" + domain
+}
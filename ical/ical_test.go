@@ -0,0 +1,74 @@
+package ical
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEscape(t *testing.T) {
+	for i, tt := range []struct {
+		in  string
+		out string
+	}{
+		{"Pasta", "Pasta"},
+		{"Salt, pepper; oil", `Salt\, pepper\; oil`},
+		{"line\nbreak", `line\nbreak`},
+		{`back\slash`, `back\\slash`},
+	} {
+		t.Run(fmt.Sprintf("%v", i), func(t *testing.T) {
+			if result := escape(tt.in); result != tt.out {
+				t.Errorf("want %q; got %q", tt.out, result)
+			}
+		})
+	}
+}
+
+func TestFold(t *testing.T) {
+	for i, tt := range []struct {
+		in  string
+		out string
+	}{
+		{"SUMMARY:short", "SUMMARY:short"},
+		{strings.Repeat("a", 80), strings.Repeat("a", 75) + "\r\n " + strings.Repeat("a", 5)},
+		{strings.Repeat("a", 74) + "é", strings.Repeat("a", 74) + "\r\n é"},
+	} {
+		t.Run(fmt.Sprintf("%v", i), func(t *testing.T) {
+			if result := fold(tt.in); result != tt.out {
+				t.Errorf("want %q; got %q", tt.out, result)
+			}
+		})
+	}
+}
+
+func TestWriteTo(t *testing.T) {
+	start := time.Date(2026, 10, 19, 19, 0, 0, 0, time.UTC)
+	cal := Calendar{
+		ProdID: "-//recipes//EN",
+		Name:   "Meals",
+		Events: []Event{{
+			UID:     UID("recipes", "plan", 1),
+			Start:   start,
+			End:     start.Add(time.Hour),
+			Summary: "Dinner: Pasta, 4 servings",
+		}},
+	}
+	var b strings.Builder
+	if _, err := cal.WriteTo(&b); err != nil {
+		t.Fatal(err)
+	}
+	out := b.String()
+	for _, want := range []string{
+		"BEGIN:VCALENDAR\r\nVERSION:2.0\r\n",
+		"UID:plan-1@recipes\r\n",
+		"DTSTART:20261019T190000\r\n",
+		"DTEND:20261019T200000\r\n",
+		"SUMMARY:Dinner: Pasta\\, 4 servings\r\n",
+		"END:VEVENT\r\nEND:VCALENDAR\r\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("want %q in %q", want, out)
+		}
+	}
+}
@@ -0,0 +1,277 @@
+package ingredients
+
+import (
+	"errors"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/TranQuocToan1996/ginProject/units"
+)
+
+// ErrNoQuantity is returned by Parse when the line does not start with a quantity
+var ErrNoQuantity = errors.New("ingredients: no leading quantity")
+
+// Seed data uses both the ASCII slash and the unicode fraction slash (U+2044)
+var fractionSlash = strings.NewReplacer("⁄", "/")
+
+var vulgarFractions = map[rune]float64{
+	'½': 1.0 / 2, '⅓': 1.0 / 3, '⅔': 2.0 / 3, '¼': 1.0 / 4, '¾': 3.0 / 4,
+	'⅛': 1.0 / 8, '⅜': 3.0 / 8, '⅝': 5.0 / 8, '⅞': 7.0 / 8,
+}
+
+const number = `(\d+\s+\d+/\d+|\d+/\d+|\d*\.\d+|\d+\s*[½⅓⅔¼¾⅛⅜⅝⅞]|\d+|[½⅓⅔¼¾⅛⅜⅝⅞])`
+
+// leadingQuantity matches "2", "1 1/2", "½", "2 to 3", "2-3" at the start of a line.
+// The quantity has to be followed by a space or the end of the line so "2-inch"
+// is not read as a quantity.
+var leadingQuantity = regexp.MustCompile(`^` + number + `(?:\s*(-|–|to|or)\s*` + number + `)?(\s+|$)`)
+
+// Ingredient is an ingredient line split into its leading quantity, an optional
+// unit of measure and the name of the ingredient
+type Ingredient struct {
+	Raw         string  `json:"raw"`
+	Quantity    float64 `json:"quantity"`
+	QuantityMax float64 `json:"quantityMax,omitempty"`
+	Unit        string  `json:"unit,omitempty"`
+	Name        string  `json:"name"`
+	Rest        string  `json:"rest"`
+
+	rangeSep string
+	unit     *units.Unit
+}
+
+// Parse reads the leading quantity (or range of quantities) of an ingredient line
+func Parse(line string) (Ingredient, error) {
+	raw := strings.TrimSpace(line)
+	text := fractionSlash.Replace(raw)
+	m := leadingQuantity.FindStringSubmatch(text)
+	if m == nil {
+		return Ingredient{Raw: raw}, ErrNoQuantity
+	}
+	quantity, err := ParseQuantity(m[1])
+	if err != nil {
+		return Ingredient{Raw: raw}, err
+	}
+	ingredient := Ingredient{
+		Raw:      raw,
+		Quantity: quantity,
+		Rest:     strings.TrimSpace(text[len(m[0]):]),
+	}
+	if m[3] != "" {
+		ingredient.QuantityMax, err = ParseQuantity(m[3])
+		if err != nil {
+			return Ingredient{Raw: raw}, err
+		}
+		ingredient.rangeSep = m[2]
+	}
+	ingredient.unit, ingredient.Name = parseUnit(ingredient.Rest)
+	if ingredient.unit != nil {
+		ingredient.Unit = ingredient.unit.Name
+	}
+	return ingredient, nil
+}
+
+// parseUnit splits "tablespoon olive oil" into the unit and the ingredient name
+func parseUnit(rest string) (*units.Unit, string) {
+	fields := strings.Fields(rest)
+	for n := 2; n >= 1; n-- {
+		if len(fields) < n {
+			continue
+		}
+		name := strings.TrimRight(strings.Join(fields[:n], " "), ",")
+		if u, ok := units.Lookup(name); ok && u.Kind != units.Temperature {
+			return &u, strings.Join(fields[n:], " ")
+		}
+	}
+	return nil, rest
+}
+
+// ParseQuantity converts "3", "1.5", "3/4", "1 1/2" or "1½" into a number
+func ParseQuantity(s string) (float64, error) {
+	s = strings.TrimSpace(fractionSlash.Replace(s))
+	if s == "" {
+		return 0, ErrNoQuantity
+	}
+	total := 0.0
+	for _, field := range strings.Fields(s) {
+		runes := []rune(field)
+		if v, ok := vulgarFractions[runes[len(runes)-1]]; ok {
+			total += v
+			field = string(runes[:len(runes)-1])
+			if field == "" {
+				continue
+			}
+		}
+		if num, den, ok := strings.Cut(field, "/"); ok {
+			n, err := strconv.ParseFloat(num, 64)
+			if err != nil {
+				return 0, err
+			}
+			d, err := strconv.ParseFloat(den, 64)
+			if err != nil {
+				return 0, err
+			}
+			if d == 0 {
+				return 0, errors.New("ingredients: zero denominator")
+			}
+			total += n / d
+			continue
+		}
+		v, err := strconv.ParseFloat(field, 64)
+		if err != nil {
+			return 0, err
+		}
+		total += v
+	}
+	return total, nil
+}
+
+// Scale renders the ingredient line with its quantity multiplied by factor
+func (i Ingredient) Scale(factor float64) string {
+	quantity := FormatQuantity(i.Quantity * factor)
+	if i.QuantityMax > 0 {
+		sep := i.rangeSep
+		if sep == "to" || sep == "or" {
+			sep = " " + sep + " "
+		}
+		quantity += sep + FormatQuantity(i.QuantityMax*factor)
+	}
+	if i.Rest == "" {
+		return quantity
+	}
+	return quantity + " " + i.Rest
+}
+
+// Convert renders the ingredient line in system. Volumes of dry ingredients with
+// a known density become weights in metric. Lines without a unit, or already
+// measured in system, are returned unchanged.
+func (i Ingredient) Convert(system units.System) string {
+	if system == "" || i.unit == nil || i.unit.System == system {
+		return i.Raw
+	}
+	// Ranges from zero, such as "0-2 cups", are converted by their upper bound
+	base := i.Quantity
+	if base == 0 {
+		base = i.QuantityMax
+	}
+	if base == 0 {
+		return i.Raw
+	}
+	from, quantity := *i.unit, base
+	if system == units.Metric {
+		if grams, ok := units.VolumeToWeight(quantity, from, i.Name, false); ok {
+			from, quantity = units.Gram, grams
+		}
+	}
+	converted, to := units.Best(quantity, from, system)
+	factor := converted / base
+
+	// Converted US measures are rounded to the nearest eighth so 250 ml reads
+	// as 1 cup rather than 1.06 cups
+	format := func(q float64) string {
+		if q > 0 {
+			q = math.Max(math.Round(q*8)/8, 1.0/8)
+		}
+		return FormatQuantity(q)
+	}
+	if system == units.Metric {
+		format = func(q float64) string {
+			return strconv.FormatFloat(units.Round(q, to), 'f', -1, 64)
+		}
+	}
+	line := format(i.Quantity * factor)
+	if i.QuantityMax > 0 {
+		sep := i.rangeSep
+		if sep == "to" || sep == "or" {
+			sep = " " + sep + " "
+		}
+		line += sep + format(i.QuantityMax*factor)
+	}
+	line += " " + to.Name
+	if i.Name != "" {
+		line += " " + i.Name
+	}
+	return line
+}
+
+// Fractions cooks actually measure with, tried in this order
+var denominators = []float64{2, 3, 4, 8}
+
+const fractionTolerance = 0.02
+
+// FormatQuantity renders a quantity as a whole number, a fraction ("1/2") or a
+// mixed number ("1 1/3"). Quantities that don't land near a kitchen fraction are
+// rendered as decimals rounded to two places, or to two significant digits
+// when smaller than a fraction, so that they never read "0".
+func FormatQuantity(q float64) string {
+	if q <= 0 {
+		return "0"
+	}
+	if q < fractionTolerance {
+		scale := math.Pow(10, 1-math.Floor(math.Log10(q)))
+		return strconv.FormatFloat(math.Round(q*scale)/scale, 'f', -1, 64)
+	}
+	whole := math.Floor(q)
+	frac := q - whole
+	if frac < fractionTolerance {
+		return strconv.FormatFloat(whole, 'f', -1, 64)
+	}
+	if 1-frac < fractionTolerance {
+		return strconv.FormatFloat(whole+1, 'f', -1, 64)
+	}
+	for _, den := range denominators {
+		num := math.Round(frac * den)
+		if num == 0 || num == den || math.Abs(frac-num/den) >= fractionTolerance {
+			continue
+		}
+		fraction := strconv.Itoa(int(num)) + "/" + strconv.Itoa(int(den))
+		if whole == 0 {
+			return fraction
+		}
+		return strconv.FormatFloat(whole, 'f', -1, 64) + " " + fraction
+	}
+	return strconv.FormatFloat(math.Round(q*100)/100, 'f', -1, 64)
+}
+
+// IsSeparator reports whether the line is a section separator ("<hr>") or blank
+// rather than an ingredient
+func IsSeparator(line string) bool {
+	line = strings.TrimSpace(line)
+	return line == "" || strings.EqualFold(line, "<hr>")
+}
+
+var nonLetters = regexp.MustCompile(`[^a-z]+`)
+
+var parenthetical = regexp.MustCompile(`\([^)]*\)`)
+
+// NormalizeName reduces an ingredient name to lowercase singular words so that
+// "Eggs", "egg" and "large eggs (room temperature)" can be compared. Anything
+// after the first comma, usually preparation ("1 lemon, juiced"), is dropped.
+func NormalizeName(name string) string {
+	name = strings.ToLower(parenthetical.ReplaceAllString(name, " "))
+	name, _, _ = strings.Cut(name, ",")
+	words := strings.Fields(nonLetters.ReplaceAllString(name, " "))
+	for i, word := range words {
+		words[i] = singular(word)
+	}
+	return strings.Join(words, " ")
+}
+
+// Words that already are singular despite their trailing s
+var invariant = map[string]bool{"molasses": true, "brussels": true, "grits": true, "greens": true}
+
+func singular(word string) string {
+	switch {
+	case invariant[word], len(word) <= 3 || strings.HasSuffix(word, "ss") || strings.HasSuffix(word, "us"):
+		return word
+	case strings.HasSuffix(word, "ies"):
+		return strings.TrimSuffix(word, "ies") + "y"
+	case strings.HasSuffix(word, "oes"), strings.HasSuffix(word, "ches"), strings.HasSuffix(word, "shes"):
+		return strings.TrimSuffix(word, "es")
+	case strings.HasSuffix(word, "s"):
+		return strings.TrimSuffix(word, "s")
+	}
+	return word
+}
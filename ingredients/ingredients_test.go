@@ -0,0 +1,124 @@
+package ingredients
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/TranQuocToan1996/ginProject/units"
+)
+
+func TestFormatQuantity(t *testing.T) {
+	for i, tt := range []struct {
+		in  float64
+		out string
+	}{
+		{0.5, "1/2"},
+		{1.0 / 3, "1/3"},
+		{4.0 / 3, "1 1/3"},
+		{0.375, "3/8"},
+		{2, "2"},
+		{2.999, "3"},
+		{0.3, "0.3"},
+		{1.15, "1.15"},
+		{0.0125, "0.013"},
+		{1.0 / 64, "0.016"},
+		{0.002, "0.002"},
+	} {
+		t.Run(fmt.Sprintf("%v", i), func(t *testing.T) {
+			if result := FormatQuantity(tt.in); result != tt.out {
+				t.Errorf("want %v; got %v", tt.out, result)
+			}
+		})
+	}
+}
+
+func TestScale(t *testing.T) {
+	for i, tt := range []struct {
+		line   string
+		factor float64
+		out    string
+	}{
+		{"1/2 tsp salt\r", 2, "1 tsp salt"},
+		{"3 1/2 cup all-purpose flour", 0.5, "1 3/4 cup all-purpose flour"},
+		{"4 (6 to 7-ounce) boneless skinless chicken breasts", 0.5, "2 (6 to 7-ounce) boneless skinless chicken breasts"},
+		{"1 1⁄2 cups powdered sugar", 2, "3 cups powdered sugar"},
+		{"2 to 3 cloves garlic", 2, "4 to 6 cloves garlic"},
+		{"2-3 carrots", 1.5, "3-4 1/2 carrots"},
+		{"½ cup milk", 2, "1 cup milk"},
+		{"1 lemon, juiced", 1.0 / 3, "1/3 lemon, juiced"},
+	} {
+		t.Run(fmt.Sprintf("%v", i), func(t *testing.T) {
+			ingredient, err := Parse(tt.line)
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if result := ingredient.Scale(tt.factor); result != tt.out {
+				t.Errorf("want %v; got %v", tt.out, result)
+			}
+		})
+	}
+}
+
+func TestParseNoQuantity(t *testing.T) {
+	for i, line := range []string{
+		"Coarse salt and ground pepper",
+		"2-inch piece fresh ginger",
+		"<hr>",
+	} {
+		t.Run(fmt.Sprintf("%v", i), func(t *testing.T) {
+			if _, err := Parse(line); err != ErrNoQuantity {
+				t.Errorf("want %v; got %v", ErrNoQuantity, err)
+			}
+		})
+	}
+}
+
+func TestConvert(t *testing.T) {
+	for i, tt := range []struct {
+		line   string
+		system units.System
+		out    string
+	}{
+		{"1 cup all-purpose flour", units.Metric, "125 g all-purpose flour"},
+		{"2 cup chicken broth", units.Metric, "473 ml chicken broth"},
+		{"30 oz frozen peas", units.Metric, "850 g frozen peas"},
+		{"2 tablespoon extra-virgin olive oil", units.Metric, "30 ml extra-virgin olive oil"},
+		{"250 ml milk", units.US, "1 cup milk"},
+		{"500 g potatoes", units.US, "1 1/8 lb potatoes"},
+		{"1 lemon, juiced", units.Metric, "1 lemon, juiced"},
+		{"1/2 tsp salt", units.US, "1/2 tsp salt"},
+		{"0-2 cups chicken broth", units.Metric, "0-473 ml chicken broth"},
+		{"0-500 ml milk", units.US, "0-2 1/8 cup milk"},
+	} {
+		t.Run(fmt.Sprintf("%v", i), func(t *testing.T) {
+			ingredient, err := Parse(tt.line)
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if result := ingredient.Convert(tt.system); result != tt.out {
+				t.Errorf("want %v; got %v", tt.out, result)
+			}
+		})
+	}
+}
+
+func TestNormalizeName(t *testing.T) {
+	for i, tt := range []struct {
+		in  string
+		out string
+	}{
+		{"Eggs", "egg"},
+		{"large eggs (room temperature)", "large egg"},
+		{"grape tomatoes, halved", "grape tomato"},
+		{"fresh raspberries", "fresh raspberry"},
+		{"Confectioners' sugar", "confectioner sugar"},
+		{"radishes", "radish"},
+		{"molasses", "molasses"},
+	} {
+		t.Run(fmt.Sprintf("%v", i), func(t *testing.T) {
+			if result := NormalizeName(tt.in); result != tt.out {
+				t.Errorf("want %v; got %v", tt.out, result)
+			}
+		})
+	}
+}
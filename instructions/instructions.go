@@ -0,0 +1,118 @@
+package instructions
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/TranQuocToan1996/ginProject/ingredients"
+	"github.com/TranQuocToan1996/ginProject/models"
+)
+
+const maxHeadingWords = 6
+
+// paragraphBreak separates a section heading from the end of the previous step
+// in the seed data, e.g. "\r\n\r\nTo cook the chicken: Heat a nonstick skillet"
+var paragraphBreak = regexp.MustCompile(`\n\s*\n`)
+
+// heading matches "To cook the chicken:" at the start of a step
+var heading = regexp.MustCompile(`(?s)^([A-Z*_][^:.\d]*?):\s*(.*)$`)
+
+var duration = regexp.MustCompile(`(?i)\b(\d+(?:\.\d+)?(?:\s+\d+/\d+)?|\d+/\d+|an?)(?:\s*(?:-|–|to|or)\s*(\d+(?:\.\d+)?))?\s+(seconds?|secs?|minutes?|mins?|hours?|hrs?)\b`)
+
+// Normalize cleans up steps split naively from free text: surrounding
+// whitespace and separator lines are removed, section headings such as
+// "To cook the chicken:" are moved into Section (and carried over to the
+// following steps), steps are numbered from 1 and their durations detected.
+func Normalize(steps []models.Instruction) []models.Instruction {
+	normalized := make([]models.Instruction, 0, len(steps))
+	section := ""
+	for _, step := range steps {
+		text := strings.ReplaceAll(step.Text, "\r\n", "\n")
+		for i, part := range paragraphBreak.Split(text, -1) {
+			part = strings.TrimSpace(strings.Trim(strings.TrimSpace(part), "-"))
+			if !hasWords(part) {
+				continue
+			}
+			if step.Section != "" && i == 0 {
+				section = step.Section
+			} else if i > 0 || len(normalized) == 0 || startsWithBreak(text) {
+				if m := heading.FindStringSubmatch(part); m != nil && len(strings.Fields(m[1])) <= maxHeadingWords {
+					section = strings.Trim(m[1], "*_ ")
+					part = m[2]
+				}
+			}
+			if part == "" {
+				continue
+			}
+			normalized = append(normalized, models.Instruction{
+				Section: section,
+				Text:    part,
+			})
+		}
+	}
+	return Renumber(normalized)
+}
+
+// Renumber numbers steps from 1 in their current order and refreshes their durations
+func Renumber(steps []models.Instruction) []models.Instruction {
+	for i := range steps {
+		steps[i].Step = i + 1
+		steps[i].Durations = DetectDurations(steps[i].Text)
+	}
+	return steps
+}
+
+// DetectDurations finds cooking times such as "30 minutes", "4 to 5 minutes"
+// or "an hour" in text
+func DetectDurations(text string) []models.Duration {
+	var durations []models.Duration
+	for _, m := range duration.FindAllStringSubmatch(text, -1) {
+		unit := unitSeconds(m[3])
+		amount := strings.ToLower(m[1])
+		min, err := 1.0, error(nil)
+		if amount != "a" && amount != "an" {
+			min, err = ingredients.ParseQuantity(amount)
+		} else if unit == 1 {
+			// "a second" is nearly always "a second batch", not a timer
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		max := min
+		if m[2] != "" {
+			if max, err = strconv.ParseFloat(m[2], 64); err != nil {
+				continue
+			}
+		}
+		durations = append(durations, models.Duration{
+			Text:       m[0],
+			MinSeconds: int(min * float64(unit)),
+			MaxSeconds: int(max * float64(unit)),
+		})
+	}
+	return durations
+}
+
+func unitSeconds(unit string) int {
+	switch strings.ToLower(unit[:1]) {
+	case "h":
+		return 3600
+	case "m":
+		return 60
+	default:
+		return 1
+	}
+}
+
+func startsWithBreak(text string) bool {
+	return strings.HasPrefix(strings.TrimLeft(text, " \t"), "\n")
+}
+
+// hasWords reports whether s is more than punctuation left over from splitting
+func hasWords(s string) bool {
+	return strings.IndexFunc(s, func(r rune) bool {
+		return r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z'
+	}) >= 0
+}
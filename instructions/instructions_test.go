@@ -0,0 +1,56 @@
+package instructions
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/TranQuocToan1996/ginProject/models"
+)
+
+func newDuration(text string, min, max int) models.Duration {
+	return models.Duration{Text: text, MinSeconds: min, MaxSeconds: max}
+}
+
+func TestNormalize(t *testing.T) {
+	raw := []models.Instruction{
+		{Text: "To marinate the chicken: In a non-reactive dish, combine the lemon juice, olive oil, oregano, salt, and pepper and mix together"},
+		{Text: " Cover the dish and let marinate in the refrigerator for at least 30 minutes and up to 4 hours"},
+		{Text: "\r\n\r\nTo cook the chicken: Heat a nonstick skillet or grill pan over high heat"},
+		{Text: " Add the chicken breasts and cook, about 4 to 5 minutes on each side"},
+		{Text: "---"},
+	}
+	want := []models.Instruction{
+		{Step: 1, Section: "To marinate the chicken", Text: "In a non-reactive dish, combine the lemon juice, olive oil, oregano, salt, and pepper and mix together"},
+		{Step: 2, Section: "To marinate the chicken", Text: "Cover the dish and let marinate in the refrigerator for at least 30 minutes and up to 4 hours",
+			Durations: []models.Duration{newDuration("30 minutes", 1800, 1800), newDuration("4 hours", 14400, 14400)}},
+		{Step: 3, Section: "To cook the chicken", Text: "Heat a nonstick skillet or grill pan over high heat"},
+		{Step: 4, Section: "To cook the chicken", Text: "Add the chicken breasts and cook, about 4 to 5 minutes on each side",
+			Durations: []models.Duration{newDuration("4 to 5 minutes", 240, 300)}},
+	}
+	result := Normalize(raw)
+	if !reflect.DeepEqual(result, want) {
+		t.Errorf("want %+v; got %+v", want, result)
+	}
+	if again := Normalize(result); !reflect.DeepEqual(again, want) {
+		t.Errorf("normalize is not idempotent: got %+v", again)
+	}
+}
+
+func TestDetectDurations(t *testing.T) {
+	for i, tt := range []struct {
+		in  string
+		out []models.Duration
+	}{
+		{"Simmer for 1-2 minutes", []models.Duration{newDuration("1-2 minutes", 60, 120)}},
+		{"Bake for an hour", []models.Duration{newDuration("an hour", 3600, 3600)}},
+		{"Rest 1 1/2 hours", []models.Duration{newDuration("1 1/2 hours", 5400, 5400)}},
+		{"Repeat with a second batch", nil},
+	} {
+		t.Run(fmt.Sprintf("%v", i), func(t *testing.T) {
+			if result := DetectDurations(tt.in); !reflect.DeepEqual(result, tt.out) {
+				t.Errorf("want %v; got %v", tt.out, result)
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+// Package mealplan handles the weeks, days and meal slots of meal plans
+package mealplan
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/TranQuocToan1996/ginProject/ical"
+	"github.com/TranQuocToan1996/ginProject/models"
+)
+
+// DateFormat is the format of MealPlan.WeekStart
+const DateFormat = "2006-01-02"
+
+var ErrInvalidWeek = errors.New("week must be a date like 2006-01-02 or an ISO week like 2006-W01")
+
+// Days are the days of a plan, in order from its Monday
+var Days = []string{"monday", "tuesday", "wednesday", "thursday", "friday", "saturday", "sunday"}
+
+// Slot is a meal of the day, scheduled at a default time in the calendar feed
+type Slot struct {
+	Name     string
+	Hour     int
+	Minute   int
+	Duration time.Duration
+}
+
+// Slots are the meal slots of a day, in order
+var Slots = []Slot{
+	{"breakfast", 8, 0, 30 * time.Minute},
+	{"lunch", 12, 30, time.Hour},
+	{"snack", 16, 0, 30 * time.Minute},
+	{"dinner", 19, 0, time.Hour},
+}
+
+// WeekStart returns the Monday of the week of t, at midnight
+func WeekStart(t time.Time) time.Time {
+	offset := (int(t.Weekday()) + 6) % 7
+	return time.Date(t.Year(), t.Month(), t.Day()-offset, 0, 0, 0, 0, time.UTC)
+}
+
+// ParseWeek parses a week given as any date in it, like 2026-10-21, or as an
+// ISO 8601 week, like 2026-W43, and returns its Monday
+func ParseWeek(s string) (time.Time, error) {
+	if t, err := time.Parse(DateFormat, s); err == nil {
+		return WeekStart(t), nil
+	}
+	var year, week int
+	if n, err := fmt.Sscanf(strings.ToUpper(s), "%4d-W%2d", &year, &week); err != nil || n != 2 {
+		return time.Time{}, ErrInvalidWeek
+	}
+	// January 4th is always in week 1
+	monday := WeekStart(time.Date(year, time.January, 4, 0, 0, 0, 0, time.UTC)).AddDate(0, 0, (week-1)*7)
+	if y, w := monday.ISOWeek(); y != year || w != week {
+		return time.Time{}, ErrInvalidWeek
+	}
+	return monday, nil
+}
+
+// DayIndex returns the position of day in Days, or -1
+func DayIndex(day string) int {
+	for i, d := range Days {
+		if d == day {
+			return i
+		}
+	}
+	return -1
+}
+
+// SlotIndex returns the position of the slot named name in Slots, or -1
+func SlotIndex(name string) int {
+	for i, s := range Slots {
+		if s.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// Sort orders entries by day then slot
+func Sort(entries []models.MealEntry) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if a.Day != b.Day {
+			return DayIndex(a.Day) < DayIndex(b.Day)
+		}
+		return SlotIndex(a.Slot) < SlotIndex(b.Slot)
+	})
+}
+
+// Set assigns entry to its day and slot, replacing the entry already there
+func Set(entries []models.MealEntry, entry models.MealEntry) []models.MealEntry {
+	result := Remove(entries, entry.Day, entry.Slot)
+	result = append(result, entry)
+	Sort(result)
+	return result
+}
+
+// Remove removes the entry of a day and slot
+func Remove(entries []models.MealEntry, day, slot string) []models.MealEntry {
+	result := make([]models.MealEntry, 0, len(entries))
+	for _, e := range entries {
+		if e.Day != day || e.Slot != slot {
+			result = append(result, e)
+		}
+	}
+	return result
+}
+
+// Calendar returns the events of a plan, one per entry
+func Calendar(plan models.MealPlan) (*ical.Calendar, error) {
+	monday, err := time.Parse(DateFormat, plan.WeekStart)
+	if err != nil {
+		return nil, err
+	}
+	cal := &ical.Calendar{
+		ProdID: "-//ginProject//Meal planner//EN",
+		Name:   "Meal plan " + plan.WeekStart,
+		Events: make([]ical.Event, 0, len(plan.Entries)),
+	}
+	for _, entry := range plan.Entries {
+		day, slot := DayIndex(entry.Day), SlotIndex(entry.Slot)
+		if day < 0 || slot < 0 {
+			continue
+		}
+		s := Slots[slot]
+		start := monday.AddDate(0, 0, day).Add(time.Duration(s.Hour)*time.Hour + time.Duration(s.Minute)*time.Minute)
+		cal.Events = append(cal.Events, ical.Event{
+			UID:         ical.UID("ginproject", plan.ID.Hex(), entry.Day, entry.Slot),
+			Start:       start,
+			End:         start.Add(s.Duration),
+			Summary:     fmt.Sprintf("%v: %v", strings.ToUpper(entry.Slot[:1])+entry.Slot[1:], entry.RecipeName),
+			Description: fmt.Sprintf("%v servings", entry.Servings),
+			URL:         "/recipes/search/" + entry.RecipeID.Hex(),
+		})
+	}
+	return cal, nil
+}
@@ -0,0 +1,78 @@
+package mealplan
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/TranQuocToan1996/ginProject/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestParseWeek(t *testing.T) {
+	for i, tt := range []struct {
+		in   string
+		want string
+		err  error
+	}{
+		{"2026-10-19", "2026-10-19", nil},
+		{"2026-10-25", "2026-10-19", nil},
+		{"2026-10-21", "2026-10-19", nil},
+		{"2026-W43", "2026-10-19", nil},
+		{"2026-w01", "2025-12-29", nil},
+		{"2020-W53", "2020-12-28", nil},
+		{"2025-W53", "", ErrInvalidWeek},
+		{"next week", "", ErrInvalidWeek},
+	} {
+		t.Run(fmt.Sprintf("%v", i), func(t *testing.T) {
+			result, err := ParseWeek(tt.in)
+			if err != tt.err {
+				t.Fatalf("want error %v; got %v", tt.err, err)
+			}
+			if err == nil && result.Format(DateFormat) != tt.want {
+				t.Errorf("want %v; got %v", tt.want, result.Format(DateFormat))
+			}
+		})
+	}
+}
+
+func TestSet(t *testing.T) {
+	entry := func(day, slot string, servings int) models.MealEntry {
+		return models.MealEntry{Day: day, Slot: slot, Servings: servings}
+	}
+	entries := []models.MealEntry{entry("tuesday", "dinner", 2), entry("monday", "lunch", 2)}
+	entries = Set(entries, entry("monday", "breakfast", 1))
+	entries = Set(entries, entry("tuesday", "dinner", 4))
+	want := []models.MealEntry{entry("monday", "breakfast", 1), entry("monday", "lunch", 2), entry("tuesday", "dinner", 4)}
+	if !reflect.DeepEqual(entries, want) {
+		t.Errorf("want %v; got %v", want, entries)
+	}
+	if entries = Remove(entries, "monday", "lunch"); len(entries) != 2 {
+		t.Errorf("want 2 entries; got %v", entries)
+	}
+}
+
+func TestCalendar(t *testing.T) {
+	plan := models.MealPlan{
+		ID:        primitive.NewObjectID(),
+		WeekStart: "2026-10-19",
+		Entries: []models.MealEntry{
+			{Day: "wednesday", Slot: "dinner", RecipeName: "Pasta", Servings: 4},
+			{Day: "someday", Slot: "dinner", RecipeName: "Ignored"},
+		},
+	}
+	cal, err := Calendar(plan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cal.Events) != 1 {
+		t.Fatalf("want 1 event; got %v", len(cal.Events))
+	}
+	event := cal.Events[0]
+	if got := event.Start.Format("2006-01-02 15:04"); got != "2026-10-21 19:00" {
+		t.Errorf("want start 2026-10-21 19:00; got %v", got)
+	}
+	if event.Summary != "Dinner: Pasta" {
+		t.Errorf("want summary Dinner: Pasta; got %v", event.Summary)
+	}
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Favorite records that a user favorited a recipe
+type Favorite struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id"`
+	UserName  string             `json:"username" bson:"username"`
+	RecipeID  primitive.ObjectID `json:"recipeId" bson:"recipeId"`
+	CreatedAt time.Time          `json:"createdAt" bson:"createdAt"`
+}
+
+// Collection is a named list of recipes kept by a user. A collection with a
+// share token can be read by anyone through its public link.
+type Collection struct {
+	ID         primitive.ObjectID   `json:"id" bson:"_id"`
+	Owner      string               `json:"owner" bson:"owner"`
+	Name       string               `json:"name" bson:"name"`
+	Recipes    []primitive.ObjectID `json:"recipes" bson:"recipes"`
+	ShareToken string               `json:"shareToken,omitempty" bson:"shareToken,omitempty"`
+	CreatedAt  time.Time            `json:"createdAt" bson:"createdAt"`
+	UpdatedAt  time.Time            `json:"updatedAt" bson:"updatedAt"`
+}
+
+// CollectionWithRecipes is a collection returned with its recipes loaded
+type CollectionWithRecipes struct {
+	Collection `bson:",inline"`
+	Items      []Recipe `json:"items" bson:"items"`
+}
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// RecipeImage is a picture of a recipe stored in several sizes
+type RecipeImage struct {
+	ID         string                  `json:"id" bson:"id"`
+	Variants   map[string]ImageVariant `json:"variants" bson:"variants"`
+	UploadedAt time.Time               `json:"uploadedAt" bson:"uploadedAt"`
+}
+
+// ImageVariant is one size of a recipe image
+type ImageVariant struct {
+	URL    string `json:"url" bson:"url"`
+	Width  int    `json:"width" bson:"width"`
+	Height int    `json:"height" bson:"height"`
+}
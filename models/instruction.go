@@ -0,0 +1,56 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/x/bsonx/bsoncore"
+)
+
+// Instruction is a single numbered step of a recipe
+type Instruction struct {
+	Step      int        `json:"step" bson:"step"`
+	Section   string     `json:"section,omitempty" bson:"section,omitempty" validate:"max=100"`
+	Text      string     `json:"text" bson:"text" validate:"notblank,max=2000"`
+	Durations []Duration `json:"durations,omitempty" bson:"durations,omitempty"`
+}
+
+// Duration is a cooking time found in an instruction such as "4 to 5 minutes"
+type Duration struct {
+	Text       string `json:"text" bson:"text"`
+	MinSeconds int    `json:"minSeconds" bson:"minSeconds"`
+	MaxSeconds int    `json:"maxSeconds" bson:"maxSeconds"`
+}
+
+// instruction has the fields of Instruction without its custom decoders
+type instruction Instruction
+
+// UnmarshalJSON accepts a step object as well as the plain strings used by the
+// seed data and older clients
+func (i *Instruction) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		*i = Instruction{Text: text}
+		return nil
+	}
+	return json.Unmarshal(data, (*instruction)(i))
+}
+
+// UnmarshalBSONValue reads recipes stored before instructions were structured,
+// where every step is a plain string
+func (i *Instruction) UnmarshalBSONValue(t bsontype.Type, data []byte) error {
+	if t == bsontype.String {
+		text, _, ok := bsoncore.ReadString(data)
+		if !ok {
+			return errors.New("models: invalid instruction string")
+		}
+		*i = Instruction{Text: text}
+		return nil
+	}
+	if t != bsontype.EmbeddedDocument {
+		return errors.New("models: instruction must be a string or a document")
+	}
+	return bson.Unmarshal(data, (*instruction)(i))
+}
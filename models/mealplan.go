@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MealPlan is the schedule of a user for the week starting on WeekStart, a
+// Monday formatted as 2006-01-02
+type MealPlan struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id"`
+	Owner     string             `json:"owner" bson:"owner"`
+	WeekStart string             `json:"weekStart" bson:"weekStart"`
+	Entries   []MealEntry        `json:"entries" bson:"entries"`
+	UpdatedAt time.Time          `json:"updatedAt" bson:"updatedAt"`
+}
+
+// MealEntry assigns a recipe to a meal slot of a day of the week
+type MealEntry struct {
+	Day        string             `json:"day" bson:"day"`
+	Slot       string             `json:"slot" bson:"slot"`
+	RecipeID   primitive.ObjectID `json:"recipeId" bson:"recipeId"`
+	RecipeName string             `json:"recipeName" bson:"recipeName"`
+	Servings   int                `json:"servings" bson:"servings"`
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// NutritionFacts are the nutrients of a whole recipe or of one serving.
+// Sodium is in milligrams, the other nutrients in grams.
+type NutritionFacts struct {
+	Calories      float64 `json:"calories" bson:"calories"`
+	Protein       float64 `json:"protein" bson:"protein"`
+	Fat           float64 `json:"fat" bson:"fat"`
+	Carbohydrates float64 `json:"carbohydrates" bson:"carbohydrates"`
+	Sodium        float64 `json:"sodium" bson:"sodium"`
+}
+
+// Nutrition is computed from the ingredients of a recipe and stored with it
+type Nutrition struct {
+	Total                NutritionFacts  `json:"total" bson:"total"`
+	PerServing           *NutritionFacts `json:"perServing,omitempty" bson:"perServing,omitempty"`
+	UnmatchedIngredients []string        `json:"unmatchedIngredients" bson:"unmatchedIngredients"`
+	ComputedAt           time.Time       `json:"computedAt" bson:"computedAt"`
+}
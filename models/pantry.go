@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// Pantry lists the ingredients a user has at home
+type Pantry struct {
+	Owner     string       `json:"owner" bson:"_id"`
+	Items     []PantryItem `json:"items" bson:"items"`
+	UpdatedAt time.Time    `json:"updatedAt" bson:"updatedAt"`
+}
+
+// PantryItem is an ingredient in a pantry. Quantity is free text such as
+// "2 cans", only shown back to the user.
+type PantryItem struct {
+	Name     string    `json:"name" bson:"name"`
+	Quantity string    `json:"quantity,omitempty" bson:"quantity,omitempty"`
+	AddedAt  time.Time `json:"addedAt" bson:"addedAt"`
+}
+
+// CookableRecipe is a recipe ranked by how much of it can be cooked from a pantry
+type CookableRecipe struct {
+	Recipe    Recipe   `json:"recipe"`
+	Available int      `json:"available"`
+	Total     int      `json:"total"`
+	Score     float64  `json:"score"`
+	Missing   []string `json:"missing"`
+}
@@ -7,10 +7,37 @@ import (
 )
 
 type Recipe struct {
-	ID           primitive.ObjectID `json:"id" bson:"_id"`
-	Name         string             `json:"name" bson:"name"`
-	Tags         []string           `json:"tags" bson:"tags"`
-	Ingredients  []string           `json:"ingredients" bson:"ingredients"`
-	Instructions []string           `json:"instructions" bson:"instructions"`
-	PublishedAt  time.Time          `json:"publishedAt" bson:"publishedAt"`
+	ID            primitive.ObjectID `json:"id" bson:"_id"`
+	Name          string             `json:"name" bson:"name" validate:"notblank,max=120"`
+	Tags          []string           `json:"tags" bson:"tags" validate:"max=20,unique,dive,tag,max=30"`
+	Ingredients   []string           `json:"ingredients" bson:"ingredients" validate:"min=1,max=100,dive,notblank,max=300"`
+	Instructions  []Instruction      `json:"instructions" bson:"instructions" validate:"max=100,dive"`
+	Servings      int                `json:"servings,omitempty" bson:"servings,omitempty" validate:"min=0,max=100"`
+	Nutrition     *Nutrition         `json:"nutrition,omitempty" bson:"nutrition,omitempty"`
+	DietLabels    []string           `json:"dietLabels" bson:"dietLabels"`
+	Allergens     []string           `json:"allergens" bson:"allergens"`
+	Images        []RecipeImage      `json:"images,omitempty" bson:"images,omitempty"`
+	Rating        RatingSummary      `json:"rating" bson:"rating"`
+	FavoriteCount int                `json:"favoriteCount" bson:"favoriteCount"`
+	Status        string             `json:"status" bson:"status,omitempty"`
+	Author        string             `json:"author,omitempty" bson:"author,omitempty"`
+	PublishAt     *time.Time         `json:"publishAt,omitempty" bson:"publishAt,omitempty"`
+	PublishedAt   time.Time          `json:"publishedAt" bson:"publishedAt"`
+	DeletedAt     *time.Time         `json:"deletedAt,omitempty" bson:"deletedAt,omitempty"`
+	DeletedBy     string             `json:"deletedBy,omitempty" bson:"deletedBy,omitempty"`
+	Version       int                `json:"version" bson:"version"`
+	ETag          string             `json:"etag,omitempty" bson:"-"`
+}
+
+// TrashedRecipe is a deleted recipe with the time it will be purged at
+type TrashedRecipe struct {
+	Recipe
+	PurgeAt time.Time `json:"purgeAt"`
+}
+
+// ScaledRecipe is a recipe with its ingredient quantities scaled to a number of servings
+type ScaledRecipe struct {
+	Recipe
+	ScaleFactor         float64  `json:"scaleFactor"`
+	UnscaledIngredients []string `json:"unscaledIngredients"`
 }
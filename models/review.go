@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Review is the rating and comment of a user on a recipe. Hidden reviews were
+// moderated and don't count towards the recipe rating.
+type Review struct {
+	ID             primitive.ObjectID `json:"id" bson:"_id"`
+	RecipeID       primitive.ObjectID `json:"recipeId" bson:"recipeId"`
+	UserName       string             `json:"username" bson:"username"`
+	Rating         int                `json:"rating" bson:"rating"`
+	Text           string             `json:"text" bson:"text"`
+	Hidden         bool               `json:"hidden,omitempty" bson:"hidden"`
+	ModerationNote string             `json:"moderationNote,omitempty" bson:"moderationNote,omitempty"`
+	CreatedAt      time.Time          `json:"createdAt" bson:"createdAt"`
+	UpdatedAt      time.Time          `json:"updatedAt" bson:"updatedAt"`
+}
+
+// RatingSummary aggregates the visible reviews of a recipe
+type RatingSummary struct {
+	Average float64 `json:"average" bson:"average"`
+	Count   int     `json:"count" bson:"count"`
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RecipeContent is the part of a recipe written by its authors, kept in every revision
+type RecipeContent struct {
+	Name         string        `json:"name" bson:"name"`
+	Tags         []string      `json:"tags" bson:"tags"`
+	Ingredients  []string      `json:"ingredients" bson:"ingredients"`
+	Instructions []Instruction `json:"instructions" bson:"instructions"`
+	Servings     int           `json:"servings,omitempty" bson:"servings,omitempty"`
+}
+
+// Revision is the content of a recipe after a change, numbered from 1 for
+// each recipe, with the changes from the previous revision
+type Revision struct {
+	ID         primitive.ObjectID `json:"id" bson:"_id"`
+	RecipeID   primitive.ObjectID `json:"recipeId" bson:"recipeId"`
+	Number     int                `json:"number" bson:"number"`
+	Action     string             `json:"action" bson:"action"`
+	Author     string             `json:"author" bson:"author"`
+	RevertedTo int                `json:"revertedTo,omitempty" bson:"revertedTo,omitempty"`
+	Content    RecipeContent      `json:"content" bson:"content"`
+	Changes    []FieldChange      `json:"changes" bson:"changes"`
+	CreatedAt  time.Time          `json:"createdAt" bson:"createdAt"`
+}
+
+// FieldChange describes how a field changed. Scalar fields have Before and
+// After, list fields have the Added and Removed elements.
+type FieldChange struct {
+	Field   string      `json:"field" bson:"field"`
+	Before  interface{} `json:"before,omitempty" bson:"before,omitempty"`
+	After   interface{} `json:"after,omitempty" bson:"after,omitempty"`
+	Added   []string    `json:"added,omitempty" bson:"added,omitempty"`
+	Removed []string    `json:"removed,omitempty" bson:"removed,omitempty"`
+}
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ShoppingList holds the ingredients of a set of recipes merged into items to buy
+type ShoppingList struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id"`
+	Owner     string             `json:"owner" bson:"owner"`
+	Name      string             `json:"name" bson:"name"`
+	Recipes   []string           `json:"recipes" bson:"recipes"`
+	Items     []ShoppingItem     `json:"items" bson:"items"`
+	CreatedAt time.Time          `json:"createdAt" bson:"createdAt"`
+	UpdatedAt time.Time          `json:"updatedAt" bson:"updatedAt"`
+}
+
+// ShoppingItem is an ingredient to buy. Quantity and Unit are empty for
+// ingredients without a measure, such as "salt to taste".
+type ShoppingItem struct {
+	ID       string   `json:"id" bson:"id"`
+	Name     string   `json:"name" bson:"name"`
+	Quantity float64  `json:"quantity,omitempty" bson:"quantity,omitempty"`
+	Unit     string   `json:"unit,omitempty" bson:"unit,omitempty"`
+	Display  string   `json:"display" bson:"display"`
+	Category string   `json:"category" bson:"category"`
+	Checked  bool     `json:"checked" bson:"checked"`
+	Recipes  []string `json:"recipes" bson:"recipes"`
+}
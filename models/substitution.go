@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Substitution replaces Unit of Ingredient (one item when Unit is empty) with
+// the quantities of Replacement. Except lists names containing the ingredient
+// that it doesn't apply to, like "peanut butter" for butter.
+type Substitution struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id"`
+	Ingredient  string             `json:"ingredient" bson:"ingredient" binding:"required"`
+	Except      []string           `json:"except,omitempty" bson:"except,omitempty"`
+	Unit        string             `json:"unit,omitempty" bson:"unit,omitempty"`
+	Replacement []SubstitutePart   `json:"replacement" bson:"replacement" binding:"required,min=1,dive"`
+	Notes       string             `json:"notes,omitempty" bson:"notes,omitempty"`
+	UpdatedAt   time.Time          `json:"updatedAt" bson:"updatedAt"`
+}
+
+// SubstitutePart is one ingredient of a replacement, Unit is empty for items
+type SubstitutePart struct {
+	Name     string  `json:"name" bson:"name" binding:"required"`
+	Quantity float64 `json:"quantity" bson:"quantity" binding:"gt=0"`
+	Unit     string  `json:"unit,omitempty" bson:"unit,omitempty"`
+}
+
+// SubstitutionSuggestion is a substitution applied to an ingredient line of a
+// recipe. Line holds the replacement with adjusted quantities when Adjusted
+// is set, and only the replacement names otherwise.
+type SubstitutionSuggestion struct {
+	SubstitutionID primitive.ObjectID `json:"substitutionId"`
+	Line           string             `json:"line"`
+	Adjusted       bool               `json:"adjusted"`
+	Notes          string             `json:"notes,omitempty"`
+	DietLabels     []string           `json:"dietLabels"`
+	Allergens      []string           `json:"allergens"`
+}
+
+// IngredientSubstitutions lists the suggestions for an ingredient line
+type IngredientSubstitutions struct {
+	Ingredient  string                   `json:"ingredient"`
+	Suggestions []SubstitutionSuggestion `json:"suggestions"`
+}
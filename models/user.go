@@ -4,4 +4,8 @@ type User struct {
 	Id       string `json:"id,omitempty" bson:"_id,omitempty"`
 	Name     string `json:"username" bson:"username"`
 	Password string `json:"password" bson:"password"`
+	Role     string `json:"role,omitempty" bson:"role,omitempty"`
 }
+
+// RoleAdmin is the role of users allowed to moderate content
+const RoleAdmin = "admin"
@@ -0,0 +1,230 @@
+package negotiate
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/ugorji/go/codec"
+	"gopkg.in/yaml.v2"
+)
+
+// toJSON converts a request body of format to JSON. XML has no types, so
+// its text is converted to the types of the fields of target.
+func toJSON(body []byte, format string, target reflect.Type) ([]byte, error) {
+	var value interface{}
+	switch format {
+	case MIMEXML:
+		node, err := decodeXML(body)
+		if err != nil {
+			return nil, err
+		}
+		value = node.value(target)
+	case MIMEYAML:
+		if err := yaml.Unmarshal(body, &value); err != nil {
+			return nil, err
+		}
+		value = jsonValue(value)
+	case MIMEMsgPack:
+		handle := &codec.MsgpackHandle{WriteExt: true}
+		handle.RawToString = true
+		if err := codec.NewDecoderBytes(body, handle).Decode(&value); err != nil {
+			return nil, err
+		}
+		value = jsonValue(value)
+	default:
+		return nil, fmt.Errorf("unknown format %v", format)
+	}
+	return json.Marshal(value)
+}
+
+// jsonValue converts the maps with interface{} keys YAML and MessagePack
+// decode to maps json.Marshal encodes
+func jsonValue(value interface{}) interface{} {
+	switch value := value.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(value))
+		for key, v := range value {
+			m[fmt.Sprint(key)] = jsonValue(v)
+		}
+		return m
+	case map[string]interface{}:
+		for key, v := range value {
+			value[key] = jsonValue(v)
+		}
+		return value
+	case []interface{}:
+		for i, v := range value {
+			value[i] = jsonValue(v)
+		}
+		return value
+	case []byte:
+		return string(value)
+	}
+	return value
+}
+
+// xmlNode is an element of an XML body
+type xmlNode struct {
+	name     string
+	key      string
+	text     string
+	children []*xmlNode
+}
+
+// decodeXML returns the root element of an XML document
+func decodeXML(body []byte) (*xmlNode, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(body))
+	var stack []*xmlNode
+	var root *xmlNode
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch token := token.(type) {
+		case xml.StartElement:
+			node := &xmlNode{name: token.Name.Local}
+			for _, attr := range token.Attr {
+				if attr.Name.Local == "key" {
+					node.key = attr.Value
+				}
+			}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, node)
+			} else if root == nil {
+				root = node
+			}
+			stack = append(stack, node)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text += string(token)
+			}
+		}
+	}
+	if root == nil {
+		return nil, fmt.Errorf("no root element")
+	}
+	return root, nil
+}
+
+// memberKey returns the key of the member an element is, as encodeXML names
+// them
+func (node *xmlNode) memberKey() string {
+	if node.name == "entry" && node.key != "" {
+		return node.key
+	}
+	return node.name
+}
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// value converts node to a JSON value of type t, or of the shape of node
+// when t is nil or an interface
+func (node *xmlNode) value(t reflect.Type) interface{} {
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	text := strings.TrimSpace(node.text)
+	if t == nil || t.Kind() == reflect.Interface {
+		return node.untyped()
+	}
+	if t.Implements(textUnmarshalerType) || reflect.PtrTo(t).Implements(textUnmarshalerType) {
+		return text
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		fields := jsonFields(t)
+		o := make(map[string]interface{}, len(node.children))
+		for _, child := range node.children {
+			key := child.memberKey()
+			o[key] = child.value(fields[key])
+		}
+		return o
+	case reflect.Map:
+		o := make(map[string]interface{}, len(node.children))
+		for _, child := range node.children {
+			o[child.memberKey()] = child.value(t.Elem())
+		}
+		return o
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return text
+		}
+		items := make([]interface{}, 0, len(node.children))
+		for _, child := range node.children {
+			items = append(items, child.value(t.Elem()))
+		}
+		return items
+	case reflect.Bool:
+		if b, err := strconv.ParseBool(text); err == nil {
+			return b
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		if _, err := strconv.ParseFloat(text, 64); err == nil {
+			return json.Number(text)
+		}
+	}
+	// the text of strings, and the values not of their type, which fail to
+	// decode as invalid fields
+	return node.text
+}
+
+// untyped converts node by its shape: text, a list of item elements or an
+// object
+func (node *xmlNode) untyped() interface{} {
+	if len(node.children) == 0 {
+		return node.text
+	}
+	items := make([]interface{}, 0, len(node.children))
+	o := make(map[string]interface{}, len(node.children))
+	for _, child := range node.children {
+		items = append(items, child.untyped())
+		o[child.memberKey()] = child.untyped()
+	}
+	if node.children[0].name == "item" {
+		return items
+	}
+	return o
+}
+
+// jsonFields returns the types of the fields of a struct by json name, the
+// fields of embedded structs included
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			for key, value := range jsonFields(f.Type) {
+				fields[key] = value
+			}
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[name] = f.Type
+	}
+	return fields
+}
@@ -0,0 +1,344 @@
+package negotiate
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+
+	"github.com/ugorji/go/codec"
+	"gopkg.in/yaml.v2"
+)
+
+// field is a member of an object
+type field struct {
+	key   string
+	value interface{}
+}
+
+// object is a JSON object keeping the order of its members, so that the
+// other formats list fields in the order of the JSON responses
+type object []field
+
+func (o object) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, f := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(f.key)
+		buf.Write(key)
+		buf.WriteByte(':')
+		value, err := json.Marshal(f.value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// decodeJSON decodes a JSON document into nil, bool, json.Number, string,
+// []interface{} and object values
+func decodeJSON(data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	value, err := decodeValue(decoder)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, fmt.Errorf("data after the JSON document")
+	}
+	return value, nil
+}
+
+func decodeValue(decoder *json.Decoder) (interface{}, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch token {
+	case json.Delim('{'):
+		o := make(object, 0)
+		for decoder.More() {
+			key, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeValue(decoder)
+			if err != nil {
+				return nil, err
+			}
+			o = append(o, field{key: key.(string), value: value})
+		}
+		_, err := decoder.Token()
+		return o, err
+	case json.Delim('['):
+		items := make([]interface{}, 0)
+		for decoder.More() {
+			value, err := decodeValue(decoder)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, value)
+		}
+		_, err := decoder.Token()
+		return items, err
+	}
+	return token, nil
+}
+
+// list returns the items of a list, or of the data of a v2 envelope
+func list(value interface{}) ([]interface{}, bool) {
+	switch value := value.(type) {
+	case []interface{}:
+		return value, true
+	case object:
+		for _, f := range value {
+			if f.key == "data" {
+				items, ok := f.value.([]interface{})
+				return items, ok
+			}
+		}
+	}
+	return nil, false
+}
+
+// render encodes a decoded JSON value in format, returning its content type.
+// Problem details are rendered as problem documents.
+func render(value interface{}, format string, problem bool) ([]byte, string, error) {
+	switch format {
+	case MIMEXML:
+		root := xml.StartElement{Name: xml.Name{Local: "response"}}
+		contentType := MIMEXML + "; charset=utf-8"
+		if problem {
+			root = xml.StartElement{Name: xml.Name{Space: "urn:ietf:rfc:7807", Local: "problem"}}
+			contentType = "application/problem+xml; charset=utf-8"
+		}
+		data, err := encodeXML(value, root)
+		return data, contentType, err
+	case MIMEYAML:
+		data, err := yaml.Marshal(yamlValue(value))
+		return data, MIMEYAML + "; charset=utf-8", err
+	case MIMEMsgPack:
+		var data []byte
+		err := codec.NewEncoderBytes(&data, &codec.MsgpackHandle{WriteExt: true}).Encode(plainValue(value))
+		return data, MIMEMsgPack, err
+	case MIMECSV:
+		items, _ := list(value)
+		data, err := encodeCSV(items)
+		return data, MIMECSV + "; charset=utf-8", err
+	}
+	return nil, "", fmt.Errorf("unknown format %v", format)
+}
+
+// encodeXML encodes value in root. Members of objects are elements named by
+// their key, or entry elements with a key attribute when the key isn't an XML
+// name, and the items of lists are item elements.
+func encodeXML(value interface{}, root xml.StartElement) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	encoder := xml.NewEncoder(&buf)
+	if err := writeXML(encoder, root, value); err != nil {
+		return nil, err
+	}
+	if err := encoder.Flush(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeXML(encoder *xml.Encoder, start xml.StartElement, value interface{}) error {
+	if err := encoder.EncodeToken(start); err != nil {
+		return err
+	}
+	switch value := value.(type) {
+	case object:
+		for _, f := range value {
+			if err := writeXML(encoder, element(f.key), f.value); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, item := range value {
+			if err := writeXML(encoder, xml.StartElement{Name: xml.Name{Local: "item"}}, item); err != nil {
+				return err
+			}
+		}
+	case nil:
+	default:
+		if err := encoder.EncodeToken(xml.CharData(scalar(value))); err != nil {
+			return err
+		}
+	}
+	return encoder.EncodeToken(start.End())
+}
+
+// element returns the element of a member of key
+func element(key string) xml.StartElement {
+	if isXMLName(key) {
+		return xml.StartElement{Name: xml.Name{Local: key}}
+	}
+	return xml.StartElement{
+		Name: xml.Name{Local: "entry"},
+		Attr: []xml.Attr{{Name: xml.Name{Local: "key"}, Value: key}},
+	}
+}
+
+// isXMLName reports whether name is a valid element name without namespace
+func isXMLName(name string) bool {
+	if name == "" || strings.HasPrefix(strings.ToLower(name), "xml") {
+		return false
+	}
+	for i, r := range name {
+		if unicode.IsLetter(r) || r == '_' {
+			continue
+		}
+		if i > 0 && (unicode.IsDigit(r) || r == '-' || r == '.') {
+			continue
+		}
+		return false
+	}
+	return true
+}
+
+// scalar returns the text of a scalar value
+func scalar(value interface{}) string {
+	switch value := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	case json.Number:
+		return value.String()
+	case bool:
+		if value {
+			return "true"
+		}
+		return "false"
+	}
+	data, _ := json.Marshal(value)
+	return string(data)
+}
+
+// number returns a json.Number as an int64 when it is one, as a float64
+// otherwise
+func number(n json.Number) interface{} {
+	if i, err := n.Int64(); err == nil {
+		return i
+	}
+	f, _ := n.Float64()
+	return f
+}
+
+// yamlValue converts a decoded JSON value for yaml.Marshal, objects keeping
+// their order
+func yamlValue(value interface{}) interface{} {
+	switch value := value.(type) {
+	case object:
+		slice := make(yaml.MapSlice, 0, len(value))
+		for _, f := range value {
+			slice = append(slice, yaml.MapItem{Key: f.key, Value: yamlValue(f.value)})
+		}
+		return slice
+	case []interface{}:
+		items := make([]interface{}, 0, len(value))
+		for _, item := range value {
+			items = append(items, yamlValue(item))
+		}
+		return items
+	case json.Number:
+		return number(value)
+	}
+	return value
+}
+
+// plainValue converts a decoded JSON value to maps, slices and scalars
+func plainValue(value interface{}) interface{} {
+	switch value := value.(type) {
+	case object:
+		m := make(map[string]interface{}, len(value))
+		for _, f := range value {
+			m[f.key] = plainValue(f.value)
+		}
+		return m
+	case []interface{}:
+		items := make([]interface{}, 0, len(value))
+		for _, item := range value {
+			items = append(items, plainValue(item))
+		}
+		return items
+	case json.Number:
+		return number(value)
+	}
+	return value
+}
+
+// formulaSafe prefixes text starting like a spreadsheet formula with a quote,
+// so that the cell is read as text
+func formulaSafe(text string) string {
+	if text != "" && strings.ContainsRune("=+-@\t\r", rune(text[0])) {
+		return "'" + text
+	}
+	return text
+}
+
+// encodeCSV encodes items as rows under a header of the keys of the objects
+// in the order they first appear. Nested objects and lists are JSON cells,
+// items that aren't objects are in a value column. Text that a spreadsheet
+// would run as a formula is escaped.
+func encodeCSV(items []interface{}) ([]byte, error) {
+	columns := make([]string, 0)
+	index := make(map[string]int)
+	for _, item := range items {
+		o, ok := item.(object)
+		if !ok {
+			o = object{{key: "value", value: item}}
+		}
+		for _, f := range o {
+			if _, ok := index[f.key]; !ok {
+				index[f.key] = len(columns)
+				columns = append(columns, f.key)
+			}
+		}
+	}
+
+	if len(columns) == 0 {
+		return []byte{}, nil
+	}
+
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	header := make([]string, len(columns))
+	for i, column := range columns {
+		header[i] = formulaSafe(column)
+	}
+	if err := writer.Write(header); err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		o, ok := item.(object)
+		if !ok {
+			o = object{{key: "value", value: item}}
+		}
+		row := make([]string, len(columns))
+		for _, f := range o {
+			row[index[f.key]] = scalar(f.value)
+			if _, ok := f.value.(string); ok {
+				row[index[f.key]] = formulaSafe(row[index[f.key]])
+			}
+		}
+		if err := writer.Write(row); err != nil {
+			return nil, err
+		}
+	}
+	writer.Flush()
+	return buf.Bytes(), writer.Error()
+}
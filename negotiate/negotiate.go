@@ -0,0 +1,282 @@
+// Package negotiate renders the JSON responses of handlers in the format the
+// Accept header prefers and decodes request bodies by their Content-Type.
+// JSON, XML, YAML and MessagePack are exchanged both ways, lists are also
+// rendered as CSV.
+package negotiate
+
+import (
+	"bytes"
+	"mime"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/TranQuocToan1996/ginProject/apierrors"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// Media types of the formats
+const (
+	MIMEJSON    = "application/json"
+	MIMEXML     = "application/xml"
+	MIMEYAML    = "application/yaml"
+	MIMEMsgPack = "application/msgpack"
+	MIMECSV     = "text/csv"
+)
+
+// aliases lists the other media types clients use for the formats
+var aliases = map[string][]string{
+	MIMEXML:     {"text/xml"},
+	MIMEYAML:    {"application/x-yaml", "text/yaml", "text/x-yaml"},
+	MIMEMsgPack: {"application/x-msgpack"},
+}
+
+// MediaTypes are the media types of the formats bodies and responses are
+// exchanged in besides JSON
+var MediaTypes = []string{MIMEXML, MIMEYAML, MIMEMsgPack}
+
+// formats are the formats of responses in the order of preference, CSV only
+// renders lists
+var formats = []string{MIMEJSON, MIMEXML, MIMEYAML, MIMEMsgPack, MIMECSV}
+
+// mediaRange is a media range of an Accept header
+type mediaRange struct {
+	mediaType string
+	q         float64
+}
+
+// parseAccept returns the media ranges of an Accept header
+func parseAccept(header string) []mediaRange {
+	ranges := make([]mediaRange, 0)
+	for _, part := range strings.Split(header, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if value, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(value, 64); err != nil {
+				continue
+			}
+		}
+		ranges = append(ranges, mediaRange{mediaType: mediaType, q: q})
+	}
+	return ranges
+}
+
+// quality returns the q of the most specific range matching mediaType, 0
+// when none does
+func quality(ranges []mediaRange, mediaType string) float64 {
+	kind := mediaType[:strings.Index(mediaType, "/")]
+	q, specificity := 0.0, 0
+	for _, r := range ranges {
+		s := 0
+		switch r.mediaType {
+		case mediaType:
+			s = 3
+		case kind + "/*":
+			s = 2
+		case "*/*":
+			s = 1
+		}
+		if s > specificity {
+			q, specificity = r.q, s
+		}
+	}
+	return q
+}
+
+// Negotiate returns the offer an Accept header prefers, the first offer when
+// the header is empty and an empty string when no offer is acceptable. Offers
+// are media types of the formats, in the order of preference of the server.
+func Negotiate(accept string, offers []string) string {
+	if strings.TrimSpace(accept) == "" {
+		return offers[0]
+	}
+	ranges := parseAccept(accept)
+	best, bestQ := "", 0.0
+	for _, offer := range offers {
+		q := quality(ranges, offer)
+		for _, alias := range aliases[offer] {
+			if aliasQ := quality(ranges, alias); aliasQ > q {
+				q = aliasQ
+			}
+		}
+		if q > bestQ {
+			best, bestQ = offer, q
+		}
+	}
+	return best
+}
+
+// Middleware renders the JSON responses of handlers, problem details
+// included, in the format the Accept header prefers. Responses of other
+// media types are written unchanged. Writes are negotiated before their
+// handler runs and never answered in CSV, so a write no format of the Accept
+// header can represent is answered with 406 without taking effect. Reads have
+// no side effects, so their responses are negotiated once written: CSV is
+// offered for lists only and JSON responses no acceptable format can
+// represent are answered with 406.
+//
+// Entity tags of rendered responses get the suffix of their format, a
+// representation having its own tag. The tags of that format in the
+// conditional headers of requests are turned back to the tags of JSON bodies
+// that handlers compare them with.
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Writer.Header().Add("Vary", "Accept")
+		accept := c.GetHeader("Accept")
+		read := safe(c.Request.Method)
+		offers := formats
+		if !read {
+			offers = formats[:len(formats)-1]
+		}
+		format := Negotiate(accept, offers)
+		if format == "" && !read {
+			apierrors.Write(c, apierrors.NotAcceptable("Responses are available as "+strings.Join(offers, ", ")))
+			c.Abort()
+			return
+		}
+		if format == MIMEJSON {
+			c.Next()
+			return
+		}
+		if format != "" {
+			for _, name := range []string{"If-Match", "If-None-Match"} {
+				if header := c.Request.Header.Get(name); header != "" {
+					c.Request.Header.Set(name, jsonTags(header, format))
+				}
+			}
+		}
+
+		writer := &bufferedWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+		c.Writer = writer.ResponseWriter
+		body := writer.body.Bytes()
+		contentType := c.Writer.Header().Get("Content-Type")
+		if len(body) == 0 && format != "" {
+			setTag(c, format)
+		}
+		if len(body) == 0 || !isJSON(contentType) {
+			c.Writer.Write(body)
+			return
+		}
+
+		value, err := decodeJSON(body)
+		if err != nil {
+			c.Writer.Write(body)
+			return
+		}
+		offers = formats[:len(formats)-1]
+		if _, ok := list(value); ok && read {
+			offers = formats
+		}
+		format = Negotiate(accept, offers)
+		if format == "" {
+			c.Writer.Header().Del("ETag")
+			apierrors.Write(c, apierrors.NotAcceptable("Responses are available as "+strings.Join(offers, ", ")))
+			return
+		}
+		rendered, renderedType, err := render(value, format, strings.HasPrefix(contentType, "application/problem+"))
+		if err != nil {
+			c.Writer.Write(body)
+			return
+		}
+		c.Writer.Header().Set("Content-Type", renderedType)
+		setTag(c, format)
+		c.Writer.Write(rendered)
+	}
+}
+
+// safe reports whether requests of method only read
+func safe(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+// tagSuffixes tell the entity tags of the formats from the ones of JSON
+var tagSuffixes = map[string]string{MIMEXML: "+xml", MIMEYAML: "+yaml", MIMEMsgPack: "+msgpack", MIMECSV: "+csv"}
+
+// setTag adds the suffix of format to the ETag header of the response
+func setTag(c *gin.Context, format string) {
+	if tag := c.Writer.Header().Get("ETag"); strings.HasSuffix(tag, `"`) {
+		c.Writer.Header().Set("ETag", strings.TrimSuffix(tag, `"`)+tagSuffixes[format]+`"`)
+	}
+}
+
+// jsonTags turns the entity tags of format of a conditional header into the
+// tags of the JSON bodies
+func jsonTags(header, format string) string {
+	return strings.ReplaceAll(header, tagSuffixes[format]+`"`, `"`)
+}
+
+// isJSON reports whether contentType is JSON, such as problem details
+func isJSON(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && (mediaType == MIMEJSON || strings.HasSuffix(mediaType, "+json"))
+}
+
+// Bind decodes the body of the request into obj by its Content-Type, JSON
+// when it has none, and validates obj as ShouldBindJSON does. Bodies of
+// other media types are rejected with an unsupported media type error.
+func Bind(c *gin.Context, obj interface{}) error {
+	format, ok := bodyFormat(c.ContentType())
+	if !ok {
+		return apierrors.UnsupportedMedia("Content-Type must be one of " + strings.Join(append([]string{MIMEJSON}, MediaTypes...), ", "))
+	}
+	if format == MIMEJSON {
+		return c.ShouldBindJSON(obj)
+	}
+	body, err := c.GetRawData()
+	if err != nil {
+		return err
+	}
+	data, err := toJSON(body, format, reflect.TypeOf(obj))
+	if err != nil {
+		return apierrors.New(apierrors.CodeInvalidBody, "Request body isn't valid "+format+": "+err.Error())
+	}
+	return binding.JSON.BindBody(data, obj)
+}
+
+// bodyFormat returns the format of a request body of contentType
+func bodyFormat(contentType string) (string, bool) {
+	if contentType == "" || contentType == MIMEJSON || strings.HasSuffix(contentType, "+json") {
+		return MIMEJSON, true
+	}
+	for _, format := range MediaTypes {
+		if contentType == format || contains(aliases[format], contentType) {
+			return format, true
+		}
+	}
+	return "", false
+}
+
+func contains(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// bufferedWriter holds the body written by handlers until it is rendered,
+// headers and status go to the underlying writer
+type bufferedWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *bufferedWriter) Write(data []byte) (int, error) {
+	return w.body.Write(data)
+}
+
+func (w *bufferedWriter) WriteString(s string) (int, error) {
+	return w.body.WriteString(s)
+}
+
+func (w *bufferedWriter) Written() bool {
+	return w.body.Len() > 0 || w.ResponseWriter.Written()
+}
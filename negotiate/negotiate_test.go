@@ -0,0 +1,218 @@
+package negotiate
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/TranQuocToan1996/ginProject/apierrors"
+	"github.com/gin-gonic/gin"
+	"github.com/ugorji/go/codec"
+)
+
+func TestNegotiate(t *testing.T) {
+	for i, tt := range []struct {
+		accept string
+		offers []string
+		want   string
+	}{
+		{"", formats, MIMEJSON},
+		{"*/*", formats, MIMEJSON},
+		{"application/xml", formats, MIMEXML},
+		{"text/xml", formats, MIMEXML},
+		{"application/x-yaml", formats, MIMEYAML},
+		{"application/json;q=0.5, application/msgpack", formats, MIMEMsgPack},
+		{"text/*", formats, MIMEXML},
+		{"text/csv", formats, MIMECSV},
+		{"text/csv, application/json;q=0.1", formats[:4], MIMEJSON},
+		{"text/csv", formats[:4], ""},
+		{"image/png", formats, ""},
+		{"application/*;q=0.2, application/yaml;q=0", formats, MIMEJSON},
+		{"text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", formats, MIMEXML},
+	} {
+		t.Run(fmt.Sprintf("%v", i), func(t *testing.T) {
+			if got := Negotiate(tt.accept, tt.offers); got != tt.want {
+				t.Errorf("want %q; got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRender(t *testing.T) {
+	recipes := `[{"id":"1","name":"Pasta & \"beans\"","tags":["italian","quick"],"servings":4,"rating":{"average":4.5}},{"id":"2","name":"Soup","author":null}]`
+	for i, tt := range []struct {
+		body    string
+		format  string
+		problem bool
+		want    string
+	}{
+		{`{"name":"Soup","servings":2,"tags":["a","b"],"1 egg":null}`, MIMEXML, false,
+			xmlHeader + `<response><name>Soup</name><servings>2</servings><tags><item>a</item><item>b</item></tags><entry key="1 egg"></entry></response>`},
+		{`{"status":404,"title":"Not found"}`, MIMEXML, true,
+			xmlHeader + `<problem xmlns="urn:ietf:rfc:7807"><status>404</status><title>Not found</title></problem>`},
+		{`{"name":"Soup","servings":2,"rating":{"average":4.5},"tags":["a"]}`, MIMEYAML, false,
+			"name: Soup\nservings: 2\nrating:\n  average: 4.5\ntags:\n- a\n"},
+		{recipes, MIMECSV, false,
+			"id,name,tags,servings,rating,author\n1,\"Pasta & \"\"beans\"\"\",\"[\"\"italian\"\",\"\"quick\"\"]\",4,\"{\"\"average\"\":4.5}\",\n2,Soup,,,,\n"},
+		{`{"data":["a","b"],"meta":{"count":2}}`, MIMECSV, false, "value\na\nb\n"},
+		{`[]`, MIMECSV, false, ""},
+		{`[{"name":"=HYPERLINK(\"http://x\")","tags":["@SUM(A1)"],"servings":-2},{"name":"+1","=cmd":"-x"}]`, MIMECSV, false,
+			"name,tags,servings,'=cmd\n\"'=HYPERLINK(\"\"http://x\"\")\",\"[\"\"@SUM(A1)\"\"]\",-2,\n'+1,,,'-x\n"},
+	} {
+		t.Run(fmt.Sprintf("%v", i), func(t *testing.T) {
+			value, err := decodeJSON([]byte(tt.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			data, _, err := render(value, tt.format, tt.problem)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != tt.want {
+				t.Errorf("want %q; got %q", tt.want, data)
+			}
+		})
+	}
+}
+
+const xmlHeader = `<?xml version="1.0" encoding="UTF-8"?>` + "\n"
+
+type ingredient struct {
+	Name string `json:"name"`
+}
+
+type request struct {
+	Name        string            `json:"name" binding:"required"`
+	Servings    int               `json:"servings"`
+	Vegan       bool              `json:"vegan"`
+	Tags        []string          `json:"tags"`
+	Ingredients []ingredient      `json:"ingredients"`
+	Notes       map[string]string `json:"notes"`
+	Score       *float64          `json:"score"`
+}
+
+func TestBind(t *testing.T) {
+	score := 4.5
+	want := request{
+		Name:        "123",
+		Servings:    4,
+		Vegan:       true,
+		Tags:        []string{"quick", "cheap"},
+		Ingredients: []ingredient{{Name: "1 egg"}},
+		Notes:       map[string]string{"oven temp": "hot"},
+		Score:       &score,
+	}
+	var msgpack []byte
+	codec.NewEncoderBytes(&msgpack, &codec.MsgpackHandle{WriteExt: true}).Encode(map[string]interface{}{
+		"name": "123", "servings": 4, "vegan": true, "tags": []string{"quick", "cheap"},
+		"ingredients": []interface{}{map[string]interface{}{"name": "1 egg"}},
+		"notes":       map[string]string{"oven temp": "hot"}, "score": 4.5,
+	})
+	for i, tt := range []struct {
+		contentType string
+		body        string
+		status      int
+	}{
+		{"application/json", `{"name":"123","servings":4,"vegan":true,"tags":["quick","cheap"],"ingredients":[{"name":"1 egg"}],"notes":{"oven temp":"hot"},"score":4.5}`, 0},
+		{"application/xml", `<recipe><name>123</name><servings> 4 </servings><vegan>true</vegan><tags><item>quick</item><item>cheap</item></tags>` +
+			`<ingredients><item><name>1 egg</name></item></ingredients><notes><entry key="oven temp">hot</entry></notes><score>4.5</score></recipe>`, 0},
+		{"text/yaml", "name: \"123\"\nservings: 4\nvegan: true\ntags: [quick, cheap]\ningredients:\n- name: 1 egg\nnotes:\n  oven temp: hot\nscore: 4.5\n", 0},
+		{"application/msgpack", string(msgpack), 0},
+		{"application/xml", `<recipe><name>Soup</name><servings>four</servings></recipe>`, http.StatusBadRequest},
+		{"application/xml", `<recipe><servings>4</servings></recipe>`, http.StatusUnprocessableEntity},
+		{"application/xml", `<recipe>`, http.StatusBadRequest},
+		{"text/plain", `name: Soup`, http.StatusUnsupportedMediaType},
+	} {
+		t.Run(fmt.Sprintf("%v", i), func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodPost, "/recipes", strings.NewReader(tt.body))
+			c.Request.Header.Set("Content-Type", tt.contentType)
+			var got request
+			err := Bind(c, &got)
+			if tt.status != 0 {
+				if status := apierrors.InvalidInput(err).Status(); err == nil || status != tt.status {
+					t.Errorf("want %v; got %v", tt.status, err)
+				}
+				return
+			}
+			if err != nil || !reflect.DeepEqual(got, want) {
+				t.Errorf("want %+v; got %+v %v", want, got, err)
+			}
+		})
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Middleware(), apierrors.Middleware())
+	router.GET("/recipes", func(c *gin.Context) {
+		c.Header("ETag", `W/"1"`)
+		c.JSON(http.StatusOK, []gin.H{{"name": "Soup"}})
+	})
+	router.GET("/recipes/1", func(c *gin.Context) {
+		c.Header("ETag", `"1-1"`)
+		if c.GetHeader("If-None-Match") == `"1-1"` {
+			c.Status(http.StatusNotModified)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"name": "Soup"})
+	})
+	created := 0
+	router.POST("/recipes", func(c *gin.Context) {
+		created++
+		c.JSON(http.StatusCreated, []gin.H{{"name": "Soup"}})
+	})
+	router.GET("/missing", func(c *gin.Context) { c.Error(apierrors.NotFound("Recipe not found")) })
+	router.GET("/image", func(c *gin.Context) { c.Data(http.StatusOK, "image/png", []byte("png")) })
+	for i, tt := range []struct {
+		method      string
+		path        string
+		accept      string
+		ifNoneMatch string
+		status      int
+		contentType string
+		body        string
+		etag        string
+		created     int
+	}{
+		{http.MethodGet, "/recipes/1", "", "", http.StatusOK, "application/json; charset=utf-8", `{"name":"Soup"}`, `"1-1"`, 0},
+		{http.MethodGet, "/recipes/1", "application/xml", "", http.StatusOK, "application/xml; charset=utf-8", xmlHeader + `<response><name>Soup</name></response>`, `"1-1+xml"`, 0},
+		{http.MethodGet, "/recipes/1", "application/xml", `"1-1+xml"`, http.StatusNotModified, "", "", `"1-1+xml"`, 0},
+		{http.MethodGet, "/recipes/1", "application/yaml", `"1-1+xml"`, http.StatusOK, "application/yaml; charset=utf-8", "name: Soup", `"1-1+yaml"`, 0},
+		{http.MethodGet, "/recipes", "text/csv", "", http.StatusOK, "text/csv; charset=utf-8", "name\nSoup\n", `W/"1+csv"`, 0},
+		{http.MethodGet, "/recipes/1", "text/csv", "", http.StatusNotAcceptable, apierrors.ContentType, `"code":"not_acceptable"`, "", 0},
+		{http.MethodGet, "/missing", "application/yaml", "", http.StatusNotFound, "application/yaml; charset=utf-8", "code: not_found", "", 0},
+		{http.MethodGet, "/missing", "application/xml", "", http.StatusNotFound, "application/problem+xml; charset=utf-8", "<code>not_found</code>", "", 0},
+		{http.MethodGet, "/image", "image/png", "", http.StatusOK, "image/png", "png", "", 0},
+		{http.MethodPost, "/recipes", "application/xml", "", http.StatusCreated, "application/xml; charset=utf-8", "<name>Soup</name>", "", 1},
+		{http.MethodPost, "/recipes", "text/csv", "", http.StatusNotAcceptable, apierrors.ContentType, `"code":"not_acceptable"`, "", 1},
+		{http.MethodPost, "/recipes", "image/png", "", http.StatusNotAcceptable, apierrors.ContentType, `"code":"not_acceptable"`, "", 1},
+	} {
+		t.Run(fmt.Sprintf("%v", i), func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			req.Header.Set("Accept", tt.accept)
+			if tt.ifNoneMatch != "" {
+				req.Header.Set("If-None-Match", tt.ifNoneMatch)
+			}
+			router.ServeHTTP(w, req)
+			if w.Code != tt.status || w.Header().Get("Content-Type") != tt.contentType || !bytes.Contains(w.Body.Bytes(), []byte(tt.body)) {
+				t.Errorf("want %v %v %q; got %v %v %q", tt.status, tt.contentType, tt.body, w.Code, w.Header().Get("Content-Type"), w.Body)
+			}
+			if w.Header().Get("ETag") != tt.etag {
+				t.Errorf("want ETag %q; got %q", tt.etag, w.Header().Get("ETag"))
+			}
+			if created != tt.created {
+				t.Errorf("want %v recipes created; got %v", tt.created, created)
+			}
+			if w.Header().Get("Vary") != "Accept" {
+				t.Errorf("want Vary: Accept; got %q", w.Header().Get("Vary"))
+			}
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package nutrition
+
+import (
+	_ "embed"
+	"encoding/json"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/TranQuocToan1996/ginProject/ingredients"
+	"github.com/TranQuocToan1996/ginProject/models"
+	"github.com/TranQuocToan1996/ginProject/units"
+)
+
+// foods.json holds approximate values per 100 g taken from USDA FoodData
+// Central for the ingredients that appear most in the recipes
+//
+//go:embed foods.json
+var foodsJSON []byte
+
+type food struct {
+	Names        []string              `json:"names"`
+	Per100g      models.NutritionFacts `json:"per100g"`
+	GramsPerMl   float64               `json:"gramsPerMl"`
+	GramsPerUnit float64               `json:"gramsPerUnit"`
+}
+
+// foods maps every normalized name of a food to it
+var foods = map[string]*food{}
+
+func init() {
+	var list []*food
+	if err := json.Unmarshal(foodsJSON, &list); err != nil {
+		panic("nutrition: invalid foods.json: " + err.Error())
+	}
+	for _, f := range list {
+		for _, name := range f.Names {
+			foods[ingredients.NormalizeName(name)] = f
+		}
+	}
+}
+
+// Weights of the counted measures that aren't units of volume or weight
+var countUnits = map[string]float64{
+	"pinch": 0.36,
+	"dash":  0.6,
+	"can":   400,
+	"stick": 113,
+	"slice": 28,
+	"sprig": 1,
+	"bunch": 60,
+}
+
+// itemWeight matches the size of one item given in parentheses, as in
+// "4 (6 to 7-ounce) boneless skinless chicken breasts"
+var itemWeight = regexp.MustCompile(`\((\d+(?:\.\d+)?)(?:\s*(?:to|-)\s*(\d+(?:\.\d+)?))?[\s-]*([a-zA-Z]+)\)`)
+
+// Calculate computes the nutrition facts of a recipe from its ingredient lines.
+// Lines that can't be measured or aren't in the dataset are left out of the
+// totals and listed in UnmatchedIngredients.
+func Calculate(lines []string, servings int) *models.Nutrition {
+	result := &models.Nutrition{
+		UnmatchedIngredients: make([]string, 0),
+		ComputedAt:           time.Now(),
+	}
+	for _, line := range lines {
+		if ingredients.IsSeparator(line) {
+			continue
+		}
+		facts, ok := Ingredient(line)
+		if !ok {
+			result.UnmatchedIngredients = append(result.UnmatchedIngredients, strings.TrimSpace(line))
+			continue
+		}
+		result.Total = add(result.Total, facts)
+	}
+	result.Total = round(result.Total)
+	if servings > 0 {
+		perServing := round(scale(result.Total, 1/float64(servings)))
+		result.PerServing = &perServing
+	}
+	return result
+}
+
+// Ingredient computes the nutrition facts of a single ingredient line
+func Ingredient(line string) (models.NutritionFacts, bool) {
+	ingredient, err := ingredients.Parse(line)
+	if err != nil {
+		return models.NutritionFacts{}, false
+	}
+	f, ok := lookup(ingredient.Name)
+	if !ok {
+		return models.NutritionFacts{}, false
+	}
+	grams, ok := weight(ingredient, f)
+	if !ok {
+		return models.NutritionFacts{}, false
+	}
+	return scale(f.Per100g, grams/100), true
+}
+
+// lookup finds the food whose name is the longest run of words of name
+func lookup(name string) (*food, bool) {
+	words := strings.Fields(ingredients.NormalizeName(name))
+	for size := len(words); size > 0; size-- {
+		for start := 0; start+size <= len(words); start++ {
+			if f, ok := foods[strings.Join(words[start:start+size], " ")]; ok {
+				return f, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// weight converts the quantity of an ingredient into grams
+func weight(ingredient ingredients.Ingredient, f *food) (float64, bool) {
+	quantity := ingredient.Quantity
+	if ingredient.QuantityMax > 0 {
+		quantity = (quantity + ingredient.QuantityMax) / 2
+	}
+	if ingredient.Unit != "" {
+		unit, _ := units.Lookup(ingredient.Unit)
+		if unit.Kind == units.Weight {
+			return quantity * unit.ToBase, true
+		}
+		if f.GramsPerMl > 0 {
+			return quantity * unit.ToBase * f.GramsPerMl, true
+		}
+		return units.VolumeToWeight(quantity, unit, ingredient.Name, true)
+	}
+	if m := itemWeight.FindStringSubmatch(ingredient.Name); m != nil {
+		if unit, ok := units.Lookup(m[3]); ok && unit.Kind == units.Weight {
+			size, _ := ingredients.ParseQuantity(m[1])
+			if m[2] != "" {
+				max, _ := ingredients.ParseQuantity(m[2])
+				size = (size + max) / 2
+			}
+			return quantity * size * unit.ToBase, true
+		}
+	}
+	if first := strings.Fields(ingredients.NormalizeName(ingredient.Name)); len(first) > 0 {
+		if grams, ok := countUnits[first[0]]; ok {
+			return quantity * grams, true
+		}
+	}
+	if f.GramsPerUnit > 0 {
+		return quantity * f.GramsPerUnit, true
+	}
+	return 0, false
+}
+
+func add(a, b models.NutritionFacts) models.NutritionFacts {
+	return models.NutritionFacts{
+		Calories:      a.Calories + b.Calories,
+		Protein:       a.Protein + b.Protein,
+		Fat:           a.Fat + b.Fat,
+		Carbohydrates: a.Carbohydrates + b.Carbohydrates,
+		Sodium:        a.Sodium + b.Sodium,
+	}
+}
+
+func scale(a models.NutritionFacts, factor float64) models.NutritionFacts {
+	return models.NutritionFacts{
+		Calories:      a.Calories * factor,
+		Protein:       a.Protein * factor,
+		Fat:           a.Fat * factor,
+		Carbohydrates: a.Carbohydrates * factor,
+		Sodium:        a.Sodium * factor,
+	}
+}
+
+func round(a models.NutritionFacts) models.NutritionFacts {
+	r := func(v float64) float64 { return math.Round(v*10) / 10 }
+	return models.NutritionFacts{
+		Calories:      r(a.Calories),
+		Protein:       r(a.Protein),
+		Fat:           r(a.Fat),
+		Carbohydrates: r(a.Carbohydrates),
+		Sodium:        r(a.Sodium),
+	}
+}
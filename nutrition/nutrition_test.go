@@ -0,0 +1,49 @@
+package nutrition
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+func TestIngredient(t *testing.T) {
+	for i, tt := range []struct {
+		line     string
+		calories float64
+	}{
+		{"2 tablespoon extra-virgin olive oil", 240.5},
+		{"1 cup all-purpose flour", 456.5},
+		{"2 eggs", 143},
+		{"4 (6 to 7-ounce) boneless skinless chicken breasts", 884.5},
+		{"6 oz shredded sharp white cheddar", 685.5},
+		{"2 clove garlic, pressed", 8.9},
+	} {
+		t.Run(fmt.Sprintf("%v", i), func(t *testing.T) {
+			facts, ok := Ingredient(tt.line)
+			if !ok {
+				t.Fatalf("want %q to be matched", tt.line)
+			}
+			if math.Abs(facts.Calories-tt.calories) > 0.5 {
+				t.Errorf("want %v; got %v", tt.calories, facts.Calories)
+			}
+		})
+	}
+}
+
+func TestCalculate(t *testing.T) {
+	result := Calculate([]string{
+		"1/2 tsp salt\r",
+		"2 tablespoon extra-virgin olive oil\r",
+		"<hr>",
+		"Coarse salt and ground pepper",
+	}, 2)
+	if result.Total.Sodium != 1165.9 {
+		t.Errorf("want sodium 1165.9; got %v", result.Total.Sodium)
+	}
+	if result.PerServing == nil || result.PerServing.Fat != 13.6 {
+		t.Errorf("want 13.6 g of fat per serving; got %+v", result.PerServing)
+	}
+	if len(result.UnmatchedIngredients) != 1 || result.UnmatchedIngredients[0] != "Coarse salt and ground pepper" {
+		t.Errorf("want the unmeasured line unmatched; got %v", result.UnmatchedIngredients)
+	}
+}
@@ -0,0 +1,38 @@
+package openapi
+
+import (
+	_ "embed"
+	"net/http"
+	"strings"
+
+	"github.com/TranQuocToan1996/ginProject/apierrors"
+	"github.com/gin-gonic/gin"
+	swaggerFiles "github.com/swaggo/files/v2"
+)
+
+//go:embed docs.html
+var docsPage []byte
+
+// docsAssets are the Swagger UI files the docs page loads. They are embedded
+// in the binary at the version pinned in go.mod, so the page works offline and
+// runs no third-party script fetched at request time.
+var docsAssets = map[string]bool{
+	"swagger-ui.css":       true,
+	"swagger-ui-bundle.js": true,
+}
+
+// DocsPage serves the interactive documentation of the document at /openapi.json
+func DocsPage(c *gin.Context) {
+	c.Data(http.StatusOK, "text/html; charset=utf-8", docsPage)
+}
+
+// DocsAsset serves a Swagger UI file of the docs page
+func DocsAsset(c *gin.Context) {
+	name := strings.TrimPrefix(c.Param("file"), "/")
+	if !docsAssets[name] {
+		c.Error(apierrors.NotFound("File not found"))
+		return
+	}
+	c.Header("Cache-Control", "public, max-age=86400")
+	c.FileFromFS(name, http.FS(swaggerFiles.FS))
+}
@@ -0,0 +1,45 @@
+package openapi
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/TranQuocToan1996/ginProject/apierrors"
+	"github.com/gin-gonic/gin"
+)
+
+func TestDocsAsset(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(apierrors.Middleware())
+	router.GET("/docs", DocsPage)
+	router.GET("/docs/assets/*file", DocsAsset)
+	for i, tt := range []struct {
+		path        string
+		status      int
+		contentType string
+	}{
+		{"/docs/assets/swagger-ui.css", http.StatusOK, "text/css; charset=utf-8"},
+		{"/docs/assets/swagger-ui-bundle.js", http.StatusOK, "javascript"},
+		{"/docs/assets/index.html", http.StatusNotFound, apierrors.ContentType},
+		{"/docs/assets/../docs.html", http.StatusNotFound, apierrors.ContentType},
+	} {
+		t.Run(fmt.Sprintf("%v", i), func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if w.Code != tt.status || !strings.Contains(w.Header().Get("Content-Type"), tt.contentType) {
+				t.Errorf("want %v %v; got %v %v", tt.status, tt.contentType, w.Code, w.Header().Get("Content-Type"))
+			}
+		})
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/docs", nil))
+	if bytes.Contains(w.Body.Bytes(), []byte("https://")) {
+		t.Errorf("want the docs page to load only embedded files; got %s", w.Body)
+	}
+}
@@ -0,0 +1,346 @@
+// Package openapi builds the OpenAPI 3 document of the API from its routes,
+// the documentation of their handlers and the types they read and write
+package openapi
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Document is an OpenAPI 3.0 document
+type Document struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Servers    []Server            `json:"servers"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components"`
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+type Server struct {
+	URL         string `json:"url"`
+	Description string `json:"description,omitempty"`
+}
+
+// PathItem holds the operations of a path by lower case method
+type PathItem map[string]*Operation
+
+type Operation struct {
+	OperationID string                `json:"operationId"`
+	Summary     string                `json:"summary"`
+	Tags        []string              `json:"tags,omitempty"`
+	Deprecated  bool                  `json:"deprecated,omitempty"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]Response   `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+}
+
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema        `json:"schemas"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+type SecurityScheme struct {
+	Type string `json:"type"`
+	In   string `json:"in,omitempty"`
+	Name string `json:"name,omitempty"`
+}
+
+// Route documents the operation of a handler, in every version serving it
+type Route struct {
+	Summary string
+	Tag     string
+	// Auth is set for the routes needing a session
+	Auth bool
+	// Params are the query and header parameters, path parameters are
+	// documented from the path
+	Params []Parameter
+	// Body is a value of the type of the JSON body, Files the file fields of
+	// a multipart form body and BodyTypes the media types of a body other
+	// than JSON
+	Body      interface{}
+	Files     []string
+	BodyTypes []string
+	// Status is the status of a success, 200 by default
+	Status int
+	// Response is a value of the type of the JSON response, Produces the
+	// media types of a response other than JSON
+	Response interface{}
+	Produces []string
+}
+
+// Query returns a query parameter of type string
+func Query(name, description string) Parameter {
+	return Parameter{Name: name, In: "query", Description: description, Schema: &Schema{Type: "string"}}
+}
+
+// Header returns a header parameter
+func Header(name, description string) Parameter {
+	return Parameter{Name: name, In: "header", Description: description, Schema: &Schema{Type: "string"}}
+}
+
+// Name returns the name of a handler function as gin reports it in the
+// RoutesInfo of its routes, without the suffix of method values
+func Name(handler interface{}) string {
+	return HandlerName(runtime.FuncForPC(reflect.ValueOf(handler).Pointer()).Name())
+}
+
+// HandlerName strips the suffix of method values from a function name
+func HandlerName(name string) string {
+	return strings.TrimSuffix(name, "-fm")
+}
+
+// Version describes how a version of the API changes the documented operations
+type Version struct {
+	// Prefix is the path prefix of the version, such as /v2
+	Prefix     string
+	Deprecated bool
+	// Meta, when set, is a value of the type of the meta of the {data, meta}
+	// envelope wrapping the JSON responses of the version
+	Meta interface{}
+}
+
+var pathParam = regexp.MustCompile(`[:*]([^/]+)`)
+
+// Path converts the path of a gin route to an OpenAPI path
+func Path(path string) string {
+	return pathParam.ReplaceAllString(path, "{$1}")
+}
+
+// Builder adds the operations of routes to a document
+type Builder struct {
+	Document Document
+	// Formats are the media types JSON bodies and responses are also
+	// exchanged in, ListFormats those the lists GET requests read are also
+	// rendered in
+	Formats     []string
+	ListFormats []string
+
+	routes   map[string]Route
+	used     map[string]bool
+	versions []Version
+	problem  *Schema
+}
+
+// NewBuilder returns a builder of a document documenting the handlers of
+// routes, keyed by Name, with a problem details schema for the errors
+func NewBuilder(info Info, routes map[string]Route, problem interface{}, versions ...Version) *Builder {
+	b := &Builder{
+		Document: Document{
+			OpenAPI: "3.0.3",
+			Info:    info,
+			Paths:   make(map[string]PathItem),
+			Components: Components{
+				Schemas: make(map[string]*Schema),
+				SecuritySchemes: map[string]SecurityScheme{
+					"session": {Type: "apiKey", In: "cookie", Name: "recipes_api"},
+				},
+			},
+		},
+		routes:   routes,
+		used:     make(map[string]bool),
+		versions: versions,
+	}
+	b.problem = b.Schema(problem)
+	return b
+}
+
+// Schema returns the schema of the type of v, adding its named types to the
+// components of the document
+func (b *Builder) Schema(v interface{}) *Schema {
+	return schemaOf(reflect.TypeOf(v), b.Document.Components.Schemas)
+}
+
+// Add documents the route of a handler. It fails for handlers without a Route.
+func (b *Builder) Add(route gin.RouteInfo) error {
+	name := HandlerName(route.Handler)
+	doc, ok := b.routes[name]
+	if !ok {
+		return fmt.Errorf("route %v %v: handler %v isn't documented", route.Method, route.Path, name)
+	}
+	b.used[name] = true
+
+	version := Version{}
+	for _, v := range b.versions {
+		if strings.HasPrefix(route.Path, v.Prefix+"/") {
+			version = v
+		}
+	}
+	path := Path(route.Path)
+	operation := &Operation{
+		OperationID: operationID(version.Prefix, name),
+		Summary:     doc.Summary,
+		Deprecated:  version.Deprecated,
+		Parameters:  append(pathParams(path), doc.Params...),
+		Responses:   b.responses(doc, version, route.Method == http.MethodGet),
+	}
+	if doc.Tag != "" {
+		operation.Tags = []string{doc.Tag}
+	}
+	if doc.Auth {
+		operation.Security = []map[string][]string{{"session": {}}}
+	}
+	operation.RequestBody = b.requestBody(doc)
+
+	item, ok := b.Document.Paths[path]
+	if !ok {
+		item = make(PathItem)
+		b.Document.Paths[path] = item
+	}
+	method := strings.ToLower(route.Method)
+	if _, ok := item[method]; ok {
+		return fmt.Errorf("route %v %v is documented twice", route.Method, route.Path)
+	}
+	item[method] = operation
+	return nil
+}
+
+// Unused returns the documented handlers no route added so far serves
+func (b *Builder) Unused() []string {
+	unused := make([]string, 0)
+	for name := range b.routes {
+		if !b.used[name] {
+			unused = append(unused, name)
+		}
+	}
+	sort.Strings(unused)
+	return unused
+}
+
+func (b *Builder) requestBody(doc Route) *RequestBody {
+	content := make(map[string]MediaType)
+	if doc.Body != nil {
+		schema := b.Schema(doc.Body)
+		content[gin.MIMEJSON] = MediaType{Schema: schema}
+		for _, mediaType := range b.Formats {
+			content[mediaType] = MediaType{Schema: schema}
+		}
+	}
+	if len(doc.Files) > 0 {
+		form := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+		for _, file := range doc.Files {
+			form.Properties[file] = &Schema{Type: "string", Format: "binary"}
+		}
+		content[gin.MIMEMultipartPOSTForm] = MediaType{Schema: form}
+	}
+	for _, mediaType := range doc.BodyTypes {
+		content[mediaType] = MediaType{Schema: &Schema{}}
+	}
+	if len(content) == 0 {
+		return nil
+	}
+	return &RequestBody{Required: true, Content: content}
+}
+
+func (b *Builder) responses(doc Route, version Version, read bool) map[string]Response {
+	status := doc.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	success := Response{Description: http.StatusText(status)}
+	if doc.Response != nil {
+		schema := b.Schema(doc.Response)
+		list := schema.Type == "array" && read
+		if version.Meta != nil {
+			schema = &Schema{Type: "object", Required: []string{"data", "meta"}, Properties: map[string]*Schema{
+				"data": schema,
+				"meta": b.Schema(version.Meta),
+			}}
+		}
+		success.Content = map[string]MediaType{gin.MIMEJSON: {Schema: schema}}
+		for _, mediaType := range b.Formats {
+			success.Content[mediaType] = MediaType{Schema: schema}
+		}
+		if list {
+			for _, mediaType := range b.ListFormats {
+				success.Content[mediaType] = MediaType{Schema: &Schema{Type: "string"}}
+			}
+		}
+	}
+	for _, mediaType := range doc.Produces {
+		if success.Content == nil {
+			success.Content = make(map[string]MediaType)
+		}
+		success.Content[mediaType] = MediaType{Schema: &Schema{Type: "string", Format: "binary"}}
+	}
+	responses := map[string]Response{
+		strconv.Itoa(status): success,
+		"default": {
+			Description: "Error, as problem details",
+			Content:     map[string]MediaType{"application/problem+json": {Schema: b.problem}},
+		},
+	}
+	if doc.Auth {
+		responses[strconv.Itoa(http.StatusUnauthorized)] = Response{
+			Description: "No session",
+			Content:     map[string]MediaType{"application/problem+json": {Schema: b.problem}},
+		}
+	}
+	return responses
+}
+
+// pathParams returns the parameters of the {name} segments of path
+func pathParams(path string) []Parameter {
+	params := make([]Parameter, 0)
+	for _, segment := range strings.Split(path, "/") {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			params = append(params, Parameter{
+				Name:     strings.Trim(segment, "{}"),
+				In:       "path",
+				Required: true,
+				Schema:   &Schema{Type: "string"},
+			})
+		}
+	}
+	return params
+}
+
+// operationID returns an id such as v2SearchRecipes from the prefix of a
+// version and the name of a handler
+func operationID(prefix, name string) string {
+	name = name[strings.LastIndex(name, ".")+1:]
+	prefix = strings.Trim(prefix, "/")
+	if prefix == "" {
+		runes := []rune(name)
+		runes[0] = unicode.ToLower(runes[0])
+		return string(runes)
+	}
+	return prefix + name
+}
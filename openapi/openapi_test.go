@@ -0,0 +1,147 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type item struct {
+	Name  string   `json:"name" binding:"required,max=100"`
+	Tags  []string `json:"tags" validate:"max=20,dive,max=30"`
+	Count int      `json:"count,omitempty" validate:"min=0,max=5"`
+	Next  *item    `json:"next,omitempty"`
+	Skip  string   `json:"-"`
+}
+
+type wrapped struct {
+	item
+	At time.Time `json:"at"`
+}
+
+func TestSchema(t *testing.T) {
+	for i, tt := range []struct {
+		v    interface{}
+		want string
+	}{
+		{"", `{"type":"string"}`},
+		{[]int{}, `{"type":"array","items":{"type":"integer"}}`},
+		{map[string]float64{}, `{"type":"object","additionalProperties":{"type":"number"}}`},
+		{json.RawMessage{}, `{}`},
+		{item{}, `{"$ref":"#/components/schemas/item"}`},
+		{[]wrapped{}, `{"type":"array","items":{"$ref":"#/components/schemas/wrapped"}}`},
+	} {
+		t.Run(fmt.Sprintf("%v", i), func(t *testing.T) {
+			b := NewBuilder(Info{}, nil, struct{}{})
+			data, _ := json.Marshal(b.Schema(tt.v))
+			if string(data) != tt.want {
+				t.Errorf("want %v; got %v", tt.want, string(data))
+			}
+		})
+	}
+
+	b := NewBuilder(Info{}, nil, struct{}{})
+	b.Schema(wrapped{})
+	data, _ := json.Marshal(b.Document.Components.Schemas)
+	want := `{"item":{"type":"object","properties":{"count":{"type":"integer","minimum":0,"maximum":5},"name":{"type":"string","maxLength":100},"next":{"$ref":"#/components/schemas/item"},"tags":{"type":"array","items":{"type":"string"},"maxItems":20}},"required":["name"]},` +
+		`"wrapped":{"type":"object","properties":{"at":{"type":"string","format":"date-time"},"count":{"type":"integer","minimum":0,"maximum":5},"name":{"type":"string","maxLength":100},"next":{"$ref":"#/components/schemas/item"},"tags":{"type":"array","items":{"type":"string"},"maxItems":20}},"required":["name"]}}`
+	if string(data) != want {
+		t.Errorf("want %v; got %v", want, string(data))
+	}
+}
+
+func getItem(c *gin.Context) {}
+
+func TestAdd(t *testing.T) {
+	routes := map[string]Route{
+		Name(getItem): {Summary: "Get an item", Auth: true, Params: []Parameter{Query("units", "")}, Response: item{}},
+	}
+	for i, tt := range []struct {
+		path       string
+		id         string
+		params     []string
+		deprecated bool
+		data       bool
+	}{
+		{"/items/:id", "getItem", []string{"id", "units"}, false, false},
+		{"/v1/items/:id/*key", "v1getItem", []string{"id", "key", "units"}, true, false},
+		{"/v2/items/:id", "v2getItem", []string{"id", "units"}, false, true},
+	} {
+		t.Run(fmt.Sprintf("%v", i), func(t *testing.T) {
+			b := NewBuilder(Info{}, routes, struct{}{}, Version{Prefix: "/v1", Deprecated: true}, Version{Prefix: "/v2", Meta: struct{}{}})
+			err := b.Add(gin.RouteInfo{Method: "GET", Path: tt.path, Handler: Name(getItem) + "-fm"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			operation := b.Document.Paths[Path(tt.path)]["get"]
+			params := make([]string, 0)
+			for _, param := range operation.Parameters {
+				params = append(params, param.Name)
+			}
+			if operation.OperationID != tt.id || operation.Deprecated != tt.deprecated || !reflect.DeepEqual(params, tt.params) {
+				t.Errorf("want %v %v %v; got %v %v %v", tt.id, tt.deprecated, tt.params, operation.OperationID, operation.Deprecated, params)
+			}
+			_, data := operation.Responses["200"].Content[gin.MIMEJSON].Schema.Properties["data"]
+			if data != tt.data || operation.Responses["401"].Description == "" {
+				t.Errorf("want envelope %v and a 401 response; got %v", tt.data, operation.Responses)
+			}
+			if len(b.Unused()) != 0 {
+				t.Errorf("want every route used; got %v", b.Unused())
+			}
+		})
+	}
+
+	b := NewBuilder(Info{}, routes, struct{}{})
+	if err := b.Add(gin.RouteInfo{Method: "GET", Path: "/other", Handler: "main.other"}); err == nil {
+		t.Error("want an error for an undocumented handler")
+	}
+	if unused := b.Unused(); len(unused) != 1 {
+		t.Errorf("want getItem unused; got %v", unused)
+	}
+}
+
+func listItems(c *gin.Context) {}
+
+func TestFormats(t *testing.T) {
+	routes := map[string]Route{
+		Name(getItem):   {Summary: "Get an item", Body: item{}, Response: item{}},
+		Name(listItems): {Summary: "List items", Response: []item{}},
+	}
+	for i, tt := range []struct {
+		method  string
+		path    string
+		handler interface{}
+		want    []string
+	}{
+		{"PUT", "/items/:id", getItem, []string{"application/json", "application/xml"}},
+		{"GET", "/items", listItems, []string{"application/json", "application/xml", "text/csv"}},
+		{"GET", "/v2/items", listItems, []string{"application/json", "application/xml", "text/csv"}},
+		{"PUT", "/items", listItems, []string{"application/json", "application/xml"}},
+	} {
+		t.Run(fmt.Sprintf("%v", i), func(t *testing.T) {
+			b := NewBuilder(Info{}, routes, struct{}{}, Version{Prefix: "/v2", Meta: struct{}{}})
+			b.Formats, b.ListFormats = []string{"application/xml"}, []string{"text/csv"}
+			if err := b.Add(gin.RouteInfo{Method: tt.method, Path: tt.path, Handler: Name(tt.handler)}); err != nil {
+				t.Fatal(err)
+			}
+			operation := b.Document.Paths[Path(tt.path)][strings.ToLower(tt.method)]
+			got := make([]string, 0)
+			for mediaType := range operation.Responses["200"].Content {
+				got = append(got, mediaType)
+			}
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("want %v; got %v", tt.want, got)
+			}
+			if body := operation.RequestBody; body != nil && body.Content["application/xml"].Schema == nil {
+				t.Errorf("want an XML body; got %v", body.Content)
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Schema is an OpenAPI schema object
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	Minimum              *int               `json:"minimum,omitempty"`
+	Maximum              *int               `json:"maximum,omitempty"`
+}
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	objectIDType = reflect.TypeOf(primitive.ObjectID{})
+	rawType      = reflect.TypeOf(json.RawMessage{})
+)
+
+// schemaOf returns the schema of the JSON encoding of t. Named structs are
+// added to schemas and referenced.
+func schemaOf(t reflect.Type, schemas map[string]*Schema) *Schema {
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case objectIDType:
+		return &Schema{Type: "string", Pattern: "^[0-9a-f]{24}$"}
+	case rawType:
+		return &Schema{}
+	}
+	switch t.Kind() {
+	case reflect.Ptr:
+		schema := schemaOf(t.Elem(), schemas)
+		if schema.Ref == "" {
+			schema.Nullable = true
+		}
+		return schema
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: schemaOf(t.Elem(), schemas)}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: schemaOf(t.Elem(), schemas)}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return structSchema(t, schemas)
+		}
+		if _, ok := schemas[t.Name()]; !ok {
+			// the placeholder stops the recursion of self referencing types
+			schemas[t.Name()] = &Schema{}
+			*schemas[t.Name()] = *structSchema(t, schemas)
+		}
+		return &Schema{Ref: "#/components/schemas/" + t.Name()}
+	}
+	return &Schema{}
+}
+
+// structSchema returns the object schema of the exported fields of t, the
+// fields of embedded structs without a json name are inlined
+func structSchema(t reflect.Type, schemas map[string]*Schema) *Schema {
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			embedded := structSchema(field.Type, schemas)
+			for key, property := range embedded.Properties {
+				schema.Properties[key] = property
+			}
+			schema.Required = append(schema.Required, embedded.Required...)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		property := schemaOf(field.Type, schemas)
+		validated := constrain(property, field.Tag.Get("validate"))
+		if constrain(property, field.Tag.Get("binding")) || validated {
+			schema.Required = append(schema.Required, name)
+		}
+		schema.Properties[name] = property
+	}
+	return schema
+}
+
+// constrain sets the bounds of the min and max rules of a validate or binding
+// tag on schema, the rules after dive apply to items and are skipped. It
+// reports whether the rules make the field required.
+func constrain(schema *Schema, rules string) bool {
+	required := false
+	for _, rule := range strings.Split(rules, ",") {
+		if rule == "dive" {
+			break
+		}
+		if rule == "required" || rule == "notblank" {
+			required = true
+			continue
+		}
+		key, value, _ := strings.Cut(rule, "=")
+		n, err := strconv.Atoi(value)
+		if err != nil || schema.Ref != "" {
+			continue
+		}
+		switch {
+		case key == "min" && schema.Type == "string":
+			schema.MinLength = &n
+		case key == "max" && schema.Type == "string":
+			schema.MaxLength = &n
+		case key == "min" && schema.Type == "array":
+			schema.MinItems = &n
+		case key == "max" && schema.Type == "array":
+			schema.MaxItems = &n
+		case key == "min":
+			schema.Minimum = &n
+		case key == "max":
+			schema.Maximum = &n
+		}
+	}
+	return required
+}
@@ -0,0 +1,97 @@
+// Package pantry matches recipe ingredients against the ingredients a user has
+package pantry
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/TranQuocToan1996/ginProject/ingredients"
+	"github.com/TranQuocToan1996/ginProject/models"
+	"github.com/TranQuocToan1996/ginProject/shopping"
+)
+
+// Words of the ingredients assumed to be in every kitchen, such as "salt and
+// freshly ground black pepper to taste"
+var stapleWords = map[string]bool{
+	"salt": true, "pepper": true, "black": true, "ground": true, "kosher": true,
+	"sea": true, "water": true, "ice": true, "and": true, "or": true,
+	"to": true, "taste": true, "needed": true, "as": true, "for": true,
+}
+
+// Key returns the normalized name of an ingredient line, or of a pantry item
+// when line has no quantity
+func Key(line string) string {
+	name := line
+	if ingredient, err := ingredients.Parse(line); err == nil {
+		name = ingredient.Name
+	}
+	return shopping.Key(name)
+}
+
+// IsStaple reports whether an ingredient line only needs staples such as salt,
+// pepper or water
+func IsStaple(line string) bool {
+	words := strings.Fields(Key(line))
+	for _, word := range words {
+		if !stapleWords[word] {
+			return false
+		}
+	}
+	return len(words) > 0
+}
+
+// Match reports whether one of the pantry keys covers the ingredient key
+// need. A key covers need when it is need or its last words, as the words
+// before only describe the ingredient: "chicken breast" covers "boneless
+// skinless chicken breast", but "chicken" covers neither "chicken breast" nor
+// "chicken broth".
+func Match(have []string, need string) bool {
+	padded := " " + need
+	for _, key := range have {
+		if key == "" {
+			continue
+		}
+		if strings.HasSuffix(padded, " "+key) {
+			return true
+		}
+	}
+	return false
+}
+
+// Rank scores recipes by the share of their ingredients found in have, a
+// list of pantry keys. Staples count as available unless countStaples is
+// set. The best recipes come first, ties going to the recipe missing fewer
+// ingredients.
+func Rank(recipes []models.Recipe, have []string, countStaples bool) []models.CookableRecipe {
+	result := make([]models.CookableRecipe, 0, len(recipes))
+	for _, recipe := range recipes {
+		cookable := models.CookableRecipe{Recipe: recipe, Missing: make([]string, 0)}
+		for _, line := range recipe.Ingredients {
+			if ingredients.IsSeparator(line) {
+				continue
+			}
+			if !countStaples && IsStaple(line) {
+				continue
+			}
+			cookable.Total++
+			if Match(have, Key(line)) {
+				cookable.Available++
+			} else {
+				cookable.Missing = append(cookable.Missing, strings.TrimSpace(line))
+			}
+		}
+		if cookable.Total > 0 {
+			cookable.Score = math.Round(float64(cookable.Available)/float64(cookable.Total)*100) / 100
+		}
+		result = append(result, cookable)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		return len(a.Missing) < len(b.Missing)
+	})
+	return result
+}
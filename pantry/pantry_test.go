@@ -0,0 +1,75 @@
+package pantry
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/TranQuocToan1996/ginProject/models"
+)
+
+func TestMatch(t *testing.T) {
+	have := []string{Key("chicken breasts"), Key("Eggs"), Key("olive oil"), Key("chicken"), Key("salt")}
+	for i, tt := range []struct {
+		line string
+		want bool
+	}{
+		{"2 boneless skinless chicken breasts", true},
+		{"1 pound chicken", true},
+		{"2 cups chicken broth", false},
+		{"2 tablespoons unsalted butter", false},
+		{"3 large eggs, beaten", true},
+		{"2 tablespoons extra virgin olive oil", true},
+		{"1 cup eggplant", false},
+		{"4 chicken thighs", false},
+	} {
+		t.Run(fmt.Sprintf("%v", i), func(t *testing.T) {
+			if result := Match(have, Key(tt.line)); result != tt.want {
+				t.Errorf("want %v; got %v", tt.want, result)
+			}
+		})
+	}
+}
+
+func TestIsStaple(t *testing.T) {
+	for i, tt := range []struct {
+		line string
+		want bool
+	}{
+		{"Salt and freshly ground black pepper to taste", true},
+		{"1 cup water", true},
+		{"1 teaspoon kosher salt", true},
+		{"1 cup salted butter", false},
+		{"<hr>", false},
+	} {
+		t.Run(fmt.Sprintf("%v", i), func(t *testing.T) {
+			if result := IsStaple(tt.line); result != tt.want {
+				t.Errorf("want %v; got %v", tt.want, result)
+			}
+		})
+	}
+}
+
+func TestRank(t *testing.T) {
+	recipes := []models.Recipe{
+		{Name: "Cake", Ingredients: []string{"2 cups flour", "2 eggs", "1 cup sugar", "1 cup milk"}},
+		{Name: "Omelette", Ingredients: []string{"3 eggs", "1 tablespoon butter", "Salt to taste"}},
+		{Name: "Scrambled eggs", Ingredients: []string{"2 eggs", "Salt and pepper to taste"}},
+	}
+	ranked := Rank(recipes, []string{Key("eggs"), Key("flour")}, false)
+	want := []struct {
+		name    string
+		score   float64
+		missing int
+	}{
+		{"Scrambled eggs", 1, 0},
+		// Tied with Cake, but missing fewer ingredients
+		{"Omelette", 0.5, 1},
+		{"Cake", 0.5, 2},
+	}
+	for i, w := range want {
+		r := ranked[i]
+		if r.Recipe.Name != w.name || r.Score != w.score || len(r.Missing) != w.missing {
+			t.Errorf("%v: want %v %v %v; got %v %v %v", i, w.name, w.score, w.missing, r.Recipe.Name, r.Score, r.Missing)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+// Package patch applies JSON Merge Patch (RFC 7396) and JSON Patch (RFC 6902)
+// documents to the editable content of recipes
+package patch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/TranQuocToan1996/ginProject/models"
+	jsonpatch "github.com/evanphx/json-patch/v5"
+)
+
+// Media types of the patch documents
+const (
+	MergePatch = "application/merge-patch+json"
+	JSONPatch  = "application/json-patch+json"
+)
+
+var (
+	ErrMediaType = errors.New("patch must be " + MergePatch + " or " + JSONPatch)
+	// ErrInvalid is returned for documents that aren't a patch
+	ErrInvalid = errors.New("invalid patch document")
+	// ErrTestFailed is returned when a test operation of a JSON Patch fails
+	ErrTestFailed = jsonpatch.ErrTestFailed
+)
+
+// Fields are the fields of a recipe a patch can change
+var Fields = []string{"name", "tags", "ingredients", "instructions", "servings"}
+
+// Error is a patch that can't be applied to the content of a recipe
+type Error struct {
+	Err error
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// document is the content a patch applies to. Its servings are kept when
+// zero, so that a JSON Patch can replace them on any recipe.
+type document struct {
+	models.RecipeContent
+	Servings int `json:"servings"`
+}
+
+// Apply returns content patched by doc, a patch document of mediaType. Any
+// field outside of Fields makes the patch fail.
+func Apply(content models.RecipeContent, mediaType string, doc []byte) (models.RecipeContent, error) {
+	original, err := json.Marshal(document{content, content.Servings})
+	if err != nil {
+		return content, err
+	}
+	var patched []byte
+	switch mediaType {
+	case MergePatch:
+		if !json.Valid(doc) || !bytes.HasPrefix(bytes.TrimSpace(doc), []byte("{")) {
+			return content, ErrInvalid
+		}
+		patched, err = jsonpatch.MergePatch(original, doc)
+	case JSONPatch:
+		var operations jsonpatch.Patch
+		if operations, err = jsonpatch.DecodePatch(doc); err != nil {
+			return content, ErrInvalid
+		}
+		patched, err = operations.Apply(original)
+	default:
+		return content, ErrMediaType
+	}
+	if err != nil {
+		return content, &Error{err}
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(patched, &fields); err != nil {
+		return content, &Error{err}
+	}
+	for field := range fields {
+		if !contains(Fields, field) {
+			return content, &Error{fmt.Errorf("field %v can't be patched, only %v", field, strings.Join(Fields, ", "))}
+		}
+	}
+	var result document
+	if err := json.Unmarshal(patched, &result); err != nil {
+		return content, &Error{err}
+	}
+	result.RecipeContent.Servings = result.Servings
+	return result.RecipeContent, nil
+}
+
+// Touched returns the fields that differ between two contents, in the order of Fields
+func Touched(before, after models.RecipeContent) []string {
+	touched := make([]string, 0)
+	for _, field := range Fields {
+		if !reflect.DeepEqual(Value(before, field), Value(after, field)) {
+			touched = append(touched, field)
+		}
+	}
+	return touched
+}
+
+// Value returns a field of content by its json name
+func Value(content models.RecipeContent, field string) interface{} {
+	switch field {
+	case "name":
+		return content.Name
+	case "tags":
+		return content.Tags
+	case "ingredients":
+		return content.Ingredients
+	case "instructions":
+		return content.Instructions
+	case "servings":
+		return content.Servings
+	}
+	return nil
+}
+
+func contains(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
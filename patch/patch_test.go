@@ -0,0 +1,103 @@
+package patch
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/TranQuocToan1996/ginProject/models"
+)
+
+func content() models.RecipeContent {
+	return models.RecipeContent{
+		Name:         "Pancakes",
+		Tags:         []string{"breakfast"},
+		Ingredients:  []string{"2 eggs", "1 cup flour"},
+		Instructions: []models.Instruction{{Step: 1, Text: "Mix"}, {Step: 2, Text: "Fry"}},
+		Servings:     2,
+	}
+}
+
+func TestApply(t *testing.T) {
+	for i, tt := range []struct {
+		mediaType string
+		doc       string
+		want      func(c *models.RecipeContent)
+		err       error
+	}{
+		{MergePatch, `{"name": "Crepes"}`, func(c *models.RecipeContent) { c.Name = "Crepes" }, nil},
+		{MergePatch, `{"servings": null, "tags": ["sweet"]}`, func(c *models.RecipeContent) {
+			c.Servings = 0
+			c.Tags = []string{"sweet"}
+		}, nil},
+		{MergePatch, `{"instructions": ["Whisk", "Fry"]}`, func(c *models.RecipeContent) {
+			c.Instructions = []models.Instruction{{Text: "Whisk"}, {Text: "Fry"}}
+		}, nil},
+		{MergePatch, `{"rating": {"average": 5}}`, nil, &Error{}},
+		{MergePatch, `[]`, nil, ErrInvalid},
+		{JSONPatch, `[{"op": "add", "path": "/ingredients/-", "value": "1 cup milk"}]`, func(c *models.RecipeContent) {
+			c.Ingredients = append(c.Ingredients, "1 cup milk")
+		}, nil},
+		{JSONPatch, `[{"op": "test", "path": "/name", "value": "Pancakes"}, {"op": "replace", "path": "/instructions/0/text", "value": "Whisk"}]`, func(c *models.RecipeContent) {
+			c.Instructions[0].Text = "Whisk"
+		}, nil},
+		{JSONPatch, `[{"op": "test", "path": "/name", "value": "Waffles"}]`, nil, ErrTestFailed},
+		{JSONPatch, `[{"op": "add", "path": "/version", "value": 9}]`, nil, &Error{}},
+		{JSONPatch, `{"op": "remove"}`, nil, ErrInvalid},
+		{"application/json", `{}`, nil, ErrMediaType},
+	} {
+		t.Run(fmt.Sprintf("%v", i), func(t *testing.T) {
+			result, err := Apply(content(), tt.mediaType, []byte(tt.doc))
+			if tt.err != nil {
+				var patchErr *Error
+				if _, ok := tt.err.(*Error); ok && !errors.As(err, &patchErr) {
+					t.Fatalf("want a patch error; got %v", err)
+				}
+				if _, ok := tt.err.(*Error); !ok && !errors.Is(err, tt.err) {
+					t.Fatalf("want error %v; got %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := content()
+			tt.want(&want)
+			if !reflect.DeepEqual(result, want) {
+				t.Errorf("want %+v; got %+v", want, result)
+			}
+		})
+	}
+}
+
+func TestApplyZeroServings(t *testing.T) {
+	zero := content()
+	zero.Servings = 0
+	for i, doc := range []string{
+		`[{"op": "replace", "path": "/servings", "value": 4}]`,
+		`[{"op": "test", "path": "/servings", "value": 0}, {"op": "replace", "path": "/servings", "value": 4}]`,
+	} {
+		t.Run(fmt.Sprintf("%v", i), func(t *testing.T) {
+			result, err := Apply(zero, JSONPatch, []byte(doc))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Servings != 4 {
+				t.Errorf("want 4 servings; got %v", result.Servings)
+			}
+		})
+	}
+}
+
+func TestTouched(t *testing.T) {
+	after := content()
+	after.Ingredients = []string{"1 cup flour", "2 eggs"}
+	after.Servings = 4
+	if got, want := Touched(content(), after), []string{"ingredients", "servings"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v; got %v", want, got)
+	}
+	if got := Touched(content(), content()); len(got) != 0 {
+		t.Errorf("want no field; got %v", got)
+	}
+}
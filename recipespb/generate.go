@@ -0,0 +1,4 @@
+// Package recipespb holds the protocol buffers of the gRPC RecipeService
+package recipespb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative recipes.proto
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/TranQuocToan1996/ginProject/apierrors"
+	"github.com/TranQuocToan1996/ginProject/apiversion"
+	"github.com/TranQuocToan1996/ginProject/openapi"
+	"github.com/gin-gonic/gin"
+)
+
+// v1 is deprecated since the release of v2 and removed at its sunset
+var (
+	v1DeprecatedAt = time.Date(2026, time.November, 1, 0, 0, 0, 0, time.UTC)
+	v1Sunset       = time.Date(2027, time.May, 1, 0, 0, 0, 0, time.UTC)
+)
+
+// document is the OpenAPI document of the routes, built by registerRoutes
+var document *openapi.Document
+
+// registerRoutes serves v1 at /v1 and at the root for the clients predating
+// versions, and v2 at /v2. It fails when a route and its documentation diverge.
+func registerRoutes(router *gin.Engine) error {
+	router.GET("/", home)
+	router.GET("/errors", apierrors.ListCatalog)
+	router.GET("/openapi.json", serveOpenAPI)
+	router.GET("/docs", openapi.DocsPage)
+	router.GET("/docs/assets/*file", openapi.DocsAsset)
+	router.POST("/graphql", authHandler.AuthMiddleware_session(), graphQLHandler.Query)
+
+	deprecated := apiversion.Deprecated(v1DeprecatedAt, v1Sunset, "/v2")
+	root := router.Group("/")
+	root.Use(deprecated)
+	v1Routes(root)
+	v1 := router.Group("/v1")
+	v1.Use(deprecated)
+	v1Routes(v1)
+
+	v2 := router.Group("/v2")
+	v2.Use(apiversion.Enveloped("v2"))
+	v2Routes(v2)
+
+	var err error
+	document, err = apiDocument(router.Routes())
+	return err
+}
+
+// home greets the clients of the root path
+func home(c *gin.Context) {
+	c.JSON(200, gin.H{
+		"message": "hello world",
+	})
+}
+
+// serveOpenAPI returns the OpenAPI document of the API
+func serveOpenAPI(c *gin.Context) {
+	c.JSON(http.StatusOK, document)
+}
+
+// v1Routes registers the routes of v1, whose responses are the bare resources
+func v1Routes(group *gin.RouterGroup) {
+	group.GET("/recipes/search", recipesHandler.SearchRecipes)
+	group.GET("/recipes/search/:id", recipesHandler.SearchRecipeById)
+	group.GET("/recipes/:id/scaled", recipesHandler.ScaleRecipe)
+	group.GET("/recipes/:id/nutrition", recipesHandler.GetNutrition)
+	group.GET("/recipes/:id/reviews", reviewsHandler.ListReviews)
+	group.GET("/recipes/:id/substitutions", substitutionsHandler.RecipeSubstitutions)
+	group.GET("/shared/collections/:token", collectionsHandler.SharedCollection)
+	group.GET("/images/*key", imagesHandler.ServeImage)
+	group.POST("/signin", authHandler.SignInHandler)
+	group.POST("/signup", authHandler.RegisterAccount)
+	group.POST("/refresh", authHandler.RefreshToken)
+	group.POST("/signout", authHandler.SignOut)
+
+	authorized := group.Group("/")
+	authorized.Use(authHandler.AuthMiddleware_session())
+	{
+		authorized.POST("/recipes", recipesHandler.AddNewRecipe)
+		authorized.GET("/recipes", recipesHandler.ListRecipes)
+		authorized.PUT("/recipes/:id", recipesHandler.UpdateRecipes)
+		authorized.PATCH("/recipes/:id", recipesHandler.PatchRecipe)
+		authorized.DELETE("/recipes/:id", recipesHandler.DeleteRecipes)
+		authorized.POST("/recipes/:id/restore", recipesHandler.RestoreRecipe)
+		authorized.PUT("/recipes/:id/status", recipesHandler.SetRecipeStatus)
+		authorized.GET("/trash", recipesHandler.ListTrash)
+		authorized.POST("/recipes/bulk", recipesHandler.BulkRecipes)
+		authorized.POST("/recipes/:id/images", imagesHandler.UploadImage)
+		authorized.POST("/recipes/:id/steps", recipesHandler.InsertStep)
+		authorized.PUT("/recipes/:id/steps/order", recipesHandler.ReorderSteps)
+		authorized.DELETE("/recipes/:id/steps/:step", recipesHandler.RemoveStep)
+		authorized.GET("/recipes/:id/revisions", recipesHandler.ListRevisions)
+		authorized.GET("/recipes/:id/revisions/:rev", recipesHandler.GetRevision)
+		authorized.GET("/recipes/:id/revisions/:rev/diff", recipesHandler.DiffRevisions)
+		authorized.POST("/recipes/:id/revert/:rev", recipesHandler.RevertRecipe)
+		authorized.POST("/recipes/:id/reviews", reviewsHandler.AddReview)
+		authorized.PUT("/recipes/:id/reviews", reviewsHandler.UpdateReview)
+		authorized.DELETE("/recipes/:id/reviews", reviewsHandler.DeleteReview)
+		authorized.POST("/recipes/:id/favorite", collectionsHandler.AddFavorite)
+		authorized.DELETE("/recipes/:id/favorite", collectionsHandler.RemoveFavorite)
+		authorized.GET("/favorites", collectionsHandler.ListFavorites)
+		authorized.GET("/collections", collectionsHandler.ListCollections)
+		authorized.POST("/collections", collectionsHandler.AddCollection)
+		authorized.GET("/collections/:collectionId", collectionsHandler.GetCollection)
+		authorized.PUT("/collections/:collectionId", collectionsHandler.RenameCollection)
+		authorized.DELETE("/collections/:collectionId", collectionsHandler.DeleteCollection)
+		authorized.POST("/collections/:collectionId/recipes", collectionsHandler.AddCollectionRecipe)
+		authorized.DELETE("/collections/:collectionId/recipes/:recipeId", collectionsHandler.RemoveCollectionRecipe)
+		authorized.POST("/collections/:collectionId/share", collectionsHandler.ShareCollection)
+		authorized.DELETE("/collections/:collectionId/share", collectionsHandler.UnshareCollection)
+		authorized.GET("/mealplans/:week", mealPlansHandler.GetMealPlan)
+		authorized.GET("/mealplans/:week/calendar.ics", mealPlansHandler.MealPlanCalendar)
+		authorized.POST("/mealplans/:week/copy", mealPlansHandler.CopyWeek)
+		authorized.PUT("/mealplans/:week/:day/:slot", mealPlansHandler.SetMeal)
+		authorized.PATCH("/mealplans/:week/:day/:slot", mealPlansHandler.SetMealServings)
+		authorized.DELETE("/mealplans/:week/:day/:slot", mealPlansHandler.RemoveMeal)
+		authorized.POST("/shopping-lists", shoppingListsHandler.AddShoppingList)
+		authorized.GET("/shopping-lists", shoppingListsHandler.ListShoppingLists)
+		authorized.GET("/shopping-lists/:listId", shoppingListsHandler.GetShoppingList)
+		authorized.GET("/shopping-lists/:listId/export", shoppingListsHandler.ExportShoppingList)
+		authorized.PATCH("/shopping-lists/:listId/items/:itemId", shoppingListsHandler.CheckShoppingItem)
+		authorized.DELETE("/shopping-lists/:listId", shoppingListsHandler.DeleteShoppingList)
+		authorized.GET("/pantry", pantryHandler.GetPantry)
+		authorized.POST("/pantry/items", pantryHandler.AddPantryItems)
+		authorized.DELETE("/pantry/items/:name", pantryHandler.RemovePantryItem)
+		authorized.GET("/recipes/cookable", pantryHandler.CookableRecipes)
+	}
+
+	adminRoutes(group)
+}
+
+// v2Routes registers the routes of v2: the same handlers under RESTful paths,
+// their responses wrapped in an envelope
+func v2Routes(group *gin.RouterGroup) {
+	group.GET("/recipes/search", recipesHandler.SearchRecipes)
+	group.GET("/recipes/:id", recipesHandler.SearchRecipeById)
+	group.GET("/recipes/:id/scaled", recipesHandler.ScaleRecipe)
+	group.GET("/recipes/:id/nutrition", recipesHandler.GetNutrition)
+	group.GET("/recipes/:id/reviews", reviewsHandler.ListReviews)
+	group.GET("/recipes/:id/substitutions", substitutionsHandler.RecipeSubstitutions)
+	group.GET("/shared/collections/:token", collectionsHandler.SharedCollection)
+	group.GET("/images/*key", imagesHandler.ServeImage)
+	group.POST("/auth/signin", authHandler.SignInHandler)
+	group.POST("/auth/signup", authHandler.RegisterAccount)
+	group.POST("/auth/refresh", authHandler.RefreshToken)
+	group.POST("/auth/signout", authHandler.SignOut)
+
+	authorized := group.Group("/")
+	authorized.Use(authHandler.AuthMiddleware_session())
+	{
+		authorized.POST("/recipes", recipesHandler.AddNewRecipe)
+		authorized.GET("/recipes", recipesHandler.ListRecipes)
+		authorized.PUT("/recipes/:id", recipesHandler.UpdateRecipes)
+		authorized.PATCH("/recipes/:id", recipesHandler.PatchRecipe)
+		authorized.DELETE("/recipes/:id", recipesHandler.DeleteRecipes)
+		authorized.POST("/recipes/:id/restore", recipesHandler.RestoreRecipe)
+		authorized.PUT("/recipes/:id/status", recipesHandler.SetRecipeStatus)
+		authorized.GET("/trash", recipesHandler.ListTrash)
+		authorized.POST("/recipes/bulk", recipesHandler.BulkRecipes)
+		authorized.GET("/recipes/cookable", pantryHandler.CookableRecipes)
+		authorized.POST("/recipes/:id/images", imagesHandler.UploadImage)
+		authorized.POST("/recipes/:id/steps", recipesHandler.InsertStep)
+		authorized.PUT("/recipes/:id/steps/order", recipesHandler.ReorderSteps)
+		authorized.DELETE("/recipes/:id/steps/:step", recipesHandler.RemoveStep)
+		authorized.GET("/recipes/:id/revisions", recipesHandler.ListRevisions)
+		authorized.GET("/recipes/:id/revisions/:rev", recipesHandler.GetRevision)
+		authorized.GET("/recipes/:id/revisions/:rev/diff", recipesHandler.DiffRevisions)
+		authorized.POST("/recipes/:id/revisions/:rev/revert", recipesHandler.RevertRecipe)
+		authorized.POST("/recipes/:id/reviews", reviewsHandler.AddReview)
+		authorized.PUT("/recipes/:id/reviews", reviewsHandler.UpdateReview)
+		authorized.DELETE("/recipes/:id/reviews", reviewsHandler.DeleteReview)
+		authorized.PUT("/recipes/:id/favorite", collectionsHandler.AddFavorite)
+		authorized.DELETE("/recipes/:id/favorite", collectionsHandler.RemoveFavorite)
+		authorized.GET("/favorites", collectionsHandler.ListFavorites)
+		authorized.GET("/collections", collectionsHandler.ListCollections)
+		authorized.POST("/collections", collectionsHandler.AddCollection)
+		authorized.GET("/collections/:collectionId", collectionsHandler.GetCollection)
+		authorized.PATCH("/collections/:collectionId", collectionsHandler.RenameCollection)
+		authorized.DELETE("/collections/:collectionId", collectionsHandler.DeleteCollection)
+		authorized.POST("/collections/:collectionId/recipes", collectionsHandler.AddCollectionRecipe)
+		authorized.DELETE("/collections/:collectionId/recipes/:recipeId", collectionsHandler.RemoveCollectionRecipe)
+		authorized.PUT("/collections/:collectionId/share", collectionsHandler.ShareCollection)
+		authorized.DELETE("/collections/:collectionId/share", collectionsHandler.UnshareCollection)
+		authorized.GET("/mealplans/:week", mealPlansHandler.GetMealPlan)
+		authorized.GET("/mealplans/:week/calendar.ics", mealPlansHandler.MealPlanCalendar)
+		authorized.POST("/mealplans/:week/copy", mealPlansHandler.CopyWeek)
+		authorized.PUT("/mealplans/:week/:day/:slot", mealPlansHandler.SetMeal)
+		authorized.PATCH("/mealplans/:week/:day/:slot", mealPlansHandler.SetMealServings)
+		authorized.DELETE("/mealplans/:week/:day/:slot", mealPlansHandler.RemoveMeal)
+		authorized.POST("/shopping-lists", shoppingListsHandler.AddShoppingList)
+		authorized.GET("/shopping-lists", shoppingListsHandler.ListShoppingLists)
+		authorized.GET("/shopping-lists/:listId", shoppingListsHandler.GetShoppingList)
+		authorized.GET("/shopping-lists/:listId/export", shoppingListsHandler.ExportShoppingList)
+		authorized.PATCH("/shopping-lists/:listId/items/:itemId", shoppingListsHandler.CheckShoppingItem)
+		authorized.DELETE("/shopping-lists/:listId", shoppingListsHandler.DeleteShoppingList)
+		authorized.GET("/pantry", pantryHandler.GetPantry)
+		authorized.POST("/pantry/items", pantryHandler.AddPantryItems)
+		authorized.DELETE("/pantry/items/:name", pantryHandler.RemovePantryItem)
+	}
+
+	adminRoutes(group)
+}
+
+// adminRoutes registers the routes of the administrators, the same in every version
+func adminRoutes(group *gin.RouterGroup) {
+	admin := group.Group("/admin")
+	admin.Use(authHandler.AuthMiddleware_session(), authHandler.AdminMiddleware())
+	{
+		admin.GET("/reviews", reviewsHandler.ListAllReviews)
+		admin.PUT("/reviews/:reviewId/moderation", reviewsHandler.ModerateReview)
+		admin.DELETE("/reviews/:reviewId", reviewsHandler.RemoveReview)
+		admin.POST("/recipes/normalize", recipesHandler.NormalizeRecipes)
+		admin.GET("/substitutions", substitutionsHandler.ListSubstitutions)
+		admin.POST("/substitutions", substitutionsHandler.AddSubstitution)
+		admin.PUT("/substitutions/:subId", substitutionsHandler.UpdateSubstitution)
+		admin.DELETE("/substitutions/:subId", substitutionsHandler.DeleteSubstitution)
+	}
+}
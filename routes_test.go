@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/TranQuocToan1996/ginProject/openapi"
+	"github.com/gin-gonic/gin"
+)
+
+func TestOpenAPIDocument(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	if err := registerRoutes(router); err != nil {
+		t.Fatal(err)
+	}
+
+	aliases := make(map[string]bool)
+	for _, route := range router.Routes() {
+		if strings.HasPrefix(route.Path, "/v1/") {
+			aliases[route.Method+" "+strings.TrimPrefix(route.Path, "/v1")] = true
+		}
+	}
+	documented := 0
+	for _, route := range router.Routes() {
+		if aliases[route.Method+" "+route.Path] {
+			continue
+		}
+		item, ok := document.Paths[openapi.Path(route.Path)]
+		if !ok || item[strings.ToLower(route.Method)] == nil {
+			t.Errorf("route %v %v isn't in the document", route.Method, route.Path)
+			continue
+		}
+		documented++
+	}
+
+	ids := make(map[string]bool)
+	count := 0
+	for path, item := range document.Paths {
+		for method, operation := range item {
+			count++
+			if ids[operation.OperationID] {
+				t.Errorf("duplicate operationId %v", operation.OperationID)
+			}
+			ids[operation.OperationID] = true
+			if operation.Summary == "" {
+				t.Errorf("%v %v has no summary", method, path)
+			}
+		}
+	}
+	if count != documented {
+		t.Errorf("want %v operations, one per route; got %v", documented, count)
+	}
+
+	data, err := json.Marshal(document)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, ref := range strings.Split(string(data), `"$ref":"#/components/schemas/`)[1:] {
+		name := ref[:strings.Index(ref, `"`)]
+		if _, ok := document.Components.Schemas[name]; !ok {
+			t.Errorf("schema %v is referenced but not defined", name)
+		}
+	}
+}
@@ -0,0 +1,304 @@
+// Package shopping merges the ingredients of recipes into a shopping list
+package shopping
+
+import (
+	_ "embed"
+	"encoding/json"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/TranQuocToan1996/ginProject/ingredients"
+	"github.com/TranQuocToan1996/ginProject/models"
+	"github.com/TranQuocToan1996/ginProject/units"
+)
+
+// aisles.json lists the store sections in the order items are listed, with
+// the ingredient names found in each one
+//
+//go:embed aisles.json
+var aislesJSON []byte
+
+type aisle struct {
+	Name     string   `json:"name"`
+	Keywords []string `json:"keywords"`
+}
+
+var aisles []aisle
+
+// Other is the category of the items that don't match any aisle
+const Other = "Other"
+
+func init() {
+	if err := json.Unmarshal(aislesJSON, &aisles); err != nil {
+		panic("shopping: invalid aisles.json: " + err.Error())
+	}
+	for _, a := range aisles {
+		for i, keyword := range a.Keywords {
+			a.Keywords[i] = ingredients.NormalizeName(keyword)
+		}
+	}
+}
+
+// Words describing the size or preparation of an ingredient, which don't
+// change what is bought
+var descriptors = map[string]bool{
+	"large": true, "small": true, "medium": true, "fresh": true, "freshly": true,
+	"chopped": true, "minced": true, "diced": true, "sliced": true, "grated": true,
+	"shredded": true, "peeled": true, "finely": true, "coarsely": true, "thinly": true,
+	"roughly": true, "cubed": true, "crushed": true, "softened": true, "melted": true,
+	"boneless": true, "skinless": true, "whole": true, "about": true, "of": true,
+}
+
+// Source is a recipe to shop for. Its quantities are multiplied by Factor,
+// which defaults to 1, to shop for a different number of servings.
+type Source struct {
+	Recipe string
+	Lines  []string
+	Factor float64
+}
+
+// item accumulates the quantities of one ingredient measured in one kind of
+// unit, in millilitres or grams for volumes and weights
+type item struct {
+	name     string
+	key      string
+	kind     string
+	quantity float64
+	system   units.System
+	recipes  []string
+}
+
+// Build merges the ingredient lines of sources into shopping items. The same
+// ingredient in several recipes becomes one item, its quantities added up
+// after converting them to a common unit.
+func Build(sources []Source) []models.ShoppingItem {
+	merged := make(map[string]*item)
+	order := make([]*item, 0)
+	for _, source := range sources {
+		factor := source.Factor
+		if factor <= 0 {
+			factor = 1
+		}
+		for _, line := range source.Lines {
+			if ingredients.IsSeparator(line) {
+				continue
+			}
+			name, kind, quantity, system := measure(line, factor)
+			key := Key(name)
+			if key == "" {
+				continue
+			}
+			it, ok := merged[key+"|"+kind]
+			if !ok {
+				it = &item{name: displayName(name), key: key, kind: kind, system: system}
+				merged[key+"|"+kind] = it
+				order = append(order, it)
+			}
+			it.quantity += quantity
+			if !contains(it.recipes, source.Recipe) {
+				it.recipes = append(it.recipes, source.Recipe)
+			}
+		}
+	}
+
+	result := make([]models.ShoppingItem, 0, len(order))
+	for _, it := range order {
+		result = append(result, it.shoppingItem())
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		a, b := categoryIndex(result[i].Category), categoryIndex(result[j].Category)
+		if a != b {
+			return a < b
+		}
+		return result[i].Name < result[j].Name
+	})
+	for i := range result {
+		result[i].ID = strconv.Itoa(i + 1)
+	}
+	return result
+}
+
+// measure reads the name of the ingredient of line and its quantity, in base
+// units for volumes and weights
+func measure(line string, factor float64) (name, kind string, quantity float64, system units.System) {
+	ingredient, err := ingredients.Parse(line)
+	if err != nil {
+		return line, "none", 0, ""
+	}
+	quantity = ingredient.Quantity
+	if ingredient.QuantityMax > 0 {
+		// Buy enough for the top of the range
+		quantity = ingredient.QuantityMax
+	}
+	quantity *= factor
+	if unit, ok := units.Lookup(ingredient.Unit); ok && ingredient.Unit != "" {
+		kind = "volume"
+		if unit.Kind == units.Weight {
+			kind = "weight"
+		}
+		return ingredient.Name, kind, quantity * unit.ToBase, unit.System
+	}
+	return ingredient.Name, "count", quantity, ""
+}
+
+// Key reduces an ingredient name to the words that identify what to buy, so
+// "2 large eggs" and "1 egg, beaten" are merged
+func Key(name string) string {
+	words := strings.Fields(ingredients.NormalizeName(name))
+	kept := words[:0]
+	for _, word := range words {
+		if !descriptors[word] {
+			kept = append(kept, word)
+		}
+	}
+	return strings.Join(kept, " ")
+}
+
+// Category returns the store aisle of an ingredient, the aisle whose longest
+// keyword appears in its name
+func Category(name string) string {
+	text := " " + Key(name) + " "
+	best, length := Other, 0
+	for _, a := range aisles {
+		for _, keyword := range a.Keywords {
+			if len(keyword) > length && strings.Contains(text, " "+keyword+" ") {
+				best, length = a.Name, len(keyword)
+			}
+		}
+	}
+	return best
+}
+
+// Categories returns the names of the aisles in the order items are listed
+func Categories() []string {
+	names := make([]string, 0, len(aisles)+1)
+	for _, a := range aisles {
+		names = append(names, a.Name)
+	}
+	return append(names, Other)
+}
+
+func categoryIndex(name string) int {
+	for i, a := range aisles {
+		if a.Name == name {
+			return i
+		}
+	}
+	return len(aisles)
+}
+
+func (it *item) shoppingItem() models.ShoppingItem {
+	result := models.ShoppingItem{
+		Name:     it.name,
+		Category: Category(it.key),
+		Recipes:  it.recipes,
+	}
+	switch it.kind {
+	case "volume", "weight":
+		base := units.Milliliter
+		if it.kind == "weight" {
+			base = units.Gram
+		}
+		system := it.system
+		if system == "" {
+			system = units.Metric
+		}
+		quantity, unit := units.Best(it.quantity, base, system)
+		if system == units.US {
+			quantity = math.Max(math.Round(quantity*8)/8, 1.0/8)
+			result.Display = ingredients.FormatQuantity(quantity) + " " + unit.Name + " " + it.name
+		} else {
+			quantity = units.Round(quantity, unit)
+			result.Display = strconv.FormatFloat(quantity, 'f', -1, 64) + " " + unit.Name + " " + it.name
+		}
+		result.Quantity, result.Unit = quantity, unit.Name
+	case "count":
+		result.Quantity = math.Round(it.quantity*100) / 100
+		result.Display = ingredients.FormatQuantity(it.quantity) + " " + it.name
+	default:
+		result.Display = it.name
+	}
+	return result
+}
+
+// displayName cleans an ingredient name for the list, dropping the
+// preparation after a comma and parenthesized notes
+func displayName(name string) string {
+	name, _, _ = strings.Cut(name, ",")
+	for {
+		open := strings.Index(name, "(")
+		close := strings.Index(name, ")")
+		if open < 0 || close < open {
+			break
+		}
+		name = name[:open] + name[close+1:]
+	}
+	return strings.Join(strings.Fields(strings.ToLower(name)), " ")
+}
+
+func contains(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Text renders a list as plain text, items grouped by aisle with a box that
+// is ticked once checked
+func Text(list models.ShoppingList) string {
+	var b strings.Builder
+	b.WriteString(list.Name + "\n")
+	for _, group := range groups(list.Items) {
+		b.WriteString("\n" + group.name + "\n")
+		for _, it := range group.items {
+			box := "[ ]"
+			if it.Checked {
+				box = "[x]"
+			}
+			b.WriteString(box + " " + it.Display + "\n")
+		}
+	}
+	return b.String()
+}
+
+// Markdown renders a list as a Markdown task list with a heading per aisle
+func Markdown(list models.ShoppingList) string {
+	var b strings.Builder
+	b.WriteString("# " + list.Name + "\n")
+	for _, group := range groups(list.Items) {
+		b.WriteString("\n## " + group.name + "\n\n")
+		for _, it := range group.items {
+			box := "[ ]"
+			if it.Checked {
+				box = "[x]"
+			}
+			b.WriteString("- " + box + " " + it.Display + "\n")
+		}
+	}
+	return b.String()
+}
+
+type group struct {
+	name  string
+	items []models.ShoppingItem
+}
+
+func groups(items []models.ShoppingItem) []group {
+	result := make([]group, 0)
+	for _, name := range Categories() {
+		g := group{name: name}
+		for _, it := range items {
+			if it.Category == name || (name == Other && categoryIndex(it.Category) == len(aisles)) {
+				g.items = append(g.items, it)
+			}
+		}
+		if len(g.items) > 0 {
+			result = append(result, g)
+		}
+	}
+	return result
+}
@@ -0,0 +1,72 @@
+package shopping
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/TranQuocToan1996/ginProject/models"
+)
+
+func TestCategory(t *testing.T) {
+	for i, tt := range []struct {
+		in  string
+		out string
+	}{
+		{"yellow onions, diced", "Produce"},
+		{"boneless skinless chicken breasts", "Meat & Seafood"},
+		{"large eggs", "Dairy & Eggs"},
+		{"black pepper", "Spices & Seasonings"},
+		{"red bell pepper", "Produce"},
+		{"extra-virgin olive oil", "Pantry"},
+		{"sumac", Other},
+	} {
+		t.Run(fmt.Sprintf("%v", i), func(t *testing.T) {
+			if result := Category(tt.in); result != tt.out {
+				t.Errorf("want %v; got %v", tt.out, result)
+			}
+		})
+	}
+}
+
+func TestBuild(t *testing.T) {
+	items := Build([]Source{
+		{Recipe: "Omelette", Lines: []string{"2 large eggs", "1 tablespoon butter", "Salt to taste", "<hr>"}},
+		{Recipe: "Cake", Factor: 2, Lines: []string{"1 egg, beaten", "1/2 cup butter", "1 cup flour", "salt to taste"}},
+		{Recipe: "Soup", Lines: []string{"500 g potatoes", "0.5 kg potatoes"}},
+	})
+	want := map[string]models.ShoppingItem{
+		"large eggs": {Display: "4 large eggs", Category: "Dairy & Eggs", Recipes: []string{"Omelette", "Cake"}},
+		"butter":     {Display: "1 1/8 cup butter", Category: "Dairy & Eggs", Recipes: []string{"Omelette", "Cake"}},
+		"flour":      {Display: "2 cup flour", Category: "Pantry", Recipes: []string{"Cake"}},
+		"salt to taste": {Display: "salt to taste", Category: "Spices & Seasonings",
+			Recipes: []string{"Omelette", "Cake"}},
+		"potatoes": {Display: "1 kg potatoes", Category: "Produce", Recipes: []string{"Soup"}},
+	}
+	if len(items) != len(want) {
+		t.Fatalf("want %v items; got %v", len(want), items)
+	}
+	for _, it := range items {
+		w, ok := want[it.Name]
+		if !ok {
+			t.Errorf("unexpected item %v", it.Name)
+			continue
+		}
+		if it.Display != w.Display || it.Category != w.Category || fmt.Sprint(it.Recipes) != fmt.Sprint(w.Recipes) {
+			t.Errorf("want %v %v %v; got %v %v %v", w.Display, w.Category, w.Recipes, it.Display, it.Category, it.Recipes)
+		}
+	}
+	if items[0].Category != "Produce" || items[0].ID != "1" {
+		t.Errorf("want items ordered by aisle and numbered; got %v", items[0])
+	}
+}
+
+func TestMarkdown(t *testing.T) {
+	list := models.ShoppingList{Name: "Week", Items: []models.ShoppingItem{
+		{Display: "2 onions", Category: "Produce", Checked: true},
+		{Display: "sumac", Category: Other},
+	}}
+	want := "# Week\n\n## Produce\n\n- [x] 2 onions\n\n## Other\n\n- [ ] sumac\n"
+	if result := Markdown(list); result != want {
+		t.Errorf("want %q; got %q", want, result)
+	}
+}
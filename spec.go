@@ -0,0 +1,205 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/TranQuocToan1996/ginProject/apierrors"
+	"github.com/TranQuocToan1996/ginProject/apiversion"
+	"github.com/TranQuocToan1996/ginProject/handlers"
+	"github.com/TranQuocToan1996/ginProject/models"
+	"github.com/TranQuocToan1996/ginProject/negotiate"
+	"github.com/TranQuocToan1996/ginProject/openapi"
+	"github.com/TranQuocToan1996/ginProject/patch"
+	"github.com/gin-gonic/gin"
+)
+
+// Message is the response of the handlers answering with a message only
+type Message struct {
+	Message string `json:"message"`
+}
+
+// Registration is the response of RegisterAccount
+type Registration struct {
+	Message    string `json:"message"`
+	InsertedID string `json:"insertedID"`
+}
+
+// ShareLink is the response of ShareCollection
+type ShareLink struct {
+	ShareToken string `json:"shareToken"`
+	URL        string `json:"url"`
+}
+
+// RevisionDiff is the response of DiffRevisions
+type RevisionDiff struct {
+	From    int                  `json:"from"`
+	To      int                  `json:"to"`
+	Changes []models.FieldChange `json:"changes"`
+}
+
+// Normalized is the response of NormalizeRecipes
+type Normalized struct {
+	Message string `json:"message"`
+	Updated int    `json:"updated"`
+}
+
+var (
+	unitsParam       = openapi.Query("units", "Unit system of the quantities: metric or us")
+	statusParam      = openapi.Query("status", "Comma separated statuses, published by default")
+	dietParam        = openapi.Query("diet", "Comma separated diet labels the recipes fit")
+	allergenParam    = openapi.Query("exclude_allergen", "Comma separated allergens the recipes are free of")
+	ifMatchParam     = openapi.Header("If-Match", "Entity tag of the current version of the recipe")
+	ifNoneMatchParam = openapi.Header("If-None-Match", "Entity tag the client holds, answered with 304 when unchanged")
+)
+
+// routeDocs documents the handlers served by registerRoutes, keyed by openapi.Name
+var routeDocs = map[string]openapi.Route{
+	openapi.Name(home):                  {Summary: "Greet", Tag: "meta", Response: Message{}},
+	openapi.Name(apierrors.ListCatalog): {Summary: "List the error codes", Tag: "meta", Response: apierrors.Catalog},
+	openapi.Name(serveOpenAPI):          {Summary: "Get this OpenAPI document", Tag: "meta", Response: json.RawMessage{}},
+	openapi.Name(openapi.DocsPage):      {Summary: "Browse this document", Tag: "meta", Produces: []string{"text/html"}},
+	openapi.Name(openapi.DocsAsset):     {Summary: "Get a file of the docs page", Tag: "meta", Produces: []string{"text/css", "application/javascript"}},
+
+	openapi.Name((*handlers.AuthHandler).SignInHandler):   {Summary: "Sign in, starting a session", Tag: "auth", Body: models.User{}, Response: Message{}},
+	openapi.Name((*handlers.AuthHandler).RegisterAccount): {Summary: "Register an account", Tag: "auth", Body: models.User{}, Response: Registration{}},
+	openapi.Name((*handlers.AuthHandler).RefreshToken): {Summary: "Refresh a JWT about to expire", Tag: "auth",
+		Params: []openapi.Parameter{openapi.Header("Authorization", "The JWT")}, Response: handlers.JWTOutput{}},
+	openapi.Name((*handlers.AuthHandler).SignOut): {Summary: "Sign out, ending the session", Tag: "auth", Response: Message{}},
+
+	openapi.Name((*handlers.RecipesHandler).ListRecipes): {Summary: "List recipes", Tag: "recipes", Auth: true,
+		Params:   []openapi.Parameter{unitsParam, statusParam, openapi.Query("sort", "Field to sort by"), openapi.Query("order", "asc or desc"), ifNoneMatchParam},
+		Response: []models.Recipe{}},
+	openapi.Name((*handlers.RecipesHandler).SearchRecipes): {Summary: "Search recipes", Tag: "recipes",
+		Params:   []openapi.Parameter{openapi.Query("tag", "Tag of the recipes"), dietParam, allergenParam, unitsParam, statusParam, ifNoneMatchParam},
+		Response: []models.Recipe{}},
+	openapi.Name((*handlers.RecipesHandler).SearchRecipeById): {Summary: "Get a recipe", Tag: "recipes",
+		Params: []openapi.Parameter{unitsParam, ifNoneMatchParam}, Response: models.Recipe{}},
+	openapi.Name((*handlers.RecipesHandler).AddNewRecipe): {Summary: "Create a recipe", Tag: "recipes", Auth: true,
+		Body: models.Recipe{}, Response: models.Recipe{}},
+	openapi.Name((*handlers.RecipesHandler).UpdateRecipes): {Summary: "Replace a recipe", Tag: "recipes", Auth: true,
+		Params: []openapi.Parameter{ifMatchParam}, Body: models.Recipe{}, Response: Message{}},
+	openapi.Name((*handlers.RecipesHandler).PatchRecipe): {Summary: "Patch a recipe", Tag: "recipes", Auth: true,
+		Params: []openapi.Parameter{ifMatchParam}, BodyTypes: []string{patch.MergePatch, patch.JSONPatch}, Response: models.Recipe{}},
+	openapi.Name((*handlers.RecipesHandler).DeleteRecipes): {Summary: "Move a recipe to the trash", Tag: "recipes", Auth: true,
+		Params: []openapi.Parameter{ifMatchParam}, Response: Message{}},
+	openapi.Name((*handlers.RecipesHandler).RestoreRecipe): {Summary: "Restore a recipe from the trash", Tag: "recipes", Auth: true, Response: models.Recipe{}},
+	openapi.Name((*handlers.RecipesHandler).ListTrash):     {Summary: "List the trash", Tag: "recipes", Auth: true, Response: []models.TrashedRecipe{}},
+	openapi.Name((*handlers.RecipesHandler).SetRecipeStatus): {Summary: "Change the status of a recipe", Tag: "recipes", Auth: true,
+		Params: []openapi.Parameter{ifMatchParam}, Body: handlers.StatusRequest{}, Response: models.Recipe{}},
+	openapi.Name((*handlers.RecipesHandler).NormalizeRecipes): {Summary: "Normalize the instructions of every recipe", Tag: "admin", Auth: true, Response: Normalized{}},
+	openapi.Name((*handlers.RecipesHandler).BulkRecipes): {Summary: "Create, update and delete recipes in a batch", Tag: "recipes", Auth: true,
+		Body: handlers.BulkRequest{}, Response: handlers.BulkResponse{}},
+	openapi.Name((*handlers.RecipesHandler).ScaleRecipe): {Summary: "Scale a recipe to a number of servings", Tag: "recipes",
+		Params: []openapi.Parameter{openapi.Query("servings", "Servings to scale to")}, Response: models.ScaledRecipe{}},
+	openapi.Name((*handlers.RecipesHandler).GetNutrition): {Summary: "Get the nutrition facts of a recipe", Tag: "recipes", Response: models.Nutrition{}},
+	openapi.Name((*handlers.RecipesHandler).InsertStep): {Summary: "Insert a step", Tag: "steps", Auth: true,
+		Params: []openapi.Parameter{ifMatchParam}, Body: handlers.StepRequest{}, Response: []models.Instruction{}},
+	openapi.Name((*handlers.RecipesHandler).ReorderSteps): {Summary: "Reorder the steps", Tag: "steps", Auth: true,
+		Params: []openapi.Parameter{ifMatchParam}, Body: handlers.ReorderRequest{}, Response: []models.Instruction{}},
+	openapi.Name((*handlers.RecipesHandler).RemoveStep): {Summary: "Remove a step", Tag: "steps", Auth: true,
+		Params: []openapi.Parameter{ifMatchParam}, Response: []models.Instruction{}},
+	openapi.Name((*handlers.RecipesHandler).ListRevisions): {Summary: "List the revisions of a recipe", Tag: "revisions", Auth: true, Response: []models.Revision{}},
+	openapi.Name((*handlers.RecipesHandler).GetRevision):   {Summary: "Get a revision", Tag: "revisions", Auth: true, Response: models.Revision{}},
+	openapi.Name((*handlers.RecipesHandler).DiffRevisions): {Summary: "Compare two revisions", Tag: "revisions", Auth: true,
+		Params: []openapi.Parameter{openapi.Query("against", "Revision to compare with, the previous one by default")}, Response: RevisionDiff{}},
+	openapi.Name((*handlers.RecipesHandler).RevertRecipe): {Summary: "Revert a recipe to a revision", Tag: "revisions", Auth: true,
+		Params: []openapi.Parameter{ifMatchParam}, Response: models.Recipe{}},
+
+	openapi.Name((*handlers.ImagesHandler).UploadImage): {Summary: "Add an image to a recipe", Tag: "images", Auth: true,
+		Body: handlers.Base64Image{}, Files: []string{"image"}, Status: http.StatusCreated, Response: models.RecipeImage{}},
+	openapi.Name((*handlers.ImagesHandler).ServeImage): {Summary: "Get an image", Tag: "images", Produces: []string{"image/jpeg", "image/png", "image/gif", "image/webp"}},
+
+	openapi.Name((*handlers.ReviewsHandler).ListReviews):    {Summary: "List the reviews of a recipe", Tag: "reviews", Response: []models.Review{}},
+	openapi.Name((*handlers.ReviewsHandler).AddReview):      {Summary: "Review a recipe", Tag: "reviews", Auth: true, Body: handlers.ReviewRequest{}, Status: http.StatusCreated, Response: models.Review{}},
+	openapi.Name((*handlers.ReviewsHandler).UpdateReview):   {Summary: "Change a review", Tag: "reviews", Auth: true, Body: handlers.ReviewRequest{}, Response: models.Review{}},
+	openapi.Name((*handlers.ReviewsHandler).DeleteReview):   {Summary: "Delete a review", Tag: "reviews", Auth: true, Response: Message{}},
+	openapi.Name((*handlers.ReviewsHandler).ListAllReviews): {Summary: "List every review", Tag: "admin", Auth: true, Params: []openapi.Parameter{openapi.Query("hidden", "true for the hidden reviews only")}, Response: []models.Review{}},
+	openapi.Name((*handlers.ReviewsHandler).ModerateReview): {Summary: "Hide or restore a review", Tag: "admin", Auth: true, Body: handlers.ModerationRequest{}, Response: models.Review{}},
+	openapi.Name((*handlers.ReviewsHandler).RemoveReview):   {Summary: "Remove a review", Tag: "admin", Auth: true, Response: Message{}},
+
+	openapi.Name((*handlers.CollectionsHandler).AddFavorite):            {Summary: "Favorite a recipe", Tag: "collections", Auth: true, Status: http.StatusCreated, Response: Message{}},
+	openapi.Name((*handlers.CollectionsHandler).RemoveFavorite):         {Summary: "Unfavorite a recipe", Tag: "collections", Auth: true, Response: Message{}},
+	openapi.Name((*handlers.CollectionsHandler).ListFavorites):          {Summary: "List the favorite recipes", Tag: "collections", Auth: true, Response: []models.Recipe{}},
+	openapi.Name((*handlers.CollectionsHandler).ListCollections):        {Summary: "List the collections", Tag: "collections", Auth: true, Response: []models.Collection{}},
+	openapi.Name((*handlers.CollectionsHandler).AddCollection):          {Summary: "Create a collection", Tag: "collections", Auth: true, Body: handlers.CollectionRequest{}, Status: http.StatusCreated, Response: models.Collection{}},
+	openapi.Name((*handlers.CollectionsHandler).GetCollection):          {Summary: "Get a collection with its recipes", Tag: "collections", Auth: true, Response: models.CollectionWithRecipes{}},
+	openapi.Name((*handlers.CollectionsHandler).RenameCollection):       {Summary: "Rename a collection", Tag: "collections", Auth: true, Body: handlers.CollectionRequest{}, Response: models.Collection{}},
+	openapi.Name((*handlers.CollectionsHandler).DeleteCollection):       {Summary: "Delete a collection", Tag: "collections", Auth: true, Response: Message{}},
+	openapi.Name((*handlers.CollectionsHandler).AddCollectionRecipe):    {Summary: "Add a recipe to a collection", Tag: "collections", Auth: true, Body: handlers.CollectionRecipeRequest{}, Response: models.Collection{}},
+	openapi.Name((*handlers.CollectionsHandler).RemoveCollectionRecipe): {Summary: "Remove a recipe from a collection", Tag: "collections", Auth: true, Response: models.Collection{}},
+	openapi.Name((*handlers.CollectionsHandler).ShareCollection):        {Summary: "Share a collection by link", Tag: "collections", Auth: true, Response: ShareLink{}},
+	openapi.Name((*handlers.CollectionsHandler).UnshareCollection):      {Summary: "Stop sharing a collection", Tag: "collections", Auth: true, Response: models.Collection{}},
+	openapi.Name((*handlers.CollectionsHandler).SharedCollection):       {Summary: "Get a shared collection", Tag: "collections", Response: models.CollectionWithRecipes{}},
+
+	openapi.Name((*handlers.MealPlansHandler).GetMealPlan):      {Summary: "Get the meal plan of a week", Tag: "mealplans", Auth: true, Response: models.MealPlan{}},
+	openapi.Name((*handlers.MealPlansHandler).MealPlanCalendar): {Summary: "Export the meal plan of a week as iCalendar", Tag: "mealplans", Auth: true, Produces: []string{"text/calendar"}},
+	openapi.Name((*handlers.MealPlansHandler).CopyWeek):         {Summary: "Copy the meals of another week", Tag: "mealplans", Auth: true, Body: handlers.CopyWeekRequest{}, Response: models.MealPlan{}},
+	openapi.Name((*handlers.MealPlansHandler).SetMeal):          {Summary: "Plan a recipe for a meal", Tag: "mealplans", Auth: true, Body: handlers.MealEntryRequest{}, Response: models.MealPlan{}},
+	openapi.Name((*handlers.MealPlansHandler).SetMealServings):  {Summary: "Change the servings of a meal", Tag: "mealplans", Auth: true, Body: handlers.ServingsRequest{}, Response: models.MealPlan{}},
+	openapi.Name((*handlers.MealPlansHandler).RemoveMeal):       {Summary: "Remove a meal", Tag: "mealplans", Auth: true, Response: models.MealPlan{}},
+
+	openapi.Name((*handlers.ShoppingListsHandler).AddShoppingList):   {Summary: "Generate a shopping list", Tag: "shopping", Auth: true, Body: handlers.ShoppingListRequest{}, Status: http.StatusCreated, Response: models.ShoppingList{}},
+	openapi.Name((*handlers.ShoppingListsHandler).ListShoppingLists): {Summary: "List the shopping lists", Tag: "shopping", Auth: true, Response: []models.ShoppingList{}},
+	openapi.Name((*handlers.ShoppingListsHandler).GetShoppingList):   {Summary: "Get a shopping list", Tag: "shopping", Auth: true, Response: models.ShoppingList{}},
+	openapi.Name((*handlers.ShoppingListsHandler).ExportShoppingList): {Summary: "Export a shopping list as text or Markdown", Tag: "shopping", Auth: true,
+		Params: []openapi.Parameter{openapi.Query("format", "text or markdown")}, Produces: []string{"text/plain", "text/markdown"}},
+	openapi.Name((*handlers.ShoppingListsHandler).CheckShoppingItem):  {Summary: "Check off an item", Tag: "shopping", Auth: true, Body: handlers.CheckItemRequest{}, Response: models.ShoppingList{}},
+	openapi.Name((*handlers.ShoppingListsHandler).DeleteShoppingList): {Summary: "Delete a shopping list", Tag: "shopping", Auth: true, Response: Message{}},
+
+	openapi.Name((*handlers.PantryHandler).GetPantry):        {Summary: "Get the pantry", Tag: "pantry", Auth: true, Response: models.Pantry{}},
+	openapi.Name((*handlers.PantryHandler).AddPantryItems):   {Summary: "Add items to the pantry", Tag: "pantry", Auth: true, Body: handlers.PantryRequest{}, Response: models.Pantry{}},
+	openapi.Name((*handlers.PantryHandler).RemovePantryItem): {Summary: "Remove an item from the pantry", Tag: "pantry", Auth: true, Response: models.Pantry{}},
+	openapi.Name((*handlers.PantryHandler).CookableRecipes): {Summary: "List the recipes cookable from the pantry", Tag: "pantry", Auth: true,
+		Params: []openapi.Parameter{openapi.Query("staples", "false to not assume staples such as salt are available"), statusParam}, Response: []models.CookableRecipe{}},
+
+	openapi.Name((*handlers.SubstitutionsHandler).RecipeSubstitutions): {Summary: "Suggest substitutions for the ingredients of a recipe", Tag: "substitutions",
+		Params:   []openapi.Parameter{openapi.Query("ingredient", "Ingredient to replace"), dietParam, allergenParam, unitsParam},
+		Response: []models.IngredientSubstitutions{}},
+	openapi.Name((*handlers.SubstitutionsHandler).ListSubstitutions):  {Summary: "List the substitutions", Tag: "admin", Auth: true, Response: []models.Substitution{}},
+	openapi.Name((*handlers.SubstitutionsHandler).AddSubstitution):    {Summary: "Add a substitution", Tag: "admin", Auth: true, Body: models.Substitution{}, Status: http.StatusCreated, Response: models.Substitution{}},
+	openapi.Name((*handlers.SubstitutionsHandler).UpdateSubstitution): {Summary: "Replace a substitution", Tag: "admin", Auth: true, Body: models.Substitution{}, Response: models.Substitution{}},
+	openapi.Name((*handlers.SubstitutionsHandler).DeleteSubstitution): {Summary: "Delete a substitution", Tag: "admin", Auth: true, Response: Message{}},
+
+	openapi.Name((*handlers.GraphQLHandler).Query): {Summary: "Run a GraphQL query or mutation over recipes and users", Tag: "graphql", Auth: true,
+		Body: handlers.GraphQLRequest{}, Response: json.RawMessage{}},
+}
+
+// apiDocument builds the OpenAPI document of routes. The unversioned aliases
+// of v1 routes are left out, the document lists them under /v1.
+func apiDocument(routes gin.RoutesInfo) (*openapi.Document, error) {
+	builder := openapi.NewBuilder(openapi.Info{
+		Title:       "Recipes API",
+		Description: "Recipes, meal plans and shopping lists. v1 is deprecated and also served without the /v1 prefix, v2 wraps its JSON responses in {data, meta}.",
+		Version:     "2.0.0",
+	}, routeDocs, apierrors.Problem{}, openapi.Version{
+		Prefix:     "/v1",
+		Deprecated: true,
+	}, openapi.Version{
+		Prefix: "/v2",
+		Meta:   apiversion.Meta{},
+	})
+	builder.Document.Servers = []openapi.Server{{URL: "https://localhost", Description: "TLS on :443"}}
+	builder.Formats, builder.ListFormats = negotiate.MediaTypes, []string{negotiate.MIMECSV}
+
+	v1 := make(map[string]bool)
+	for _, route := range routes {
+		if strings.HasPrefix(route.Path, "/v1/") {
+			v1[route.Method+" "+strings.TrimPrefix(route.Path, "/v1")] = true
+		}
+	}
+	for _, route := range routes {
+		if v1[route.Method+" "+route.Path] {
+			continue
+		}
+		if err := builder.Add(route); err != nil {
+			return nil, err
+		}
+	}
+	if unused := builder.Unused(); len(unused) > 0 {
+		return nil, fmt.Errorf("documented handlers without a route: %v", unused)
+	}
+	return &builder.Document, nil
+}
@@ -0,0 +1,204 @@
+// Package substitutions suggests replacements for the ingredients of a recipe
+package substitutions
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/TranQuocToan1996/ginProject/diet"
+	"github.com/TranQuocToan1996/ginProject/ingredients"
+	"github.com/TranQuocToan1996/ginProject/models"
+	"github.com/TranQuocToan1996/ginProject/pantry"
+	"github.com/TranQuocToan1996/ginProject/units"
+)
+
+// defaults.json is the curated table stored in mongo the first time the
+// server starts, admins edit it there afterwards
+//
+//go:embed defaults.json
+var defaultsJSON []byte
+
+// Defaults returns the curated substitutions without ids
+func Defaults() ([]models.Substitution, error) {
+	var subs []models.Substitution
+	if err := json.Unmarshal(defaultsJSON, &subs); err != nil {
+		return nil, err
+	}
+	for i := range subs {
+		if err := Validate(subs[i]); err != nil {
+			return nil, err
+		}
+	}
+	return subs, nil
+}
+
+// Validate checks that the units of a substitution are known units of the same
+// kind of measure
+func Validate(sub models.Substitution) error {
+	if key(sub.Ingredient) == "" {
+		return fmt.Errorf("substitutions: ingredient %q has no name", sub.Ingredient)
+	}
+	if sub.Unit != "" {
+		u, ok := units.Lookup(sub.Unit)
+		if !ok || u.Kind == units.Temperature {
+			return fmt.Errorf("substitutions: unknown unit %q", sub.Unit)
+		}
+	}
+	for _, part := range sub.Replacement {
+		if part.Quantity <= 0 {
+			return fmt.Errorf("substitutions: %v needs a positive quantity", part.Name)
+		}
+		if part.Unit == "" {
+			continue
+		}
+		if u, ok := units.Lookup(part.Unit); !ok || u.Kind == units.Temperature {
+			return fmt.Errorf("substitutions: unknown unit %q", part.Unit)
+		}
+	}
+	return nil
+}
+
+// Find returns the substitutions for the ingredient of line. When several
+// ingredients match, such as cream and heavy cream, only the substitutions of
+// the longest one are returned.
+func Find(line string, subs []models.Substitution) []models.Substitution {
+	text := " " + pantry.Key(line) + " "
+	result := make([]models.Substitution, 0)
+	longest := 0
+	for _, sub := range subs {
+		k := key(sub.Ingredient)
+		if k == "" || len(k) < longest || !strings.Contains(text, " "+k+" ") {
+			continue
+		}
+		excluded := false
+		for _, phrase := range sub.Except {
+			if strings.Contains(text, " "+key(phrase)+" ") {
+				excluded = true
+			}
+		}
+		if excluded {
+			continue
+		}
+		if len(k) > longest {
+			longest = len(k)
+			result = result[:0]
+		}
+		result = append(result, sub)
+	}
+	return result
+}
+
+// Suggest returns the suggestions for an ingredient line, keeping those
+// suitable for every diet label and free of every allergen given
+func Suggest(line string, subs []models.Substitution, diets, allergens []string, system units.System) []models.SubstitutionSuggestion {
+	result := make([]models.SubstitutionSuggestion, 0)
+	for _, sub := range Find(line, subs) {
+		names := make([]string, 0, len(sub.Replacement))
+		for _, part := range sub.Replacement {
+			names = append(names, part.Name)
+		}
+		classification := diet.Classify(names)
+		if !containsAll(classification.Labels, diets) || containsAny(classification.Allergens, allergens) {
+			continue
+		}
+		adjusted, ok := Apply(line, sub, system)
+		result = append(result, models.SubstitutionSuggestion{
+			SubstitutionID: sub.ID,
+			Line:           adjusted,
+			Adjusted:       ok,
+			Notes:          sub.Notes,
+			DietLabels:     classification.Labels,
+			Allergens:      classification.Allergens,
+		})
+	}
+	return result
+}
+
+// Apply writes the replacement of the ingredient of line, its quantities
+// adjusted to the quantity of line. When the quantity of line can't be
+// converted to the unit of sub, only the names are written and ok is false.
+// The replacement is written in system, or in the system of line when empty.
+func Apply(line string, sub models.Substitution, system units.System) (string, bool) {
+	names := make([]string, 0, len(sub.Replacement))
+	for _, part := range sub.Replacement {
+		names = append(names, part.Name)
+	}
+	fallback := strings.Join(names, " + ")
+
+	ingredient, err := ingredients.Parse(line)
+	if err != nil {
+		return fallback, false
+	}
+	amount := ingredient.Quantity
+	if ingredient.QuantityMax > 0 {
+		amount = (amount + ingredient.QuantityMax) / 2
+	}
+	from, hasUnit := units.Lookup(ingredient.Unit)
+	hasUnit = hasUnit && ingredient.Unit != ""
+	switch {
+	case sub.Unit == "" && hasUnit, sub.Unit != "" && !hasUnit:
+		return fallback, false
+	case sub.Unit != "":
+		to, _ := units.Lookup(sub.Unit)
+		if amount, err = units.Convert(amount, from, to); err != nil {
+			return fallback, false
+		}
+	}
+	if system == "" && hasUnit {
+		system = from.System
+	}
+
+	parts := make([]string, 0, len(sub.Replacement))
+	for _, part := range sub.Replacement {
+		q := amount * part.Quantity
+		if part.Unit == "" {
+			parts = append(parts, ingredients.FormatQuantity(q)+" "+part.Name)
+			continue
+		}
+		u, _ := units.Lookup(part.Unit)
+		s := system
+		if s == "" {
+			s = u.System
+		}
+		q, u = units.Best(q, u, s)
+		parts = append(parts, format(q, u)+" "+u.Name+" "+part.Name)
+	}
+	return strings.Join(parts, " + "), true
+}
+
+// format rounds US quantities to the nearest eighth and metric ones to a
+// kitchen precision, like ingredients.Convert
+func format(q float64, u units.Unit) string {
+	if u.System == units.US {
+		return ingredients.FormatQuantity(math.Max(math.Round(q*8)/8, 1.0/8))
+	}
+	return strconv.FormatFloat(units.Round(q, u), 'f', -1, 64)
+}
+
+func key(name string) string {
+	return pantry.Key(name)
+}
+
+func containsAll(list, values []string) bool {
+	for _, v := range values {
+		if !containsAny(list, []string{v}) {
+			return false
+		}
+	}
+	return true
+}
+
+func containsAny(list, values []string) bool {
+	for _, v := range values {
+		for _, l := range list {
+			if l == v {
+				return true
+			}
+		}
+	}
+	return false
+}
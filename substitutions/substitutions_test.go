@@ -0,0 +1,76 @@
+package substitutions
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/TranQuocToan1996/ginProject/units"
+)
+
+func TestDefaults(t *testing.T) {
+	if _, err := Defaults(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestApply(t *testing.T) {
+	subs, _ := Defaults()
+	for i, tt := range []struct {
+		line   string
+		system units.System
+		want   string
+		ok     bool
+	}{
+		{"2 cups buttermilk", "", "1 7/8 cup milk + 2 tbsp lemon juice", true},
+		{"250 ml buttermilk", "", "234 ml milk + 16 ml lemon juice", true},
+		{"3 large eggs", "", "3 tbsp ground flaxseed + 5/8 cup water", true},
+		{"3 large eggs", units.Metric, "44 ml ground flaxseed + 133 ml water", true},
+		{"2 cloves garlic, minced", "", "1/4 tsp garlic powder", true},
+		{"1 pound butter", "", "coconut oil", false},
+		{"Buttermilk for brushing", "", "milk + lemon juice", false},
+	} {
+		t.Run(fmt.Sprintf("%v", i), func(t *testing.T) {
+			found := Find(tt.line, subs)
+			if len(found) == 0 {
+				t.Fatalf("no substitution for %v", tt.line)
+			}
+			result, ok := Apply(tt.line, found[0], tt.system)
+			if result != tt.want || ok != tt.ok {
+				t.Errorf("want %q %v; got %q %v", tt.want, tt.ok, result, ok)
+			}
+		})
+	}
+}
+
+func TestFind(t *testing.T) {
+	subs, _ := Defaults()
+	for i, tt := range []struct {
+		line string
+		want string
+		n    int
+	}{
+		{"1 cup heavy cream", "heavy cream", 2},
+		{"1/2 cup peanut butter", "", 0},
+		{"1 cup butter, softened", "butter", 2},
+		{"2 egg whites", "", 0},
+		{"1 eggplant", "", 0},
+	} {
+		t.Run(fmt.Sprintf("%v", i), func(t *testing.T) {
+			found := Find(tt.line, subs)
+			if len(found) != tt.n || (tt.n > 0 && found[0].Ingredient != tt.want) {
+				t.Errorf("want %v %v; got %v", tt.n, tt.want, found)
+			}
+		})
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	subs, _ := Defaults()
+	suggestions := Suggest("1 cup buttermilk", subs, []string{"vegan"}, nil, "")
+	if len(suggestions) != 1 || suggestions[0].Line != "1 cup soy milk + 1 tbsp apple cider vinegar" {
+		t.Errorf("want the soy milk substitution only; got %v", suggestions)
+	}
+	if suggestions := Suggest("1 cup milk", subs, nil, []string{"soy"}, ""); len(suggestions) != 1 {
+		t.Errorf("want the oat milk substitution only; got %v", suggestions)
+	}
+}
@@ -0,0 +1,254 @@
+package units
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Kind is the physical quantity a unit measures
+type Kind int
+
+const (
+	Volume Kind = iota
+	Weight
+	Temperature
+)
+
+// System is a measurement system requested by the client with ?units=
+type System string
+
+const (
+	Metric System = "metric"
+	US     System = "us"
+)
+
+var ErrUnknownSystem = errors.New("units: unknown unit system, use metric or us")
+
+// ParseSystem validates the value of the units query parameter. An empty value
+// means the recipe is returned as stored.
+func ParseSystem(s string) (System, error) {
+	switch System(strings.ToLower(strings.TrimSpace(s))) {
+	case "":
+		return "", nil
+	case Metric:
+		return Metric, nil
+	case US:
+		return US, nil
+	}
+	return "", ErrUnknownSystem
+}
+
+// Unit is a measure of volume (based on millilitres), weight (based on grams)
+// or temperature
+type Unit struct {
+	Name   string
+	Kind   Kind
+	System System
+	ToBase float64
+}
+
+var (
+	Teaspoon   = Unit{"tsp", Volume, US, 4.92892}
+	Tablespoon = Unit{"tbsp", Volume, US, 14.7868}
+	FluidOunce = Unit{"fl oz", Volume, US, 29.5735}
+	Cup        = Unit{"cup", Volume, US, 236.588}
+	Pint       = Unit{"pint", Volume, US, 473.176}
+	Quart      = Unit{"quart", Volume, US, 946.353}
+	Gallon     = Unit{"gallon", Volume, US, 3785.41}
+	Milliliter = Unit{"ml", Volume, Metric, 1}
+	Liter      = Unit{"l", Volume, Metric, 1000}
+	Ounce      = Unit{"oz", Weight, US, 28.3495}
+	Pound      = Unit{"lb", Weight, US, 453.592}
+	Gram       = Unit{"g", Weight, Metric, 1}
+	Kilogram   = Unit{"kg", Weight, Metric, 1000}
+	Fahrenheit = Unit{"°F", Temperature, US, 1}
+	Celsius    = Unit{"°C", Temperature, Metric, 1}
+)
+
+var aliases = map[string]Unit{
+	"tsp": Teaspoon, "tsps": Teaspoon, "teaspoon": Teaspoon, "teaspoons": Teaspoon,
+	"tbsp": Tablespoon, "tbsps": Tablespoon, "tbs": Tablespoon, "tablespoon": Tablespoon, "tablespoons": Tablespoon,
+	"fl oz": FluidOunce, "fl. oz": FluidOunce, "fluid ounce": FluidOunce, "fluid ounces": FluidOunce,
+	"cup": Cup, "cups": Cup, "c": Cup,
+	"pint": Pint, "pints": Pint, "pt": Pint,
+	"quart": Quart, "quarts": Quart, "qt": Quart,
+	"gallon": Gallon, "gallons": Gallon, "gal": Gallon,
+	"ml": Milliliter, "milliliter": Milliliter, "milliliters": Milliliter, "millilitre": Milliliter, "millilitres": Milliliter,
+	"l": Liter, "liter": Liter, "liters": Liter, "litre": Liter, "litres": Liter,
+	"oz": Ounce, "ounce": Ounce, "ounces": Ounce,
+	"lb": Pound, "lbs": Pound, "pound": Pound, "pounds": Pound,
+	"g": Gram, "gr": Gram, "gram": Gram, "grams": Gram,
+	"kg": Kilogram, "kilogram": Kilogram, "kilograms": Kilogram,
+	"°f": Fahrenheit, "f": Fahrenheit, "fahrenheit": Fahrenheit,
+	"°c": Celsius, "celsius": Celsius,
+}
+
+// Lookup normalizes a unit name ("Tbsp", "tablespoons", "oz.") to its unit.
+// "T" and "t" keep their traditional case-sensitive meaning of tablespoon and teaspoon.
+func Lookup(name string) (Unit, bool) {
+	name = strings.TrimSuffix(strings.TrimSpace(name), ".")
+	switch name {
+	case "T":
+		return Tablespoon, true
+	case "t":
+		return Teaspoon, true
+	}
+	u, ok := aliases[strings.ToLower(name)]
+	return u, ok
+}
+
+// Convert converts q from one unit to another of the same kind
+func Convert(q float64, from, to Unit) (float64, error) {
+	if from.Kind != to.Kind {
+		return 0, fmt.Errorf("units: cannot convert %v to %v", from.Name, to.Name)
+	}
+	if from.Kind == Temperature {
+		switch {
+		case from == to:
+			return q, nil
+		case from == Fahrenheit:
+			return (q - 32) * 5 / 9, nil
+		default:
+			return q*9/5 + 32, nil
+		}
+	}
+	return q * from.ToBase / to.ToBase, nil
+}
+
+// Best converts q into the unit of system that reads most naturally for its
+// size, e.g. 3 tsp becomes 1 tbsp and 1500 ml becomes 1.5 l
+func Best(q float64, from Unit, system System) (float64, Unit) {
+	var candidates []Unit
+	switch {
+	case from.Kind == Temperature && system == Metric:
+		candidates = []Unit{Celsius}
+	case from.Kind == Temperature:
+		candidates = []Unit{Fahrenheit}
+	case from.Kind == Volume && system == Metric:
+		candidates = []Unit{Milliliter, Liter}
+	case from.Kind == Volume:
+		candidates = []Unit{Teaspoon, Tablespoon, Cup}
+	case system == Metric:
+		candidates = []Unit{Gram, Kilogram}
+	default:
+		candidates = []Unit{Ounce, Pound}
+	}
+	if from.Kind == Temperature {
+		v, _ := Convert(q, from, candidates[0])
+		return v, candidates[0]
+	}
+	base := q * from.ToBase
+	best := candidates[0]
+	for _, u := range candidates[1:] {
+		// Switch to the larger unit once the quantity reaches a quarter of it
+		// for cups, or a whole one otherwise
+		threshold := u.ToBase
+		if u == Cup {
+			threshold = u.ToBase / 4
+		}
+		if base >= threshold*0.99 {
+			best = u
+		}
+	}
+	return base / best.ToBase, best
+}
+
+// Round rounds a metric quantity to a precision that makes sense in a kitchen
+func Round(q float64, u Unit) float64 {
+	switch {
+	case u == Liter || u == Kilogram:
+		return math.Round(q*100) / 100
+	case q >= 10:
+		return math.Round(q)
+	default:
+		return math.Round(q*10) / 10
+	}
+}
+
+// density is grams per millilitre of a common ingredient. Liquids are still
+// measured by volume in metric recipes so they are only used when a weight is
+// asked for explicitly.
+type density struct {
+	gramsPerMl float64
+	liquid     bool
+}
+
+var densities = map[string]density{
+	"flour":                {0.53, false},
+	"bread flour":          {0.54, false},
+	"whole wheat flour":    {0.51, false},
+	"sugar":                {0.85, false},
+	"granulated sugar":     {0.85, false},
+	"brown sugar":          {0.93, false},
+	"powdered sugar":       {0.51, false},
+	"confectioners' sugar": {0.51, false},
+	"cocoa powder":         {0.42, false},
+	"butter":               {0.96, false},
+	"salt":                 {1.22, false},
+	"kosher salt":          {0.61, false},
+	"rice":                 {0.79, false},
+	"oats":                 {0.38, false},
+	"parmesan":             {0.42, false},
+	"cheddar":              {0.47, false},
+	"bread crumbs":         {0.45, false},
+	"honey":                {1.42, false},
+	"water":                {1.0, true},
+	"milk":                 {1.03, true},
+	"buttermilk":           {1.03, true},
+	"cream":                {1.01, true},
+	"broth":                {1.0, true},
+	"stock":                {1.0, true},
+	"oil":                  {0.92, true},
+	"olive oil":            {0.92, true},
+	"vinegar":              {1.01, true},
+	"juice":                {1.04, true},
+	"wine":                 {0.99, true},
+}
+
+// VolumeToWeight converts a volume of ingredient into grams using the density
+// of the longest known ingredient name contained in it. It reports false when
+// the ingredient is unknown. Liquids are only converted when liquids is true.
+func VolumeToWeight(q float64, from Unit, ingredient string, liquids bool) (float64, bool) {
+	if from.Kind != Volume {
+		return 0, false
+	}
+	ingredient = strings.ToLower(ingredient)
+	match := ""
+	for name := range densities {
+		if len(name) > len(match) && strings.Contains(ingredient, name) {
+			match = name
+		}
+	}
+	d, ok := densities[match]
+	if !ok || (d.liquid && !liquids) {
+		return 0, false
+	}
+	return q * from.ToBase * d.gramsPerMl, true
+}
+
+var temperature = regexp.MustCompile(`(\d+)\s*(?:°\s*|º\s*|degrees\s+)([FC])\b`)
+
+// ConvertTemperatures rewrites explicit temperatures in text such as "350°F" or
+// "350 degrees F" into system, rounded to the nearest 5 degrees like oven dials
+func ConvertTemperatures(text string, system System) string {
+	if system == "" {
+		return text
+	}
+	return temperature.ReplaceAllStringFunc(text, func(s string) string {
+		m := temperature.FindStringSubmatch(s)
+		from := Fahrenheit
+		if m[2] == "C" {
+			from = Celsius
+		}
+		if from.System == system {
+			return s
+		}
+		q, _ := strconv.ParseFloat(m[1], 64)
+		v, to := Best(q, from, system)
+		return strconv.Itoa(int(math.Round(v/5)*5)) + to.Name
+	})
+}
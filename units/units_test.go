@@ -0,0 +1,83 @@
+package units
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+func TestLookup(t *testing.T) {
+	for i, tt := range []struct {
+		in  string
+		out Unit
+	}{
+		{"Tbsp", Tablespoon},
+		{"tablespoons", Tablespoon},
+		{"T", Tablespoon},
+		{"t", Teaspoon},
+		{"oz.", Ounce},
+		{"fl oz", FluidOunce},
+		{"lbs", Pound},
+	} {
+		t.Run(fmt.Sprintf("%v", i), func(t *testing.T) {
+			result, ok := Lookup(tt.in)
+			if !ok || result != tt.out {
+				t.Errorf("want %v; got %v", tt.out.Name, result.Name)
+			}
+		})
+	}
+}
+
+func TestBest(t *testing.T) {
+	for i, tt := range []struct {
+		q      float64
+		from   Unit
+		system System
+		want   float64
+		unit   Unit
+	}{
+		{3, Teaspoon, US, 1, Tablespoon},
+		{4, Tablespoon, US, 0.25, Cup},
+		{1, Cup, Metric, 236.588, Milliliter},
+		{8, Cup, Metric, 1.893, Liter},
+		{16, Ounce, US, 1, Pound},
+		{1, Pound, Metric, 453.592, Gram},
+		{350, Fahrenheit, Metric, 176.667, Celsius},
+	} {
+		t.Run(fmt.Sprintf("%v", i), func(t *testing.T) {
+			result, unit := Best(tt.q, tt.from, tt.system)
+			if unit != tt.unit || math.Abs(result-tt.want) > 0.01 {
+				t.Errorf("want %v %v; got %v %v", tt.want, tt.unit.Name, result, unit.Name)
+			}
+		})
+	}
+}
+
+func TestVolumeToWeight(t *testing.T) {
+	grams, ok := VolumeToWeight(1, Cup, "all-purpose flour", false)
+	if !ok || math.Round(grams) != 125 {
+		t.Errorf("want 125; got %v", grams)
+	}
+	if _, ok := VolumeToWeight(1, Cup, "whole milk", false); ok {
+		t.Errorf("want liquids to stay volumes")
+	}
+}
+
+func TestConvertTemperatures(t *testing.T) {
+	for i, tt := range []struct {
+		in     string
+		system System
+		out    string
+	}{
+		{"Preheat the oven to 350°F.", Metric, "Preheat the oven to 175°C."},
+		{"Heat oven to 450 degrees F", Metric, "Heat oven to 230°C"},
+		{"Bake at 180°C", US, "Bake at 355°F"},
+		{"Bake at 180°C", Metric, "Bake at 180°C"},
+	} {
+		t.Run(fmt.Sprintf("%v", i), func(t *testing.T) {
+			if result := ConvertTemperatures(tt.in, tt.system); result != tt.out {
+				t.Errorf("want %v; got %v", tt.out, result)
+			}
+		})
+	}
+}
@@ -6,10 +6,16 @@ import (
 	"encoding/base64"
 	"errors"
 	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"math"
 	"reflect"
 	"strings"
 
 	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
 )
 
 const (
@@ -42,28 +48,22 @@ func MapItoM(i interface{}, s interface{}) error {
 	return nil
 }
 
-//resize image
-// func Resize(img image.Image, width, height int) *image.NRGBA {
-// 	srcBounds := img.Bounds()
-// 	srcAspectRatio := float64(srcBounds.Dx()) / float64(srcBounds.Dy())
-// 	dstBounds := image.Rect(0, 0, width, height)
-// 	dstAspectRatio := float64(dstBounds.Dx()) / float64(dstBounds.Dy())
-// 	var dst image.Image
-// 	if srcAspectRatio > dstAspectRatio {
-// 		dstBounds.Min.X = dstBounds.Min.Y * srcAspectRatio
-// 		dstBounds.Max.X = dstBounds.Max.Y * srcAspectRatio
-// 	} else {
-// 		dstBounds.Min.Y = dstBounds.Min.X / srcAspectRatio
-// 		dstBounds.Max.Y = dstBounds.Max.X / srcAspectRatio
-// 	}
-// 	dst = image.NewNRGBA(dstBounds)
-// 	draw.Draw(dst, dstBounds, img, srcBounds.Min, draw.Src)
-// 	return dst.(*image.NRGBA)
-// }
-
-// func Resize(img image.Image, width, height int) image.Image {
-// 	return resize.Resize(uint(width), uint(height), img, resize.Lanczos3)
-// }
+// Resize scales img down to fit within width x height, keeping its aspect
+// ratio. Images that already fit are returned unchanged.
+func Resize(img image.Image, width, height int) image.Image {
+	srcBounds := img.Bounds()
+	srcW, srcH := srcBounds.Dx(), srcBounds.Dy()
+	if srcW <= width && srcH <= height {
+		return img
+	}
+	ratio := math.Min(float64(width)/float64(srcW), float64(height)/float64(srcH))
+	dstBounds := image.Rect(0, 0,
+		int(math.Max(1, math.Round(float64(srcW)*ratio))),
+		int(math.Max(1, math.Round(float64(srcH)*ratio))))
+	dst := image.NewNRGBA(dstBounds)
+	draw.CatmullRom.Scale(dst, dstBounds, img, srcBounds, draw.Src, nil)
+	return dst
+}
 
 // decode base64 to image
 func DecodeBase64Image(data []byte) (image.Image, error) {
@@ -3,6 +3,7 @@ package utils
 import (
 	"encoding/hex"
 	"fmt"
+	"image"
 	"testing"
 )
 
@@ -23,4 +24,23 @@ func TestNewSHA256(t *testing.T) {
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+
+func TestResize(t *testing.T) {
+	for i, tt := range []struct {
+		w, h int
+		outW int
+		outH int
+	}{
+		{2400, 1200, 600, 300},
+		{1200, 2400, 300, 600},
+		{400, 300, 400, 300},
+	} {
+		t.Run(fmt.Sprintf("%v", i), func(t *testing.T) {
+			result := Resize(image.NewRGBA(image.Rect(0, 0, tt.w, tt.h)), 600, 600).Bounds()
+			if result.Dx() != tt.outW || result.Dy() != tt.outH {
+				t.Errorf("want %vx%v; got %vx%v", tt.outW, tt.outH, result.Dx(), result.Dy())
+			}
+		})
+	}
+}
@@ -0,0 +1,199 @@
+// Package validation checks request payloads against the rules declared in
+// their struct tags and reports every invalid field with a stable code
+package validation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// Codes of the field errors
+const (
+	Required      = "required"
+	TooLong       = "too_long"
+	TooShort      = "too_short"
+	TooMany       = "too_many"
+	TooFew        = "too_few"
+	TooLarge      = "too_large"
+	TooSmall      = "too_small"
+	InvalidFormat = "invalid_format"
+	InvalidValue  = "invalid_value"
+	InvalidType   = "invalid_type"
+	InvalidJSON   = "invalid_json"
+	Duplicate     = "duplicate"
+)
+
+// FieldError is an invalid field of a payload, named by its json path
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Errors lists the invalid fields of a payload
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, field := range e {
+		if field.Field == "" {
+			messages = append(messages, field.Message)
+		} else {
+			messages = append(messages, field.Field+": "+field.Message)
+		}
+	}
+	return strings.Join(messages, "; ")
+}
+
+// tagPattern is the format of recipe tags, words of letters of any script,
+// with their accents, and digits separated by a space, a dash or an underscore
+var tagPattern = regexp.MustCompile(`^[\p{L}\p{M}\p{N}]+([ _-][\p{L}\p{M}\p{N}]+)*$`)
+
+var validate = newValidator("validate")
+
+func newValidator(tagName string) *validator.Validate {
+	v := validator.New()
+	v.SetTagName(tagName)
+	Register(v)
+	return v
+}
+
+// Register adds the custom rules and json field names to v, gin's binding
+// validator is registered at startup so that binding tags can use them
+func Register(v *validator.Validate) {
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return field.Name
+		}
+		return name
+	})
+	v.RegisterValidation("notblank", func(fl validator.FieldLevel) bool {
+		return strings.TrimSpace(fl.Field().String()) != ""
+	})
+	v.RegisterValidation("tag", func(fl validator.FieldLevel) bool {
+		return tagPattern.MatchString(fl.Field().String())
+	})
+}
+
+// Struct checks s against its validate tags, returning Errors when invalid
+func Struct(s interface{}) error {
+	return FromError(validate.Struct(s))
+}
+
+// Partial checks s against its validate tags like Struct, reporting only the
+// errors of the fields named by their json name, such as the fields a patch
+// changes
+func Partial(s interface{}, fields ...string) error {
+	err := Struct(s)
+	var errs Errors
+	if !errors.As(err, &errs) {
+		return err
+	}
+	kept := make(Errors, 0, len(errs))
+	for _, fe := range errs {
+		name := fe.Field
+		if i := strings.IndexAny(name, ".["); i >= 0 {
+			name = name[:i]
+		}
+		for _, field := range fields {
+			if name == field {
+				kept = append(kept, fe)
+				break
+			}
+		}
+	}
+	if len(kept) == 0 {
+		return nil
+	}
+	return kept
+}
+
+// FromError turns an error from decoding or validating a payload into Errors.
+// Other errors are returned as they are.
+func FromError(err error) error {
+	var validationErrors validator.ValidationErrors
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &validationErrors):
+		result := make(Errors, 0, len(validationErrors))
+		for _, fe := range validationErrors {
+			result = append(result, fieldError(fe))
+		}
+		return result
+	case errors.As(err, &syntaxErr):
+		return Errors{{Code: InvalidJSON, Message: "body is not valid JSON: " + syntaxErr.Error()}}
+	case errors.As(err, &typeErr):
+		return Errors{{Field: typeErr.Field, Code: InvalidType, Message: "must be " + kind(typeErr.Type)}}
+	case err.Error() == "EOF" || err.Error() == "unexpected EOF":
+		return Errors{{Code: InvalidJSON, Message: "body is empty or truncated"}}
+	}
+	return err
+}
+
+func fieldError(fe validator.FieldError) FieldError {
+	field := fe.Namespace()
+	if i := strings.Index(field, "."); i >= 0 {
+		field = field[i+1:]
+	}
+	list := fe.Kind() == reflect.Slice || fe.Kind() == reflect.Array || fe.Kind() == reflect.Map
+	number := fe.Kind() >= reflect.Int && fe.Kind() <= reflect.Float64
+	result := FieldError{Field: field}
+	switch fe.Tag() {
+	case "required", "notblank":
+		result.Code, result.Message = Required, "is required"
+	case "max", "lte":
+		switch {
+		case list:
+			result.Code, result.Message = TooMany, "has more than "+fe.Param()+" items"
+		case number:
+			result.Code, result.Message = TooLarge, "is larger than "+fe.Param()
+		default:
+			result.Code, result.Message = TooLong, "is longer than "+fe.Param()+" characters"
+		}
+	case "min", "gte":
+		switch {
+		case list:
+			result.Code, result.Message = TooFew, "has less than "+fe.Param()+" items"
+		case number:
+			result.Code, result.Message = TooSmall, "is smaller than "+fe.Param()
+		default:
+			result.Code, result.Message = TooShort, "is shorter than "+fe.Param()+" characters"
+		}
+	case "tag":
+		result.Code, result.Message = InvalidFormat, "must be words of letters and digits separated by a space, - or _"
+	case "unique":
+		result.Code, result.Message = Duplicate, "has duplicate items"
+	case "oneof":
+		result.Code, result.Message = InvalidValue, "must be one of "+fe.Param()
+	default:
+		result.Code, result.Message = InvalidValue, fmt.Sprintf("fails the %v rule", fe.Tag())
+	}
+	return result
+}
+
+func kind(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Slice, reflect.Array:
+		return "a list"
+	case reflect.Map, reflect.Struct:
+		return "an object"
+	case reflect.Bool:
+		return "a boolean"
+	}
+	return "a number"
+}
@@ -0,0 +1,112 @@
+package validation
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/TranQuocToan1996/ginProject/models"
+)
+
+func recipe() models.Recipe {
+	return models.Recipe{
+		Name:         "Pancakes",
+		Tags:         []string{"breakfast", "slow_cooker", "GF"},
+		Ingredients:  []string{"2 eggs", "1 cup flour"},
+		Instructions: []models.Instruction{{Step: 1, Text: "Mix"}, {Step: 2, Text: "Fry"}},
+		Servings:     2,
+	}
+}
+
+func TestStruct(t *testing.T) {
+	for i, tt := range []struct {
+		change func(r *models.Recipe)
+		want   Errors
+	}{
+		{func(r *models.Recipe) {}, nil},
+		{func(r *models.Recipe) { r.Name = " " }, Errors{{"name", Required, "is required"}}},
+		{func(r *models.Recipe) { r.Name = strings.Repeat("a", 121) }, Errors{{"name", TooLong, "is longer than 120 characters"}}},
+		{func(r *models.Recipe) { r.Ingredients = nil }, Errors{{"ingredients", TooFew, "has less than 1 items"}}},
+		{func(r *models.Recipe) { r.Ingredients = append(r.Ingredients, "") }, Errors{{"ingredients[2]", Required, "is required"}}},
+		{func(r *models.Recipe) { r.Tags = append(r.Tags, "main,") }, Errors{{"tags[3]", InvalidFormat, "must be words of letters and digits separated by a space, - or _"}}},
+		{func(r *models.Recipe) { r.Tags = append(r.Tags, "GF") }, Errors{{"tags", Duplicate, "has duplicate items"}}},
+		{func(r *models.Recipe) { r.Tags = append(r.Tags, "jalapeño", "crème brûlée", "家常菜") }, nil},
+		{func(r *models.Recipe) { r.Tags = append(r.Tags, "quick&easy") }, Errors{{"tags[3]", InvalidFormat, "must be words of letters and digits separated by a space, - or _"}}},
+		{func(r *models.Recipe) {
+			r.Tags = nil
+			for i := 0; i < 21; i++ {
+				r.Tags = append(r.Tags, fmt.Sprintf("tag%v", i))
+			}
+		}, Errors{{"tags", TooMany, "has more than 20 items"}}},
+		{func(r *models.Recipe) { r.Servings = -1 }, Errors{{"servings", TooSmall, "is smaller than 0"}}},
+		{func(r *models.Recipe) {
+			r.Name = ""
+			r.Instructions[1].Text = ""
+		}, Errors{{"name", Required, "is required"}, {"instructions[1].text", Required, "is required"}}},
+	} {
+		t.Run(fmt.Sprintf("%v", i), func(t *testing.T) {
+			r := recipe()
+			tt.change(&r)
+			err := Struct(&r)
+			if tt.want == nil {
+				if err != nil {
+					t.Fatalf("want no error; got %v", err)
+				}
+				return
+			}
+			if !reflect.DeepEqual(err, tt.want) {
+				t.Errorf("want %v; got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestPartial(t *testing.T) {
+	for i, tt := range []struct {
+		fields []string
+		want   Errors
+	}{
+		{[]string{"name"}, Errors{{"name", Required, "is required"}}},
+		{[]string{"servings"}, nil},
+		{[]string{"tags", "instructions"}, Errors{{"tags[3]", InvalidFormat, "must be words of letters and digits separated by a space, - or _"}, {"instructions[1].text", Required, "is required"}}},
+	} {
+		t.Run(fmt.Sprintf("%v", i), func(t *testing.T) {
+			r := recipe()
+			r.Name = ""
+			r.Tags = append(r.Tags, "quick&easy")
+			r.Instructions[1].Text = ""
+			err := Partial(&r, tt.fields...)
+			if tt.want == nil {
+				if err != nil {
+					t.Fatalf("want no error; got %v", err)
+				}
+				return
+			}
+			if !reflect.DeepEqual(err, tt.want) {
+				t.Errorf("want %v; got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestFromError(t *testing.T) {
+	for i, tt := range []struct {
+		body string
+		want Errors
+	}{
+		{`{"name": "Pancakes"`, Errors{{"", InvalidJSON, "body is empty or truncated"}}},
+		{`{"name": }`, Errors{{"", InvalidJSON, "body is not valid JSON: invalid character '}' looking for beginning of value"}}},
+		{`{"servings": "two"}`, Errors{{"servings", InvalidType, "must be a number"}}},
+		{`{"tags": "dessert"}`, Errors{{"tags", InvalidType, "must be a list"}}},
+	} {
+		t.Run(fmt.Sprintf("%v", i), func(t *testing.T) {
+			var r models.Recipe
+			err := FromError(json.NewDecoder(strings.NewReader(tt.body)).Decode(&r))
+			if !reflect.DeepEqual(err, tt.want) {
+				t.Errorf("want %v; got %v", tt.want, err)
+			}
+		})
+	}
+}
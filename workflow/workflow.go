@@ -0,0 +1,78 @@
+// Package workflow decides the publication status of recipes and who can see them
+package workflow
+
+import (
+	"errors"
+	"time"
+
+	"github.com/TranQuocToan1996/ginProject/models"
+)
+
+// Statuses of a recipe
+const (
+	Draft     = "draft"
+	InReview  = "in_review"
+	Published = "published"
+	Archived  = "archived"
+)
+
+var (
+	ErrUnknownStatus = errors.New("status must be draft, in_review, published or archived")
+	ErrTransition    = errors.New("recipe can't move to this status")
+)
+
+// transitions lists the statuses each status can move to
+var transitions = map[string][]string{
+	Draft:     {InReview, Published, Archived},
+	InReview:  {Draft, Published},
+	Published: {Draft, Archived},
+	Archived:  {Draft, Published},
+}
+
+// IsStatus reports whether status is a known status
+func IsStatus(status string) bool {
+	_, ok := transitions[status]
+	return ok
+}
+
+// Of returns the status of recipe. Recipes stored before statuses existed
+// are published.
+func Of(recipe models.Recipe) string {
+	if recipe.Status == "" {
+		return Published
+	}
+	return recipe.Status
+}
+
+// CanTransition reports whether a recipe can move from a status to another
+func CanTransition(from, to string) bool {
+	for _, status := range transitions[from] {
+		if status == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Initial returns the status a new recipe is created with, draft unless
+// requested otherwise. A recipe can't be created archived.
+func Initial(requested string) (string, error) {
+	switch requested {
+	case "":
+		return Draft, nil
+	case Draft, InReview, Published:
+		return requested, nil
+	}
+	return "", ErrUnknownStatus
+}
+
+// Visible reports whether user can see recipe. Published recipes are public,
+// the others are only visible to their author.
+func Visible(recipe models.Recipe, user string) bool {
+	return Of(recipe) == Published || (user != "" && recipe.Author == user)
+}
+
+// Scheduled reports whether recipe waits to be published at a later time than now
+func Scheduled(recipe models.Recipe, now time.Time) bool {
+	return recipe.PublishAt != nil && recipe.PublishAt.After(now) && Of(recipe) != Published
+}
@@ -0,0 +1,93 @@
+package workflow
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/TranQuocToan1996/ginProject/models"
+)
+
+func TestCanTransition(t *testing.T) {
+	for i, tt := range []struct {
+		from, to string
+		want     bool
+	}{
+		{Draft, InReview, true},
+		{Draft, Published, true},
+		{InReview, Published, true},
+		{InReview, Archived, false},
+		{Published, Draft, true},
+		{Published, Published, false},
+		{Archived, InReview, false},
+		{"deleted", Draft, false},
+	} {
+		t.Run(fmt.Sprintf("%v", i), func(t *testing.T) {
+			if got := CanTransition(tt.from, tt.to); got != tt.want {
+				t.Errorf("want %v; got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestInitial(t *testing.T) {
+	for i, tt := range []struct {
+		in   string
+		want string
+		err  error
+	}{
+		{"", Draft, nil},
+		{InReview, InReview, nil},
+		{Published, Published, nil},
+		{Archived, "", ErrUnknownStatus},
+		{"public", "", ErrUnknownStatus},
+	} {
+		t.Run(fmt.Sprintf("%v", i), func(t *testing.T) {
+			got, err := Initial(tt.in)
+			if err != tt.err || got != tt.want {
+				t.Errorf("want %v, %v; got %v, %v", tt.want, tt.err, got, err)
+			}
+		})
+	}
+}
+
+func TestVisible(t *testing.T) {
+	for i, tt := range []struct {
+		recipe models.Recipe
+		user   string
+		want   bool
+	}{
+		{models.Recipe{}, "", true},
+		{models.Recipe{Status: Published, Author: "alice"}, "bob", true},
+		{models.Recipe{Status: Draft, Author: "alice"}, "alice", true},
+		{models.Recipe{Status: Draft, Author: "alice"}, "bob", false},
+		{models.Recipe{Status: InReview, Author: "alice"}, "", false},
+		{models.Recipe{Status: Archived}, "", false},
+	} {
+		t.Run(fmt.Sprintf("%v", i), func(t *testing.T) {
+			if got := Visible(tt.recipe, tt.user); got != tt.want {
+				t.Errorf("want %v; got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestScheduled(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	later, earlier := now.Add(time.Hour), now.Add(-time.Hour)
+	for i, tt := range []struct {
+		recipe models.Recipe
+		want   bool
+	}{
+		{models.Recipe{Status: Draft}, false},
+		{models.Recipe{Status: Draft, PublishAt: &later}, true},
+		{models.Recipe{Status: Draft, PublishAt: &earlier}, false},
+		{models.Recipe{Status: Published, PublishAt: &later}, false},
+	} {
+		t.Run(fmt.Sprintf("%v", i), func(t *testing.T) {
+			if got := Scheduled(tt.recipe, now); got != tt.want {
+				t.Errorf("want %v; got %v", tt.want, got)
+			}
+		})
+	}
+}